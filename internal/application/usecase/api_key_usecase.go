@@ -0,0 +1,150 @@
+package usecase
+
+import (
+	"errors"
+	"slices"
+	"time"
+
+	"web-server/internal/domain/constants"
+	"web-server/internal/domain/entity"
+	"web-server/internal/domain/repository"
+
+	"github.com/google/uuid"
+)
+
+// APIKeyUseCase manages the API keys users authenticate scripts and
+// integrations with.
+type APIKeyUseCase struct {
+	keyRepo  repository.APIKeyRepository
+	userRepo repository.UserRepository
+}
+
+func NewAPIKeyUseCase(keyRepo repository.APIKeyRepository, userRepo repository.UserRepository) *APIKeyUseCase {
+	return &APIKeyUseCase{
+		keyRepo:  keyRepo,
+		userRepo: userRepo,
+	}
+}
+
+// Create issues a new API key for the user. The returned key is only
+// available now; afterwards only its hash is known. The admin scope only
+// keeps the owner's role, so it needs read or write next to it to allow any
+// request.
+func (uc *APIKeyUseCase) Create(userID, name string, scopes []string, expiresAt *time.Time) (string, *entity.APIKey, error) {
+	for _, scope := range scopes {
+		if !entity.IsValidAPIKeyScope(scope) {
+			return "", nil, constants.ErrInvalidAPIKeyScope
+		}
+	}
+	if slices.Contains(scopes, entity.APIKeyScopeAdmin) &&
+		!slices.Contains(scopes, entity.APIKeyScopeRead) &&
+		!slices.Contains(scopes, entity.APIKeyScopeWrite) {
+		return "", nil, constants.ErrInvalidAPIKeyScope
+	}
+
+	now := time.Now()
+	if expiresAt != nil && !expiresAt.After(now) {
+		return "", nil, constants.ErrInvalidAPIKey
+	}
+
+	key, prefix, hash, err := entity.GenerateAPIKey()
+	if err != nil {
+		return "", nil, err
+	}
+
+	if scopes == nil {
+		scopes = []string{}
+	}
+
+	apiKey := &entity.APIKey{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		Name:      name,
+		Prefix:    prefix,
+		KeyHash:   hash,
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+		CreatedAt: now,
+	}
+	if err := uc.keyRepo.Create(apiKey); err != nil {
+		return "", nil, err
+	}
+
+	return key, apiKey, nil
+}
+
+// List returns the API keys of the user, including revoked and expired ones.
+func (uc *APIKeyUseCase) List(userID string) ([]*entity.APIKey, error) {
+	return uc.keyRepo.ListByUser(userID)
+}
+
+// Revoke revokes an API key of the user. Keys of other users are reported as
+// not found.
+func (uc *APIKeyUseCase) Revoke(userID, keyID string) error {
+	key, err := uc.keyRepo.GetByID(keyID)
+	if err != nil {
+		return err
+	}
+
+	if key.UserID != userID {
+		return constants.ErrAPIKeyNotFound
+	}
+
+	return uc.keyRepo.Revoke(key.ID, time.Now())
+}
+
+// RevokeAll revokes every API key of the user that has not been revoked yet.
+func (uc *APIKeyUseCase) RevokeAll(userID string) error {
+	keys, err := uc.keyRepo.ListByUser(userID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, key := range keys {
+		if key.RevokedAt != nil {
+			continue
+		}
+		if err := uc.keyRepo.Revoke(key.ID, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AuthenticateAPIKey resolves an API key to its active key record and owner.
+func (uc *APIKeyUseCase) AuthenticateAPIKey(key string) (*entity.User, *entity.APIKey, error) {
+	apiKey, err := uc.keyRepo.GetByHash(entity.HashOneTimeToken(key))
+	if errors.Is(err, constants.ErrAPIKeyNotFound) {
+		return nil, nil, constants.ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	if !apiKey.IsActive(now) {
+		return nil, nil, constants.ErrInvalidAPIKey
+	}
+
+	// The role is read from the user on every request, so that role changes
+	// apply to existing keys immediately
+	user, err := uc.userRepo.GetByID(apiKey.UserID)
+	if errors.Is(err, constants.ErrUserNotFound) {
+		return nil, nil, constants.ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	// Like logins, keys stop working for accounts that are disabled or have
+	// to reset their password
+	if user.Disabled || user.PasswordResetRequired {
+		return nil, nil, constants.ErrInvalidAPIKey
+	}
+
+	if err := uc.keyRepo.TouchLastUsed(apiKey.ID, now); err != nil {
+		return nil, nil, err
+	}
+
+	return user, apiKey, nil
+}
@@ -0,0 +1,137 @@
+package usecase
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"web-server/internal/domain/constants"
+	"web-server/internal/domain/entity"
+	"web-server/internal/infrastructure/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestAPIKeyUseCase(t *testing.T) *APIKeyUseCase {
+	t.Helper()
+	userRepo := repository.NewInMemoryUserRepository()
+	require.NoError(t, userRepo.Create(&entity.User{ID: "1", Email: "user@example.com", Role: "user"}))
+	require.NoError(t, userRepo.Create(&entity.User{ID: "2", Email: "admin@example.com", Role: "admin"}))
+
+	return NewAPIKeyUseCase(repository.NewInMemoryAPIKeyRepository(), userRepo)
+}
+
+func TestAPIKeyUseCase_Lifecycle(t *testing.T) {
+	uc := newTestAPIKeyUseCase(t)
+
+	key, created, err := uc.Create("1", "CI", []string{entity.APIKeyScopeRead}, nil)
+	require.NoError(t, err)
+	assert.NotEqual(t, key, created.KeyHash)
+
+	user, apiKey, err := uc.AuthenticateAPIKey(key)
+	require.NoError(t, err)
+	assert.Equal(t, "1", user.ID)
+	assert.Equal(t, created.ID, apiKey.ID)
+
+	keys, err := uc.List("1")
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.NotNil(t, keys[0].LastUsedAt)
+
+	// Other users cannot revoke the key
+	assert.ErrorIs(t, uc.Revoke("2", created.ID), constants.ErrAPIKeyNotFound)
+
+	require.NoError(t, uc.Revoke("1", created.ID))
+	_, _, err = uc.AuthenticateAPIKey(key)
+	assert.ErrorIs(t, err, constants.ErrInvalidAPIKey)
+}
+
+func TestAPIKeyUseCase_RevokeAll(t *testing.T) {
+	uc := newTestAPIKeyUseCase(t)
+
+	first, _, err := uc.Create("1", "CI", nil, nil)
+	require.NoError(t, err)
+	second, _, err := uc.Create("1", "Deploy", nil, nil)
+	require.NoError(t, err)
+	other, _, err := uc.Create("2", "Admin", nil, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, uc.RevokeAll("1"))
+
+	for _, key := range []string{first, second} {
+		_, _, err = uc.AuthenticateAPIKey(key)
+		assert.ErrorIs(t, err, constants.ErrInvalidAPIKey)
+	}
+
+	// Keys of other users keep working
+	_, _, err = uc.AuthenticateAPIKey(other)
+	assert.NoError(t, err)
+}
+
+func TestAPIKeyUseCase_Create_Validation(t *testing.T) {
+	uc := newTestAPIKeyUseCase(t)
+	past := time.Now().Add(-time.Minute)
+
+	_, _, err := uc.Create("1", "CI", []string{"everything"}, nil)
+	assert.ErrorIs(t, err, constants.ErrInvalidAPIKeyScope)
+
+	_, _, err = uc.Create("1", "CI", nil, &past)
+	assert.ErrorIs(t, err, constants.ErrInvalidAPIKey)
+
+	// The admin scope on its own would allow no request at all
+	_, _, err = uc.Create("2", "Admin", []string{entity.APIKeyScopeAdmin}, nil)
+	assert.ErrorIs(t, err, constants.ErrInvalidAPIKeyScope)
+
+	_, created, err := uc.Create("2", "Admin", []string{entity.APIKeyScopeAdmin, entity.APIKeyScopeRead}, nil)
+	require.NoError(t, err)
+	assert.True(t, created.AllowsMethod(http.MethodGet))
+	assert.Equal(t, entity.RoleAdmin, created.Role(entity.RoleAdmin))
+}
+
+func TestAPIKeyUseCase_AuthenticateAPIKey(t *testing.T) {
+	uc := newTestAPIKeyUseCase(t)
+
+	t.Run("Rejects unknown key", func(t *testing.T) {
+		_, _, err := uc.AuthenticateAPIKey("wsk_unknown")
+		assert.ErrorIs(t, err, constants.ErrInvalidAPIKey)
+	})
+
+	t.Run("Rejects key of disabled user", func(t *testing.T) {
+		key, _, err := uc.Create("2", "Disabled owner", nil, nil)
+		require.NoError(t, err)
+
+		owner, err := uc.userRepo.GetByID("2")
+		require.NoError(t, err)
+		owner.Disabled = true
+		require.NoError(t, uc.userRepo.Update(owner))
+
+		_, _, err = uc.AuthenticateAPIKey(key)
+		assert.ErrorIs(t, err, constants.ErrInvalidAPIKey)
+	})
+
+	t.Run("Rejects expired key", func(t *testing.T) {
+		expiresAt := time.Now().Add(50 * time.Millisecond)
+		key, _, err := uc.Create("1", "Short lived", nil, &expiresAt)
+		require.NoError(t, err)
+
+		time.Sleep(60 * time.Millisecond)
+		_, _, err = uc.AuthenticateAPIKey(key)
+		assert.ErrorIs(t, err, constants.ErrInvalidAPIKey)
+	})
+
+	t.Run("Rejects key of user who must reset their password", func(t *testing.T) {
+		key, _, err := uc.Create("1", "Reset owner", nil, nil)
+		require.NoError(t, err)
+		_, _, err = uc.AuthenticateAPIKey(key)
+		require.NoError(t, err)
+
+		owner, err := uc.userRepo.GetByID("1")
+		require.NoError(t, err)
+		owner.PasswordResetRequired = true
+		require.NoError(t, uc.userRepo.Update(owner))
+
+		_, _, err = uc.AuthenticateAPIKey(key)
+		assert.ErrorIs(t, err, constants.ErrInvalidAPIKey)
+	})
+}
@@ -0,0 +1,134 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+
+	"web-server/internal/domain/constants"
+	"web-server/internal/domain/entity"
+	"web-server/internal/domain/repository"
+	"web-server/internal/domain/service"
+)
+
+// Email verification modes decide what users who have not verified their
+// email can do after logging in.
+const (
+	EmailVerificationOptional   = "optional"   // Log in normally
+	EmailVerificationRestricted = "restricted" // Log in with tokens limited to a few endpoints
+	EmailVerificationRequired   = "required"   // Cannot log in
+)
+
+// EmailVerificationUseCase confirms that users own the email address they
+// registered with, through a signed link sent to that address.
+type EmailVerificationUseCase struct {
+	userRepo  repository.UserRepository
+	tokens    service.EmailVerificationTokens
+	mailer    service.Mailer
+	verifyURL string // Page the token is appended to as the token query parameter
+	mode      string
+}
+
+func NewEmailVerificationUseCase(
+	userRepo repository.UserRepository,
+	tokens service.EmailVerificationTokens,
+	mailer service.Mailer,
+	verifyURL string,
+	mode string,
+) *EmailVerificationUseCase {
+	return &EmailVerificationUseCase{
+		userRepo:  userRepo,
+		tokens:    tokens,
+		mailer:    mailer,
+		verifyURL: verifyURL,
+		mode:      mode,
+	}
+}
+
+// SendVerification emails a verification link to the user's address.
+func (uc *EmailVerificationUseCase) SendVerification(user *entity.User) error {
+	token, err := uc.tokens.GenerateEmailVerificationToken(user.ID, user.Email)
+	if err != nil {
+		return err
+	}
+
+	link := uc.verifyURL + "?token=" + url.QueryEscape(token)
+	return uc.mailer.Send(service.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf(
+			"Hello %s,\n\nPlease confirm your email address by opening the link below.\n\n%s\n\n"+
+				"If you did not create an account, you can ignore this email.\n",
+			user.Username, link,
+		),
+	})
+}
+
+// Resend emails a new verification link. Unknown and already verified
+// addresses are ignored without an error, so callers cannot find out which
+// accounts exist.
+func (uc *EmailVerificationUseCase) Resend(email string) error {
+	user, err := uc.userRepo.GetByEmail(email)
+	if errors.Is(err, constants.ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if user.EmailVerified {
+		return nil
+	}
+
+	return uc.SendVerification(user)
+}
+
+// Verify marks the email of the user a verification token was issued for as
+// verified. Tokens sent to an address the user has since changed are rejected.
+func (uc *EmailVerificationUseCase) Verify(token string) (*entity.User, error) {
+	userID, email, err := uc.tokens.ValidateEmailVerificationToken(token)
+	if err != nil {
+		return nil, constants.ErrInvalidVerificationToken
+	}
+
+	user, err := uc.userRepo.GetByID(userID)
+	if errors.Is(err, constants.ErrUserNotFound) {
+		return nil, constants.ErrInvalidVerificationToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Email != email {
+		return nil, constants.ErrInvalidVerificationToken
+	}
+
+	if user.EmailVerified {
+		return user, nil
+	}
+
+	user.EmailVerified = true
+	if err := uc.userRepo.Update(user); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// CheckLogin decides whether the user may log in. It reports whether the
+// issued tokens must be restricted, or returns constants.ErrEmailNotVerified
+// if the user cannot log in at all. Unknown modes are treated as required.
+func (uc *EmailVerificationUseCase) CheckLogin(user *entity.User) (restricted bool, err error) {
+	if user.EmailVerified {
+		return false, nil
+	}
+
+	switch uc.mode {
+	case EmailVerificationOptional:
+		return false, nil
+	case EmailVerificationRestricted:
+		return true, nil
+	default:
+		return false, constants.ErrEmailNotVerified
+	}
+}
@@ -0,0 +1,132 @@
+package usecase
+
+import (
+	"strings"
+	"testing"
+
+	"web-server/internal/domain/constants"
+	"web-server/internal/domain/entity"
+	"web-server/internal/infrastructure/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeVerificationTokens issues readable, unsigned verification tokens.
+type fakeVerificationTokens struct{}
+
+func (fakeVerificationTokens) GenerateEmailVerificationToken(userID, email string) (string, error) {
+	return userID + "|" + email, nil
+}
+
+func (fakeVerificationTokens) ValidateEmailVerificationToken(token string) (string, string, error) {
+	userID, email, found := strings.Cut(token, "|")
+	if !found {
+		return "", "", constants.ErrInvalidVerificationToken
+	}
+	return userID, email, nil
+}
+
+func newTestEmailVerificationUseCase(t *testing.T, mode string) (*EmailVerificationUseCase, *repository.InMemoryUserRepository, *recordingMailer) {
+	t.Helper()
+	userRepo := repository.NewInMemoryUserRepository()
+	require.NoError(t, userRepo.Create(&entity.User{ID: "1", Email: "user@example.com", Username: "user"}))
+
+	mailer := &recordingMailer{}
+	uc := NewEmailVerificationUseCase(
+		userRepo,
+		fakeVerificationTokens{},
+		mailer,
+		"https://app.example.com/verify-email",
+		mode,
+	)
+	return uc, userRepo, mailer
+}
+
+func TestEmailVerificationUseCase_Verify(t *testing.T) {
+	uc, userRepo, mailer := newTestEmailVerificationUseCase(t, EmailVerificationOptional)
+
+	require.NoError(t, uc.Resend("user@example.com"))
+	require.Len(t, mailer.messages, 1)
+	token := tokenFromLink(t, mailer.messages[0])
+	assert.Equal(t, "1|user@example.com", token)
+
+	user, err := uc.Verify(token)
+	require.NoError(t, err)
+	assert.True(t, user.EmailVerified)
+
+	stored, err := userRepo.GetByID("1")
+	require.NoError(t, err)
+	assert.True(t, stored.EmailVerified)
+
+	// Verified users do not get another link
+	require.NoError(t, uc.Resend("user@example.com"))
+	assert.Len(t, mailer.messages, 1)
+}
+
+func TestEmailVerificationUseCase_RejectsInvalidTokens(t *testing.T) {
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{
+			name:  "Malformed token",
+			token: "garbage",
+		},
+		{
+			name:  "Unknown user",
+			token: "2|user@example.com",
+		},
+		{
+			name:  "Address changed since the link was sent",
+			token: "1|old@example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc, _, _ := newTestEmailVerificationUseCase(t, EmailVerificationOptional)
+
+			_, err := uc.Verify(tt.token)
+			assert.ErrorIs(t, err, constants.ErrInvalidVerificationToken)
+		})
+	}
+}
+
+func TestEmailVerificationUseCase_Resend_UnknownEmail(t *testing.T) {
+	uc, _, mailer := newTestEmailVerificationUseCase(t, EmailVerificationOptional)
+
+	assert.NoError(t, uc.Resend("nobody@example.com"))
+	assert.Empty(t, mailer.messages)
+}
+
+func TestEmailVerificationUseCase_CheckLogin(t *testing.T) {
+	tests := []struct {
+		name           string
+		mode           string
+		verified       bool
+		wantRestricted bool
+		wantErr        error
+	}{
+		{name: "Optional", mode: EmailVerificationOptional},
+		{name: "Restricted", mode: EmailVerificationRestricted, wantRestricted: true},
+		{name: "Required", mode: EmailVerificationRequired, wantErr: constants.ErrEmailNotVerified},
+		{name: "Unknown mode fails closed", mode: "typo", wantErr: constants.ErrEmailNotVerified},
+		{name: "Verified user in required mode", mode: EmailVerificationRequired, verified: true},
+		{name: "Verified user in restricted mode", mode: EmailVerificationRestricted, verified: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc, _, _ := newTestEmailVerificationUseCase(t, tt.mode)
+
+			restricted, err := uc.CheckLogin(&entity.User{EmailVerified: tt.verified})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantRestricted, restricted)
+		})
+	}
+}
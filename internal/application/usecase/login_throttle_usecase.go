@@ -0,0 +1,153 @@
+package usecase
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"web-server/internal/domain/constants"
+	"web-server/internal/domain/entity"
+	"web-server/internal/domain/repository"
+)
+
+// LoginThrottleUseCase slows down password guessing. Failed logins are counted
+// per email address and per client IP, and each key is delayed and eventually
+// locked out according to its policy. Email addresses are counted whether or
+// not an account exists, so the lockout does not reveal which accounts exist.
+type LoginThrottleUseCase struct {
+	attemptRepo   repository.LoginAttemptRepository
+	accountPolicy entity.LoginThrottlePolicy
+	ipPolicy      entity.LoginThrottlePolicy
+	scope         string // Prefix of the keys, separates the counters of other login methods
+}
+
+func NewLoginThrottleUseCase(
+	attemptRepo repository.LoginAttemptRepository,
+	accountPolicy entity.LoginThrottlePolicy,
+	ipPolicy entity.LoginThrottlePolicy,
+) *LoginThrottleUseCase {
+	return &LoginThrottleUseCase{
+		attemptRepo:   attemptRepo,
+		accountPolicy: accountPolicy,
+		ipPolicy:      ipPolicy,
+	}
+}
+
+// WithScope returns a throttle with the same policies that counts attempts
+// separately, for example the requests of another login method.
+func (uc *LoginThrottleUseCase) WithScope(scope string) *LoginThrottleUseCase {
+	scoped := *uc
+	scoped.scope = scope + ":"
+	return &scoped
+}
+
+// Attempt counts a login attempt for the email and the IP before the
+// credentials are checked, and returns constants.ErrTooManyLoginAttempts and
+// the time to wait if either may not log in yet. The lockout is checked on the
+// very count the attempt increments, so concurrent guesses cannot all pass the
+// check before their failures are counted. Successful attempts are taken back
+// by RecordSuccess.
+func (uc *LoginThrottleUseCase) Attempt(email, ip string) (time.Duration, error) {
+	now := time.Now()
+
+	var counted []string
+	for _, key := range uc.keys(email, ip) {
+		retryAfter, err := uc.count(key, now)
+		if err == nil && retryAfter == 0 {
+			counted = append(counted, key.name)
+			continue
+		}
+
+		// A rejected attempt is not counted against the other keys either
+		for _, name := range counted {
+			if forgetErr := uc.attemptRepo.ForgetFailure(name); forgetErr != nil && err == nil {
+				err = forgetErr
+			}
+		}
+
+		if err != nil {
+			return 0, err
+		}
+		return retryAfter, constants.ErrTooManyLoginAttempts
+	}
+
+	return 0, nil
+}
+
+// RecordSuccess clears the failures of the email and takes back the attempt
+// counted for the IP, so that logging in to an own account neither resets nor
+// adds to the failures of the IP.
+func (uc *LoginThrottleUseCase) RecordSuccess(email, ip string) error {
+	if err := uc.attemptRepo.Reset(uc.scope + accountKey(email)); err != nil {
+		return err
+	}
+
+	return uc.attemptRepo.ForgetFailure(uc.scope + ipKey(ip))
+}
+
+// Unlock clears the failures and any lockout of the email.
+func (uc *LoginThrottleUseCase) Unlock(email string) error {
+	return uc.attemptRepo.Reset(uc.scope + accountKey(email))
+}
+
+type throttleKey struct {
+	name   string
+	policy entity.LoginThrottlePolicy
+}
+
+// keys returns the IP first, so that attempts from a locked IP are not counted
+// against the accounts it guesses at.
+func (uc *LoginThrottleUseCase) keys(email, ip string) []throttleKey {
+	return []throttleKey{
+		{name: uc.scope + ipKey(ip), policy: uc.ipPolicy},
+		{name: uc.scope + accountKey(email), policy: uc.accountPolicy},
+	}
+}
+
+// count adds an attempt to the key, or returns the time to wait if the key is
+// locked.
+func (uc *LoginThrottleUseCase) count(key throttleKey, now time.Time) (time.Duration, error) {
+	for {
+		attempt, err := uc.getAttempt(key.name)
+		if err != nil {
+			return 0, err
+		}
+
+		if wait := key.policy.LockedUntil(attempt).Sub(now); wait > 0 {
+			return wait, nil
+		}
+
+		old, failures := 0, 1
+		if attempt != nil {
+			old = attempt.Failures
+			// Start counting again once old failures no longer matter
+			if !key.policy.IsExpired(attempt, now) {
+				failures = attempt.Failures + 1
+			}
+		}
+
+		err = uc.attemptRepo.SwapFailures(key.name, old, failures, now)
+		if !errors.Is(err, constants.ErrLoginAttemptChanged) {
+			return 0, err
+		}
+
+		// Another attempt was counted in the meantime, check the new count
+	}
+}
+
+// getAttempt returns nil if the key has no failures.
+func (uc *LoginThrottleUseCase) getAttempt(key string) (*entity.LoginAttempt, error) {
+	attempt, err := uc.attemptRepo.Get(key)
+	if errors.Is(err, constants.ErrLoginAttemptNotFound) {
+		return nil, nil
+	}
+	return attempt, err
+}
+
+func accountKey(email string) string {
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
@@ -0,0 +1,145 @@
+package usecase
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"web-server/internal/domain/constants"
+	"web-server/internal/domain/entity"
+	"web-server/internal/infrastructure/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLoginThrottleUseCase() *LoginThrottleUseCase {
+	return NewLoginThrottleUseCase(
+		repository.NewInMemoryLoginAttemptRepository(),
+		entity.LoginThrottlePolicy{FreeAttempts: 2, MaxFailures: 3, BaseDelay: time.Minute, LockoutDuration: time.Hour},
+		entity.LoginThrottlePolicy{FreeAttempts: 4, MaxFailures: 5, BaseDelay: time.Minute, LockoutDuration: time.Hour},
+	)
+}
+
+func TestLoginThrottleUseCase_LocksAccount(t *testing.T) {
+	uc := newTestLoginThrottleUseCase()
+
+	for i := 0; i < 2; i++ {
+		_, err := uc.Attempt("user@example.com", "10.0.0.1")
+		require.NoError(t, err)
+	}
+
+	// The third failure locks the account, whichever IP it comes from
+	_, err := uc.Attempt("user@example.com", "10.0.0.2")
+	require.NoError(t, err)
+	retryAfter, err := uc.Attempt("User@Example.com", "10.0.0.3")
+	assert.ErrorIs(t, err, constants.ErrTooManyLoginAttempts)
+	assert.InDelta(t, time.Hour.Seconds(), retryAfter.Seconds(), 1)
+
+	// Other accounts are not affected
+	_, err = uc.Attempt("other@example.com", "10.0.0.3")
+	assert.NoError(t, err)
+
+	require.NoError(t, uc.Unlock("user@example.com"))
+	_, err = uc.Attempt("user@example.com", "10.0.0.3")
+	assert.NoError(t, err)
+}
+
+func TestLoginThrottleUseCase_LocksIP(t *testing.T) {
+	uc := newTestLoginThrottleUseCase()
+
+	// Spraying guesses over many accounts still counts against the IP
+	for _, email := range []string{"a@example.com", "b@example.com", "c@example.com", "d@example.com", "e@example.com"} {
+		_, err := uc.Attempt(email, "10.0.0.1")
+		require.NoError(t, err)
+	}
+
+	_, err := uc.Attempt("f@example.com", "10.0.0.1")
+	assert.ErrorIs(t, err, constants.ErrTooManyLoginAttempts)
+
+	// Attempts from the locked IP are not counted against the account
+	for i := 0; i < 2; i++ {
+		_, err = uc.Attempt("f@example.com", "10.0.0.2")
+		assert.NoError(t, err)
+	}
+}
+
+func TestLoginThrottleUseCase_SuccessResetsAccountOnly(t *testing.T) {
+	uc := newTestLoginThrottleUseCase()
+
+	for i := 0; i < 2; i++ {
+		_, err := uc.Attempt("user@example.com", "10.0.0.1")
+		require.NoError(t, err)
+	}
+	_, err := uc.Attempt("user@example.com", "10.0.0.1")
+	require.NoError(t, err)
+	require.NoError(t, uc.RecordSuccess("user@example.com", "10.0.0.1"))
+
+	// Two more failures are free again for the account, but not for the IP,
+	// which keeps the two failures before the successful login attempt
+	for i := 0; i < 2; i++ {
+		_, err := uc.Attempt("user@example.com", "10.0.0.2")
+		require.NoError(t, err)
+	}
+	_, err = uc.Attempt("user@example.com", "10.0.0.2")
+	assert.NoError(t, err)
+
+	for _, email := range []string{"other@example.com", "third@example.com", "fourth@example.com"} {
+		_, err := uc.Attempt(email, "10.0.0.1")
+		require.NoError(t, err)
+	}
+	_, err = uc.Attempt("fifth@example.com", "10.0.0.1")
+	assert.ErrorIs(t, err, constants.ErrTooManyLoginAttempts)
+}
+
+func TestLoginThrottleUseCase_ConcurrentAttempts(t *testing.T) {
+	uc := newTestLoginThrottleUseCase()
+
+	// Parallel guesses cannot pass the check before their failures are
+	// counted: only as many get through as the policy allows before the
+	// lockout, however they interleave
+	var allowed, locked int
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := uc.Attempt("user@example.com", "10.0.0.1")
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			if err == nil {
+				allowed++
+			} else {
+				require.ErrorIs(t, err, constants.ErrTooManyLoginAttempts)
+				locked++
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 3, allowed)
+	assert.Equal(t, 17, locked)
+}
+
+func TestLoginThrottleUseCase_WithScope(t *testing.T) {
+	uc := newTestLoginThrottleUseCase()
+	magic := uc.WithScope("magic")
+
+	for i := 0; i < 3; i++ {
+		_, err := magic.Attempt("user@example.com", "10.0.0.1")
+		require.NoError(t, err)
+	}
+
+	_, err := magic.Attempt("user@example.com", "10.0.0.1")
+	assert.ErrorIs(t, err, constants.ErrTooManyLoginAttempts)
+
+	// Password logins are counted separately
+	_, err = uc.Attempt("user@example.com", "10.0.0.1")
+	assert.NoError(t, err)
+
+	require.NoError(t, magic.Unlock("user@example.com"))
+	_, err = magic.Attempt("user@example.com", "10.0.0.2")
+	assert.NoError(t, err)
+}
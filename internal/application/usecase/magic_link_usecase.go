@@ -0,0 +1,140 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"web-server/internal/domain/constants"
+	"web-server/internal/domain/entity"
+	"web-server/internal/domain/repository"
+	"web-server/internal/domain/service"
+
+	"github.com/google/uuid"
+)
+
+// MagicLinkUseCase lets users log in without a password through a signed,
+// single-use link sent by email. Opening the link proves ownership of the
+// address, so redeeming it also verifies the email.
+type MagicLinkUseCase struct {
+	userRepo   repository.UserRepository
+	linkRepo   repository.LoginLinkRepository
+	tokens     service.LoginLinkTokens
+	mailer     service.Mailer
+	loginURL   string        // Page the token is appended to as the token query parameter
+	expiration time.Duration // Lifetime of a login link
+}
+
+func NewMagicLinkUseCase(
+	userRepo repository.UserRepository,
+	linkRepo repository.LoginLinkRepository,
+	tokens service.LoginLinkTokens,
+	mailer service.Mailer,
+	loginURL string,
+	expiration time.Duration,
+) *MagicLinkUseCase {
+	return &MagicLinkUseCase{
+		userRepo:   userRepo,
+		linkRepo:   linkRepo,
+		tokens:     tokens,
+		mailer:     mailer,
+		loginURL:   loginURL,
+		expiration: expiration,
+	}
+}
+
+// RequestLink emails a login link to the user with the given email. Unknown
+// emails and disabled accounts are ignored without an error, so callers cannot
+// find out which accounts exist.
+func (uc *MagicLinkUseCase) RequestLink(email string) error {
+	user, err := uc.userRepo.GetByEmail(email)
+	if errors.Is(err, constants.ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if user.Disabled {
+		return nil
+	}
+
+	now := time.Now()
+	link := &entity.LoginLink{
+		ID:        uuid.New().String(),
+		UserID:    user.ID,
+		ExpiresAt: now.Add(uc.expiration),
+		CreatedAt: now,
+	}
+	if err := uc.linkRepo.Create(link); err != nil {
+		return err
+	}
+
+	token, err := uc.tokens.GenerateLoginLinkToken(user.ID, link.ID, link.ExpiresAt)
+	if err != nil {
+		return err
+	}
+
+	return uc.mailer.Send(service.Message{
+		To:      user.Email,
+		Subject: "Your login link",
+		Body: fmt.Sprintf(
+			"Hello %s,\n\nUse the link below to log in. It works once and expires in %s.\n\n%s\n\n"+
+				"If you did not ask to log in, you can ignore this email.\n",
+			user.Username, uc.expiration, uc.loginURL+"?token="+url.QueryEscape(token),
+		),
+	})
+}
+
+// Redeem claims the login link a token was issued for and returns its user.
+// Every other login link of the user becomes invalid.
+// Expired, unknown and already used links are rejected with
+// constants.ErrInvalidLoginLink. The caller decides whether the user may log
+// in, like after checking a password.
+func (uc *MagicLinkUseCase) Redeem(token string) (*entity.User, error) {
+	userID, linkID, err := uc.tokens.ValidateLoginLinkToken(token)
+	if err != nil {
+		return nil, constants.ErrInvalidLoginLink
+	}
+
+	link, err := uc.linkRepo.GetByID(linkID)
+	if errors.Is(err, constants.ErrLoginLinkNotFound) {
+		return nil, constants.ErrInvalidLoginLink
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if link.UserID != userID || !link.IsValid(now) {
+		return nil, constants.ErrInvalidLoginLink
+	}
+
+	// Claim the link before logging in, so it works only once
+	if err := uc.linkRepo.MarkUsed(link.ID, now); err != nil {
+		return nil, err
+	}
+
+	user, err := uc.userRepo.GetByID(userID)
+	if errors.Is(err, constants.ErrUserNotFound) {
+		return nil, constants.ErrInvalidLoginLink
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Links sent before this login stop working
+	if err := uc.linkRepo.DeleteByUser(user.ID); err != nil {
+		return nil, err
+	}
+
+	if !user.EmailVerified {
+		user.EmailVerified = true
+		if err := uc.userRepo.Update(user); err != nil {
+			return nil, err
+		}
+	}
+
+	return user, nil
+}
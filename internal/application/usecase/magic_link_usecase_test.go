@@ -0,0 +1,129 @@
+package usecase
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"web-server/internal/domain/constants"
+	"web-server/internal/domain/entity"
+	"web-server/internal/infrastructure/mailer"
+	"web-server/internal/infrastructure/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeLoginLinkTokens issues readable, unsigned login link tokens. Expiry is
+// left to the stored link.
+type fakeLoginLinkTokens struct{}
+
+func (fakeLoginLinkTokens) GenerateLoginLinkToken(userID, linkID string, expiresAt time.Time) (string, error) {
+	return userID + "|" + linkID, nil
+}
+
+func (fakeLoginLinkTokens) ValidateLoginLinkToken(token string) (string, string, error) {
+	userID, linkID, found := strings.Cut(token, "|")
+	if !found {
+		return "", "", constants.ErrInvalidLoginLink
+	}
+	return userID, linkID, nil
+}
+
+func newTestMagicLinkUseCase(t *testing.T, expiration time.Duration) (*MagicLinkUseCase, *repository.InMemoryUserRepository, *mailer.MemoryMailer) {
+	t.Helper()
+	userRepo := repository.NewInMemoryUserRepository()
+	require.NoError(t, userRepo.Create(&entity.User{ID: "1", Email: "user@example.com", Username: "user"}))
+	require.NoError(t, userRepo.Create(&entity.User{ID: "2", Email: "disabled@example.com", Username: "disabled", Disabled: true}))
+
+	memoryMailer := mailer.NewMemoryMailer()
+	uc := NewMagicLinkUseCase(
+		userRepo,
+		repository.NewInMemoryLoginLinkRepository(),
+		fakeLoginLinkTokens{},
+		memoryMailer,
+		"https://app.example.com/magic-login",
+		expiration,
+	)
+	return uc, userRepo, memoryMailer
+}
+
+func TestMagicLinkUseCase_Redeem(t *testing.T) {
+	uc, userRepo, memoryMailer := newTestMagicLinkUseCase(t, time.Hour)
+
+	require.NoError(t, uc.RequestLink("user@example.com"))
+	messages := memoryMailer.Messages()
+	require.Len(t, messages, 1)
+	assert.Equal(t, "user@example.com", messages[0].To)
+	token := tokenFromLink(t, messages[0])
+
+	user, err := uc.Redeem(token)
+	require.NoError(t, err)
+	assert.Equal(t, "1", user.ID)
+
+	// Opening the link proves the user owns the address
+	stored, err := userRepo.GetByID("1")
+	require.NoError(t, err)
+	assert.True(t, stored.EmailVerified)
+
+	// The link works only once
+	_, err = uc.Redeem(token)
+	assert.ErrorIs(t, err, constants.ErrInvalidLoginLink)
+}
+
+func TestMagicLinkUseCase_IgnoresUnknownAndDisabledUsers(t *testing.T) {
+	uc, _, memoryMailer := newTestMagicLinkUseCase(t, time.Hour)
+
+	require.NoError(t, uc.RequestLink("unknown@example.com"))
+	require.NoError(t, uc.RequestLink("disabled@example.com"))
+	assert.Empty(t, memoryMailer.Messages())
+}
+
+func TestMagicLinkUseCase_RejectsInvalidLinks(t *testing.T) {
+	tests := []struct {
+		name       string
+		expiration time.Duration
+		token      func(t *testing.T, memoryMailer *mailer.MemoryMailer) string
+	}{
+		{
+			name:       "Malformed token",
+			expiration: time.Hour,
+			token: func(t *testing.T, memoryMailer *mailer.MemoryMailer) string {
+				return "garbage"
+			},
+		},
+		{
+			name:       "Unknown link",
+			expiration: time.Hour,
+			token: func(t *testing.T, memoryMailer *mailer.MemoryMailer) string {
+				return "1|unknown"
+			},
+		},
+		{
+			name:       "Expired link",
+			expiration: -time.Minute,
+			token: func(t *testing.T, memoryMailer *mailer.MemoryMailer) string {
+				return tokenFromLink(t, memoryMailer.Messages()[0])
+			},
+		},
+		{
+			name:       "Link of another user",
+			expiration: time.Hour,
+			token: func(t *testing.T, memoryMailer *mailer.MemoryMailer) string {
+				token := tokenFromLink(t, memoryMailer.Messages()[0])
+				_, linkID, _ := strings.Cut(token, "|")
+				return "2|" + linkID
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc, _, memoryMailer := newTestMagicLinkUseCase(t, tt.expiration)
+			require.NoError(t, uc.RequestLink("user@example.com"))
+
+			_, err := uc.Redeem(tt.token(t, memoryMailer))
+			assert.ErrorIs(t, err, constants.ErrInvalidLoginLink)
+		})
+	}
+}
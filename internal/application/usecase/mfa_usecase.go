@@ -0,0 +1,249 @@
+package usecase
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"web-server/internal/domain/constants"
+	"web-server/internal/domain/entity"
+	"web-server/internal/domain/repository"
+)
+
+// MFAUseCase manages TOTP second factors and the roles that require them.
+type MFAUseCase struct {
+	userRepo      repository.UserRepository
+	policyRepo    repository.MFAPolicyRepository
+	secretKey     []byte   // Key TOTP secrets are encrypted with
+	issuer        string   // Issuer shown in authenticator apps
+	requiredRoles []string // Roles configured to require MFA in addition to the policy repository
+}
+
+func NewMFAUseCase(
+	userRepo repository.UserRepository,
+	policyRepo repository.MFAPolicyRepository,
+	secretKey []byte,
+	issuer string,
+	requiredRoles []string,
+) *MFAUseCase {
+	return &MFAUseCase{
+		userRepo:      userRepo,
+		policyRepo:    policyRepo,
+		secretKey:     secretKey,
+		issuer:        issuer,
+		requiredRoles: requiredRoles,
+	}
+}
+
+// BeginEnrollment generates a new TOTP secret for the user and stores it until
+// the user confirms it with a code. It returns the secret and the otpauth URI.
+func (uc *MFAUseCase) BeginEnrollment(userID string) (secret, uri string, err error) {
+	user, err := uc.userRepo.GetByID(userID)
+	if err != nil {
+		return "", "", err
+	}
+
+	if user.MFA.Enabled {
+		return "", "", constants.ErrMFAAlreadyEnabled
+	}
+
+	secret, err = entity.GenerateTOTPSecret()
+	if err != nil {
+		return "", "", err
+	}
+
+	encrypted, err := entity.EncryptSecret(uc.secretKey, secret)
+	if err != nil {
+		return "", "", err
+	}
+
+	// Concurrent enrollments must not leave the user with a secret other than
+	// the one shown to them
+	if err := uc.userRepo.SwapMFA(user.ID, user.MFA, entity.MFASettings{Secret: encrypted}); err != nil {
+		return "", "", err
+	}
+
+	return secret, entity.TOTPProvisioningURI(secret, uc.issuer, user.Email), nil
+}
+
+// ConfirmEnrollment enables MFA once the user proves the authenticator works.
+// It returns the recovery codes, which are only shown this once.
+func (uc *MFAUseCase) ConfirmEnrollment(userID, code string) ([]string, error) {
+	user, err := uc.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return uc.confirmEnrollment(user, code)
+}
+
+func (uc *MFAUseCase) confirmEnrollment(user *entity.User, code string) ([]string, error) {
+	if user.MFA.Enabled {
+		return nil, constants.ErrMFAAlreadyEnabled
+	}
+
+	if user.MFA.Secret == "" {
+		return nil, constants.ErrMFANotEnrolled
+	}
+
+	mfa := user.MFA
+	if !uc.checkTOTP(&mfa, code) {
+		return nil, constants.ErrInvalidMFACode
+	}
+
+	codes, hashes, err := entity.GenerateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	mfa.Enabled = true
+	mfa.RecoveryCodes = hashes
+	if err := uc.swapMFA(user, mfa); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// Disable turns MFA off after checking a current code or recovery code. Users
+// whose role requires MFA cannot disable it.
+func (uc *MFAUseCase) Disable(userID, code string) error {
+	user, err := uc.userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+
+	if !user.MFA.Enabled {
+		return constants.ErrMFANotEnrolled
+	}
+
+	required, err := uc.isRoleRequired(user.Role)
+	if err != nil {
+		return err
+	}
+	if required {
+		return constants.ErrMFARequired
+	}
+
+	mfa := user.MFA
+	if !uc.checkCode(&mfa, code) {
+		return constants.ErrInvalidMFACode
+	}
+
+	return uc.swapMFA(user, entity.MFASettings{})
+}
+
+// IsRequired reports whether the user has to pass a second factor to log in.
+func (uc *MFAUseCase) IsRequired(user *entity.User) (bool, error) {
+	if user.MFA.Enabled {
+		return true, nil
+	}
+	return uc.isRoleRequired(user.Role)
+}
+
+// Verify checks the second factor of a login. For a user who started but did
+// not confirm enrollment, a valid code completes the enrollment and the new
+// recovery codes are returned.
+func (uc *MFAUseCase) Verify(userID, code string) (*entity.User, []string, error) {
+	user, err := uc.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !user.MFA.Enabled {
+		codes, err := uc.confirmEnrollment(user, code)
+		if err != nil {
+			return nil, nil, err
+		}
+		return user, codes, nil
+	}
+
+	mfa := user.MFA
+	if !uc.checkCode(&mfa, code) {
+		return nil, nil, constants.ErrInvalidMFACode
+	}
+
+	// Persist the used time step or the consumed recovery code
+	if err := uc.swapMFA(user, mfa); err != nil {
+		return nil, nil, err
+	}
+
+	return user, nil, nil
+}
+
+// RequiredRoles lists the roles that require MFA, from config and the policy repository.
+func (uc *MFAUseCase) RequiredRoles() ([]string, error) {
+	stored, err := uc.policyRepo.ListRequiredRoles()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	roles := make([]string, 0, len(stored)+len(uc.requiredRoles))
+	for _, role := range append(stored, uc.requiredRoles...) {
+		if !seen[role] {
+			seen[role] = true
+			roles = append(roles, role)
+		}
+	}
+	sort.Strings(roles)
+
+	return roles, nil
+}
+
+// SetRoleRequired changes whether a role requires MFA. Roles required by the
+// configuration stay required.
+func (uc *MFAUseCase) SetRoleRequired(role string, required bool) error {
+	return uc.policyRepo.SetRoleRequired(role, required)
+}
+
+func (uc *MFAUseCase) isRoleRequired(role string) (bool, error) {
+	roles, err := uc.RequiredRoles()
+	if err != nil {
+		return false, err
+	}
+
+	for _, r := range roles {
+		if r == role {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// swapMFA stores the new MFA settings of the user, provided no concurrent
+// request changed them since the user was read. A lost race is reported as an
+// invalid code, since the code was used by the other request.
+func (uc *MFAUseCase) swapMFA(user *entity.User, mfa entity.MFASettings) error {
+	err := uc.userRepo.SwapMFA(user.ID, user.MFA, mfa)
+	if errors.Is(err, constants.ErrMFAChanged) {
+		return constants.ErrInvalidMFACode
+	}
+	if err != nil {
+		return err
+	}
+
+	user.MFA = mfa
+	return nil
+}
+
+// checkCode accepts either a TOTP code or an unused recovery code.
+func (uc *MFAUseCase) checkCode(mfa *entity.MFASettings, code string) bool {
+	return uc.checkTOTP(mfa, code) || mfa.UseRecoveryCode(code)
+}
+
+// checkTOTP validates a TOTP code and records its time step so it cannot be replayed.
+func (uc *MFAUseCase) checkTOTP(mfa *entity.MFASettings, code string) bool {
+	secret, err := entity.DecryptSecret(uc.secretKey, mfa.Secret)
+	if err != nil {
+		return false
+	}
+
+	step, ok := entity.ValidateTOTP(secret, code, time.Now(), mfa.LastUsedStep)
+	if !ok {
+		return false
+	}
+
+	mfa.LastUsedStep = step
+	return true
+}
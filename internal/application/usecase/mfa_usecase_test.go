@@ -0,0 +1,202 @@
+package usecase
+
+import (
+	"bytes"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"web-server/internal/domain/constants"
+	"web-server/internal/domain/entity"
+	"web-server/internal/infrastructure/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestMFAUseCase(t *testing.T, requiredRoles ...string) (*MFAUseCase, *repository.InMemoryUserRepository) {
+	t.Helper()
+	userRepo := repository.NewInMemoryUserRepository()
+	require.NoError(t, userRepo.Create(&entity.User{ID: "1", Email: "user@example.com", Role: "user"}))
+	require.NoError(t, userRepo.Create(&entity.User{ID: "2", Email: "admin@example.com", Role: "admin"}))
+
+	uc := NewMFAUseCase(
+		userRepo,
+		repository.NewInMemoryMFAPolicyRepository(),
+		bytes.Repeat([]byte{7}, 32),
+		"Test",
+		requiredRoles,
+	)
+	return uc, userRepo
+}
+
+func currentCode(t *testing.T, secret string) string {
+	t.Helper()
+	code, err := entity.TOTPCode(secret, entity.TOTPStep(time.Now()))
+	require.NoError(t, err)
+	return code
+}
+
+func TestMFAUseCase_Enrollment(t *testing.T) {
+	uc, userRepo := newTestMFAUseCase(t)
+
+	secret, uri, err := uc.BeginEnrollment("1")
+	require.NoError(t, err)
+	assert.Contains(t, uri, "secret="+secret)
+
+	// The secret is stored encrypted and MFA is not enabled before confirmation
+	user, err := userRepo.GetByID("1")
+	require.NoError(t, err)
+	assert.NotEqual(t, secret, user.MFA.Secret)
+	assert.False(t, user.MFA.Enabled)
+
+	_, err = uc.ConfirmEnrollment("1", "000000")
+	assert.ErrorIs(t, err, constants.ErrInvalidMFACode)
+
+	codes, err := uc.ConfirmEnrollment("1", currentCode(t, secret))
+	require.NoError(t, err)
+	assert.Len(t, codes, entity.RecoveryCodesCount)
+
+	user, err = userRepo.GetByID("1")
+	require.NoError(t, err)
+	required, err := uc.IsRequired(user)
+	require.NoError(t, err)
+	assert.True(t, required)
+
+	_, _, err = uc.BeginEnrollment("1")
+	assert.ErrorIs(t, err, constants.ErrMFAAlreadyEnabled)
+}
+
+func TestMFAUseCase_Verify(t *testing.T) {
+	uc, _ := newTestMFAUseCase(t)
+	secret, _, err := uc.BeginEnrollment("1")
+	require.NoError(t, err)
+
+	// Confirm with the previous time step so the current code is still unused
+	previous, err := entity.TOTPCode(secret, entity.TOTPStep(time.Now())-1)
+	require.NoError(t, err)
+	recoveryCodes, err := uc.ConfirmEnrollment("1", previous)
+	require.NoError(t, err)
+
+	t.Run("Accepts TOTP code once", func(t *testing.T) {
+		code := currentCode(t, secret)
+		user, _, err := uc.Verify("1", code)
+		require.NoError(t, err)
+		assert.Equal(t, "1", user.ID)
+
+		_, _, err = uc.Verify("1", code)
+		assert.ErrorIs(t, err, constants.ErrInvalidMFACode)
+	})
+
+	t.Run("Accepts recovery code once", func(t *testing.T) {
+		_, _, err := uc.Verify("1", recoveryCodes[0])
+		require.NoError(t, err)
+
+		_, _, err = uc.Verify("1", recoveryCodes[0])
+		assert.ErrorIs(t, err, constants.ErrInvalidMFACode)
+	})
+}
+
+func TestMFAUseCase_VerifyConcurrently(t *testing.T) {
+	uc, userRepo := newTestMFAUseCase(t)
+	secret, _, err := uc.BeginEnrollment("1")
+	require.NoError(t, err)
+	previous, err := entity.TOTPCode(secret, entity.TOTPStep(time.Now())-1)
+	require.NoError(t, err)
+	recoveryCodes, err := uc.ConfirmEnrollment("1", previous)
+	require.NoError(t, err)
+
+	for name, code := range map[string]string{
+		"TOTP code":     currentCode(t, secret),
+		"Recovery code": recoveryCodes[0],
+	} {
+		t.Run(name+" is accepted by one request only", func(t *testing.T) {
+			var (
+				wg       sync.WaitGroup
+				accepted atomic.Int32
+			)
+			for i := 0; i < 20; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if _, _, err := uc.Verify("1", code); err == nil {
+						accepted.Add(1)
+					} else {
+						assert.ErrorIs(t, err, constants.ErrInvalidMFACode)
+					}
+				}()
+			}
+			wg.Wait()
+
+			assert.Equal(t, int32(1), accepted.Load())
+		})
+	}
+
+	t.Run("Verification leaves other fields alone", func(t *testing.T) {
+		user, err := userRepo.GetByID("1")
+		require.NoError(t, err)
+		stale := *user
+
+		// An admin disables the account while the code is checked
+		user.Disabled = true
+		require.NoError(t, userRepo.Update(user))
+		_, _, err = uc.Verify("1", recoveryCodes[1])
+		require.NoError(t, err)
+
+		user, err = userRepo.GetByID("1")
+		require.NoError(t, err)
+		assert.True(t, user.Disabled)
+		assert.Len(t, user.MFA.RecoveryCodes, entity.RecoveryCodesCount-2)
+
+		// Nor can a stale full update bring used codes back
+		require.NoError(t, userRepo.Update(&stale))
+		_, _, err = uc.Verify("1", recoveryCodes[1])
+		assert.ErrorIs(t, err, constants.ErrInvalidMFACode)
+	})
+}
+
+func TestMFAUseCase_RequiredRoles(t *testing.T) {
+	uc, userRepo := newTestMFAUseCase(t, "admin")
+	admin, err := userRepo.GetByID("2")
+	require.NoError(t, err)
+	user, err := userRepo.GetByID("1")
+	require.NoError(t, err)
+
+	required, err := uc.IsRequired(admin)
+	require.NoError(t, err)
+	assert.True(t, required)
+
+	required, err = uc.IsRequired(user)
+	require.NoError(t, err)
+	assert.False(t, required)
+
+	require.NoError(t, uc.SetRoleRequired("user", true))
+	required, err = uc.IsRequired(user)
+	require.NoError(t, err)
+	assert.True(t, required)
+
+	roles, err := uc.RequiredRoles()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"admin", "user"}, roles)
+
+	// Configured roles cannot be turned off at runtime
+	require.NoError(t, uc.SetRoleRequired("admin", false))
+	required, err = uc.IsRequired(admin)
+	require.NoError(t, err)
+	assert.True(t, required)
+
+	t.Run("Login completes a pending enrollment", func(t *testing.T) {
+		secret, _, err := uc.BeginEnrollment("2")
+		require.NoError(t, err)
+
+		_, codes, err := uc.Verify("2", currentCode(t, secret))
+		require.NoError(t, err)
+		assert.Len(t, codes, entity.RecoveryCodesCount)
+	})
+
+	t.Run("Cannot disable MFA required by role", func(t *testing.T) {
+		err := uc.Disable("2", "000000")
+		assert.ErrorIs(t, err, constants.ErrMFARequired)
+	})
+}
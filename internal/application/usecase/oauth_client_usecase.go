@@ -0,0 +1,112 @@
+package usecase
+
+import (
+	"crypto/subtle"
+	"errors"
+	"strings"
+	"time"
+
+	"web-server/internal/domain/constants"
+	"web-server/internal/domain/entity"
+	"web-server/internal/domain/repository"
+
+	"github.com/google/uuid"
+)
+
+// OAuthClientUseCase manages the OAuth clients other services authenticate
+// with, for example to introspect and revoke tokens.
+type OAuthClientUseCase struct {
+	clientRepo repository.OAuthClientRepository
+}
+
+func NewOAuthClientUseCase(clientRepo repository.OAuthClientRepository) *OAuthClientUseCase {
+	return &OAuthClientUseCase{
+		clientRepo: clientRepo,
+	}
+}
+
+// Create registers a new client that may request the given scopes, which are
+// permission names or entity.ScopeIntrospect. The returned secret is only available now; afterwards only
+// its hash is known.
+func (uc *OAuthClientUseCase) Create(name string, scopes []string) (string, *entity.OAuthClient, error) {
+	for _, scope := range scopes {
+		if !entity.IsValidClientScope(scope) {
+			return "", nil, constants.ErrInvalidScope
+		}
+	}
+
+	if scopes == nil {
+		scopes = []string{}
+	}
+
+	secret, hash, err := entity.GenerateOAuthClientSecret()
+	if err != nil {
+		return "", nil, err
+	}
+
+	client := &entity.OAuthClient{
+		ID:         uuid.New().String(),
+		Name:       name,
+		SecretHash: hash,
+		Scopes:     scopes,
+		CreatedAt:  time.Now(),
+	}
+	if err := uc.clientRepo.Create(client); err != nil {
+		return "", nil, err
+	}
+
+	return secret, client, nil
+}
+
+// List returns every client, including revoked ones.
+func (uc *OAuthClientUseCase) List() ([]*entity.OAuthClient, error) {
+	return uc.clientRepo.List()
+}
+
+// Revoke revokes a client, so that its credentials stop working.
+func (uc *OAuthClientUseCase) Revoke(clientID string) error {
+	if _, err := uc.clientRepo.GetByID(clientID); err != nil {
+		return err
+	}
+
+	return uc.clientRepo.Revoke(clientID, time.Now())
+}
+
+// Authenticate returns the client with the given credentials. Unknown clients,
+// wrong secrets and revoked clients are all reported as
+// constants.ErrInvalidClient.
+func (uc *OAuthClientUseCase) Authenticate(clientID, secret string) (*entity.OAuthClient, error) {
+	client, err := uc.clientRepo.GetByID(clientID)
+	if errors.Is(err, constants.ErrOAuthClientNotFound) {
+		return nil, constants.ErrInvalidClient
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	hash := entity.HashOneTimeToken(secret)
+	if subtle.ConstantTimeCompare([]byte(hash), []byte(client.SecretHash)) != 1 || !client.IsActive() {
+		return nil, constants.ErrInvalidClient
+	}
+
+	return client, nil
+}
+
+// GrantScopes returns the scopes a token requested by the client gets, from a
+// space separated scope parameter. Without requested scopes the client gets
+// all of its token scopes. Scopes the client may not request are reported as
+// constants.ErrInvalidScope.
+func (uc *OAuthClientUseCase) GrantScopes(client *entity.OAuthClient, requested string) ([]string, error) {
+	if strings.TrimSpace(requested) == "" {
+		return client.TokenScopes(), nil
+	}
+
+	scopes := strings.Fields(requested)
+	for _, scope := range scopes {
+		if scope == entity.ScopeIntrospect || !client.HasScope(scope) {
+			return nil, constants.ErrInvalidScope
+		}
+	}
+
+	return scopes, nil
+}
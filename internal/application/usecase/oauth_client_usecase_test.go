@@ -0,0 +1,67 @@
+package usecase
+
+import (
+	"testing"
+
+	"web-server/internal/domain/constants"
+	"web-server/internal/domain/entity"
+	"web-server/internal/infrastructure/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOAuthClientUseCase_Authenticate(t *testing.T) {
+	uc := NewOAuthClientUseCase(repository.NewInMemoryOAuthClientRepository())
+
+	secret, client, err := uc.Create("billing", nil)
+	require.NoError(t, err)
+	assert.NotEmpty(t, client.ID)
+	assert.NotEqual(t, secret, client.SecretHash)
+
+	authenticated, err := uc.Authenticate(client.ID, secret)
+	require.NoError(t, err)
+	assert.Equal(t, "billing", authenticated.Name)
+
+	_, err = uc.Authenticate(client.ID, "wrong")
+	assert.ErrorIs(t, err, constants.ErrInvalidClient)
+
+	_, err = uc.Authenticate("unknown", secret)
+	assert.ErrorIs(t, err, constants.ErrInvalidClient)
+
+	require.NoError(t, uc.Revoke(client.ID))
+	_, err = uc.Authenticate(client.ID, secret)
+	assert.ErrorIs(t, err, constants.ErrInvalidClient)
+}
+
+func TestOAuthClientUseCase_GrantScopes(t *testing.T) {
+	uc := NewOAuthClientUseCase(repository.NewInMemoryOAuthClientRepository())
+
+	_, _, err := uc.Create("billing", []string{"unknown"})
+	assert.ErrorIs(t, err, constants.ErrInvalidScope)
+
+	_, client, err := uc.Create("billing", []string{entity.PermissionUsersRead, entity.PermissionRolesRead})
+	require.NoError(t, err)
+
+	scopes, err := uc.GrantScopes(client, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{entity.PermissionUsersRead, entity.PermissionRolesRead}, scopes)
+
+	scopes, err = uc.GrantScopes(client, " users:read ")
+	require.NoError(t, err)
+	assert.Equal(t, []string{entity.PermissionUsersRead}, scopes)
+
+	_, err = uc.GrantScopes(client, "users:read users:write")
+	assert.ErrorIs(t, err, constants.ErrInvalidScope)
+
+	// The introspect scope is registered but never granted to tokens
+	_, client, err = uc.Create("gateway", []string{entity.ScopeIntrospect, entity.PermissionUsersRead})
+	require.NoError(t, err)
+
+	scopes, err = uc.GrantScopes(client, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{entity.PermissionUsersRead}, scopes)
+
+	_, err = uc.GrantScopes(client, entity.ScopeIntrospect)
+	assert.ErrorIs(t, err, constants.ErrInvalidScope)
+}
@@ -0,0 +1,189 @@
+package usecase
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"web-server/internal/domain/constants"
+	"web-server/internal/domain/entity"
+	"web-server/internal/domain/repository"
+	"web-server/internal/domain/service"
+
+	"github.com/google/uuid"
+)
+
+// Usernames of provisioned users must fit the limits of entity.User.
+const (
+	minUsernameLength = 3
+	maxUsernameLength = 50
+)
+
+// OIDCUseCase logs users in through an external OpenID Connect provider with
+// the authorization code flow and PKCE. The external account is linked to a
+// user by issuer and subject. On the first login it is linked to the user with
+// the same email, or a new user is provisioned, but only if the provider has
+// verified the email.
+type OIDCUseCase struct {
+	userRepo     repository.UserRepository
+	identityRepo repository.UserIdentityRepository
+	provider     service.IdentityProvider
+	states       service.OIDCStateTokens
+}
+
+func NewOIDCUseCase(
+	userRepo repository.UserRepository,
+	identityRepo repository.UserIdentityRepository,
+	provider service.IdentityProvider,
+	states service.OIDCStateTokens,
+) *OIDCUseCase {
+	return &OIDCUseCase{
+		userRepo:     userRepo,
+		identityRepo: identityRepo,
+		provider:     provider,
+		states:       states,
+	}
+}
+
+// Begin starts a login. It returns the URL of the provider's login page and
+// the state token the browser has to keep until the callback.
+func (uc *OIDCUseCase) Begin() (authURL, stateToken string, err error) {
+	var login service.OIDCLoginState
+	for _, value := range []*string{&login.State, &login.Nonce, &login.CodeVerifier} {
+		if *value, _, err = entity.GenerateOneTimeToken(); err != nil {
+			return "", "", err
+		}
+	}
+
+	authURL, err = uc.provider.AuthCodeURL(login.State, login.Nonce, pkceChallenge(login.CodeVerifier))
+	if err != nil {
+		return "", "", err
+	}
+
+	stateToken, err = uc.states.GenerateOIDCStateToken(login)
+	if err != nil {
+		return "", "", err
+	}
+
+	return authURL, stateToken, nil
+}
+
+// Complete finishes a login with the state and code the provider sent the
+// browser back with, and the state token kept since Begin. It returns the
+// linked or provisioned user, whose account status the caller still checks.
+func (uc *OIDCUseCase) Complete(stateToken, state, code string) (*entity.User, error) {
+	login, err := uc.states.ValidateOIDCStateToken(stateToken)
+	if err != nil {
+		return nil, constants.ErrInvalidOIDCState
+	}
+
+	// A state from another browser means someone tries to log this browser
+	// into their own account
+	if subtle.ConstantTimeCompare([]byte(state), []byte(login.State)) != 1 {
+		return nil, constants.ErrInvalidOIDCState
+	}
+
+	identity, err := uc.provider.Exchange(code, login.CodeVerifier, login.Nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	return uc.findOrLinkUser(identity)
+}
+
+// findOrLinkUser returns the user linked to the external identity, linking or
+// provisioning one by email first if there is none.
+func (uc *OIDCUseCase) findOrLinkUser(identity *service.ExternalIdentity) (*entity.User, error) {
+	linked, err := uc.identityRepo.GetBySubject(identity.Issuer, identity.Subject)
+	switch {
+	case err == nil:
+		user, err := uc.userRepo.GetByID(linked.UserID)
+		if !errors.Is(err, constants.ErrUserNotFound) {
+			return user, err
+		}
+
+		// The user was deleted, so the identity is linked again below
+		if err := uc.identityRepo.Delete(linked.ID); err != nil {
+			return nil, err
+		}
+	case !errors.Is(err, constants.ErrUserIdentityNotFound):
+		return nil, err
+	}
+
+	// Anyone can claim any email at some providers, so only verified emails
+	// may take over an account
+	if identity.Email == "" || !identity.EmailVerified {
+		return nil, constants.ErrExternalEmailNotVerified
+	}
+
+	user, err := uc.userRepo.GetByEmail(identity.Email)
+	switch {
+	case errors.Is(err, constants.ErrUserNotFound):
+		if user, err = uc.provisionUser(identity); err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, err
+	case !user.EmailVerified:
+		user.EmailVerified = true
+		if err := uc.userRepo.Update(user); err != nil {
+			return nil, err
+		}
+	}
+
+	err = uc.identityRepo.Create(&entity.UserIdentity{
+		ID:        uuid.New().String(),
+		UserID:    user.ID,
+		Issuer:    identity.Issuer,
+		Subject:   identity.Subject,
+		Email:     identity.Email,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// provisionUser creates a user for an external identity. The user has no
+// password and can only log in through the provider, or after a password reset.
+func (uc *OIDCUseCase) provisionUser(identity *service.ExternalIdentity) (*entity.User, error) {
+	user := entity.NewUser(usernameFor(identity), identity.Email, "")
+	user.ID = uuid.New().String()
+	user.EmailVerified = true
+
+	if err := uc.userRepo.Create(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// usernameFor picks the username of a provisioned user: the preferred username
+// at the provider if it fits, else the local part of the email.
+func usernameFor(identity *service.ExternalIdentity) string {
+	candidates := []string{identity.PreferredUsername}
+	if local, _, found := strings.Cut(identity.Email, "@"); found {
+		candidates = append(candidates, local)
+	}
+
+	for _, name := range candidates {
+		if len(name) >= minUsernameLength && len(name) <= maxUsernameLength {
+			return name
+		}
+	}
+
+	if len(identity.Email) > maxUsernameLength {
+		return identity.Email[:maxUsernameLength]
+	}
+	return identity.Email
+}
+
+// pkceChallenge returns the S256 code challenge of a PKCE code verifier.
+func pkceChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
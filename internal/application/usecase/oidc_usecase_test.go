@@ -0,0 +1,131 @@
+package usecase
+
+import (
+	"encoding/json"
+	"testing"
+
+	"web-server/internal/domain/constants"
+	"web-server/internal/domain/entity"
+	"web-server/internal/domain/service"
+	"web-server/internal/infrastructure/oidc"
+	"web-server/internal/infrastructure/oidc/oidctest"
+	"web-server/internal/infrastructure/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeOIDCStateTokens keeps the login state readable and unsigned.
+type fakeOIDCStateTokens struct{}
+
+func (fakeOIDCStateTokens) GenerateOIDCStateToken(state service.OIDCLoginState) (string, error) {
+	data, err := json.Marshal(state)
+	return string(data), err
+}
+
+func (fakeOIDCStateTokens) ValidateOIDCStateToken(token string) (*service.OIDCLoginState, error) {
+	var state service.OIDCLoginState
+	if err := json.Unmarshal([]byte(token), &state); err != nil {
+		return nil, constants.ErrInvalidOIDCState
+	}
+	return &state, nil
+}
+
+func newTestOIDCUseCase(t *testing.T) (*OIDCUseCase, *repository.InMemoryUserRepository, *oidctest.Provider) {
+	t.Helper()
+	fake, err := oidctest.NewProvider("web-server", "client-secret")
+	require.NoError(t, err)
+	t.Cleanup(fake.Close)
+
+	provider, err := oidc.New(fake.Config("https://app.example.com/oidc/callback"))
+	require.NoError(t, err)
+
+	userRepo := repository.NewInMemoryUserRepository()
+	uc := NewOIDCUseCase(userRepo, repository.NewInMemoryUserIdentityRepository(), provider, fakeOIDCStateTokens{})
+	return uc, userRepo, fake
+}
+
+// oidcLogin runs a login as the user signed in at the fake provider.
+func oidcLogin(t *testing.T, uc *OIDCUseCase, fake *oidctest.Provider) (*entity.User, error) {
+	t.Helper()
+	authURL, stateToken, err := uc.Begin()
+	require.NoError(t, err)
+
+	callback, err := fake.Authorize(authURL)
+	require.NoError(t, err)
+
+	return uc.Complete(stateToken, callback.Query().Get("state"), callback.Query().Get("code"))
+}
+
+func TestOIDCUseCase_ProvisionsUser(t *testing.T) {
+	uc, userRepo, fake := newTestOIDCUseCase(t)
+	fake.SignIn(oidctest.User{Subject: "ext-1", Email: "new@example.com", EmailVerified: true, PreferredUsername: "newbie"})
+
+	user, err := oidcLogin(t, uc, fake)
+	require.NoError(t, err)
+	assert.Equal(t, "newbie", user.Username)
+	assert.Equal(t, "new@example.com", user.Email)
+	assert.Equal(t, entity.RoleUser, user.Role)
+	assert.True(t, user.EmailVerified)
+	assert.Empty(t, user.Password)
+
+	stored, err := userRepo.GetByEmail("new@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, stored.ID)
+
+	// The next login finds the linked user, even after the email changed at the provider
+	fake.SignIn(oidctest.User{Subject: "ext-1", Email: "renamed@example.com", EmailVerified: true})
+	again, err := oidcLogin(t, uc, fake)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, again.ID)
+
+	users, err := userRepo.List()
+	require.NoError(t, err)
+	assert.Len(t, users, 1)
+}
+
+func TestOIDCUseCase_LinksUserByVerifiedEmail(t *testing.T) {
+	uc, userRepo, fake := newTestOIDCUseCase(t)
+	require.NoError(t, userRepo.Create(&entity.User{ID: "1", Email: "user@example.com", Username: "user", Password: "hash"}))
+
+	// Unverified emails must not take over an account
+	fake.SignIn(oidctest.User{Subject: "ext-1", Email: "user@example.com"})
+	_, err := oidcLogin(t, uc, fake)
+	assert.ErrorIs(t, err, constants.ErrExternalEmailNotVerified)
+
+	fake.SignIn(oidctest.User{Subject: "ext-1", Email: "user@example.com", EmailVerified: true})
+	user, err := oidcLogin(t, uc, fake)
+	require.NoError(t, err)
+	assert.Equal(t, "1", user.ID)
+
+	stored, err := userRepo.GetByID("1")
+	require.NoError(t, err)
+	assert.True(t, stored.EmailVerified)
+	assert.Equal(t, "hash", stored.Password)
+}
+
+func TestOIDCUseCase_RejectsForeignState(t *testing.T) {
+	uc, _, fake := newTestOIDCUseCase(t)
+	fake.SignIn(oidctest.User{Subject: "ext-1", Email: "user@example.com", EmailVerified: true})
+
+	// A callback of a login started by another browser
+	authURL, _, err := uc.Begin()
+	require.NoError(t, err)
+	callback, err := fake.Authorize(authURL)
+	require.NoError(t, err)
+
+	_, ownState, err := uc.Begin()
+	require.NoError(t, err)
+
+	_, err = uc.Complete(ownState, callback.Query().Get("state"), callback.Query().Get("code"))
+	assert.ErrorIs(t, err, constants.ErrInvalidOIDCState)
+
+	_, err = uc.Complete("", callback.Query().Get("state"), callback.Query().Get("code"))
+	assert.ErrorIs(t, err, constants.ErrInvalidOIDCState)
+}
+
+func TestUsernameFor(t *testing.T) {
+	assert.Equal(t, "preferred", usernameFor(&service.ExternalIdentity{PreferredUsername: "preferred", Email: "local@example.com"}))
+	assert.Equal(t, "local", usernameFor(&service.ExternalIdentity{PreferredUsername: "ab", Email: "local@example.com"}))
+	assert.Equal(t, "ab@example.com", usernameFor(&service.ExternalIdentity{Email: "ab@example.com"}))
+}
@@ -0,0 +1,172 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"web-server/internal/domain/constants"
+	"web-server/internal/domain/entity"
+	"web-server/internal/domain/repository"
+	"web-server/internal/domain/service"
+
+	"github.com/google/uuid"
+)
+
+// PasswordResetUseCase lets users who forgot their password set a new one
+// through a single-use link sent by email.
+type PasswordResetUseCase struct {
+	userRepo   repository.UserRepository
+	tokenRepo  repository.PasswordResetTokenRepository
+	mailer     service.Mailer
+	hasher     service.PasswordHasher
+	passwords  *PasswordValidator
+	resetURL   string        // Page the token is appended to as the token query parameter
+	expiration time.Duration // Lifetime of a reset token
+}
+
+func NewPasswordResetUseCase(
+	userRepo repository.UserRepository,
+	tokenRepo repository.PasswordResetTokenRepository,
+	mailer service.Mailer,
+	hasher service.PasswordHasher,
+	passwords *PasswordValidator,
+	resetURL string,
+	expiration time.Duration,
+) *PasswordResetUseCase {
+	return &PasswordResetUseCase{
+		userRepo:   userRepo,
+		tokenRepo:  tokenRepo,
+		mailer:     mailer,
+		hasher:     hasher,
+		passwords:  passwords,
+		resetURL:   resetURL,
+		expiration: expiration,
+	}
+}
+
+// RequestReset emails a reset link to the user with the given email. Unknown
+// emails are ignored without an error, so callers cannot find out which
+// accounts exist.
+func (uc *PasswordResetUseCase) RequestReset(email string) error {
+	user, err := uc.userRepo.GetByEmail(email)
+	if errors.Is(err, constants.ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return uc.sendResetLink(user, "Reset your password",
+		"Use the link below to choose a new password.",
+		"If you did not ask to reset your password, you can ignore this email.")
+}
+
+// ForceReset requires the user to choose a new password before they can log in
+// again and emails them a reset link. The caller is responsible for ending the
+// user's existing sessions.
+func (uc *PasswordResetUseCase) ForceReset(userID string) (*entity.User, error) {
+	user, err := uc.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	user.PasswordResetRequired = true
+	if err := uc.userRepo.Update(user); err != nil {
+		return nil, err
+	}
+
+	if err := uc.sendResetLink(user, "Choose a new password",
+		"An administrator has asked you to choose a new password before you log in again.",
+		"If you have questions about this request, contact your administrator."); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// sendResetLink stores a new reset token for the user and emails them the link.
+func (uc *PasswordResetUseCase) sendResetLink(user *entity.User, subject, intro, outro string) error {
+	token, hash, err := entity.GenerateOneTimeToken()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if err := uc.tokenRepo.Create(&entity.PasswordResetToken{
+		ID:        uuid.New().String(),
+		UserID:    user.ID,
+		TokenHash: hash,
+		ExpiresAt: now.Add(uc.expiration),
+		CreatedAt: now,
+	}); err != nil {
+		return err
+	}
+
+	link := uc.resetURL + "?token=" + url.QueryEscape(token)
+	return uc.mailer.Send(service.Message{
+		To:      user.Email,
+		Subject: subject,
+		Body: fmt.Sprintf(
+			"Hello %s,\n\n%s It expires in %s.\n\n%s\n\n%s\n",
+			user.Username, intro, uc.expiration, link, outro,
+		),
+	})
+}
+
+// ResetPassword sets a new password with a reset token and returns the ID of
+// the user. Every reset token of the user becomes invalid. A password the
+// user may not choose is reported as an *entity.ValidationError and leaves the
+// token valid. The caller is responsible for ending the user's existing
+// sessions.
+func (uc *PasswordResetUseCase) ResetPassword(token, newPassword string) (string, error) {
+	resetToken, err := uc.tokenRepo.GetByHash(entity.HashOneTimeToken(token))
+	if errors.Is(err, constants.ErrPasswordResetTokenNotFound) {
+		return "", constants.ErrInvalidResetToken
+	}
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	if !resetToken.IsValid(now) {
+		return "", constants.ErrInvalidResetToken
+	}
+
+	user, err := uc.userRepo.GetByID(resetToken.UserID)
+	if errors.Is(err, constants.ErrUserNotFound) {
+		return "", constants.ErrInvalidResetToken
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// Checked before the token is claimed, so the user can pick another
+	// password with the same link
+	if err := uc.passwords.Validate("password", newPassword, user); err != nil {
+		return "", err
+	}
+
+	// Claim the token before changing anything, so it works only once
+	if err := uc.tokenRepo.MarkUsed(resetToken.ID, now); err != nil {
+		return "", err
+	}
+
+	hashedPassword, err := uc.hasher.Hash(newPassword)
+	if err != nil {
+		return "", err
+	}
+
+	user.Password = hashedPassword
+	user.PasswordResetRequired = false
+	if err := uc.userRepo.Update(user); err != nil {
+		return "", err
+	}
+
+	if err := uc.tokenRepo.DeleteByUser(user.ID); err != nil {
+		return "", err
+	}
+
+	return user.ID, nil
+}
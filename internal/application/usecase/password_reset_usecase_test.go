@@ -0,0 +1,152 @@
+package usecase
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"web-server/internal/domain/constants"
+	"web-server/internal/domain/entity"
+	"web-server/internal/domain/service"
+	"web-server/internal/infrastructure/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingMailer keeps the messages it was asked to send.
+type recordingMailer struct {
+	messages []service.Message
+}
+
+func (m *recordingMailer) Send(message service.Message) error {
+	m.messages = append(m.messages, message)
+	return nil
+}
+
+// tokenFromLink extracts the token query parameter from the link in an email.
+func tokenFromLink(t *testing.T, message service.Message) string {
+	t.Helper()
+	start := strings.Index(message.Body, "https://")
+	require.NotEqual(t, -1, start)
+	link, err := url.Parse(strings.Fields(message.Body[start:])[0])
+	require.NoError(t, err)
+	return link.Query().Get("token")
+}
+
+func newTestPasswordResetUseCase(t *testing.T, expiration time.Duration) (*PasswordResetUseCase, *repository.InMemoryUserRepository, *recordingMailer) {
+	t.Helper()
+	userRepo := repository.NewInMemoryUserRepository()
+	hasher := newTestHasher(t)
+	hashed, err := hasher.Hash("old-password")
+	require.NoError(t, err)
+	require.NoError(t, userRepo.Create(&entity.User{ID: "1", Email: "user@example.com", Username: "user", Password: hashed}))
+
+	mailer := &recordingMailer{}
+	uc := NewPasswordResetUseCase(
+		userRepo,
+		repository.NewInMemoryPasswordResetTokenRepository(),
+		mailer,
+		hasher,
+		newTestPasswordValidator(),
+		"https://app.example.com/reset-password",
+		expiration,
+	)
+	return uc, userRepo, mailer
+}
+
+func TestPasswordResetUseCase_Reset(t *testing.T) {
+	uc, userRepo, mailer := newTestPasswordResetUseCase(t, time.Hour)
+
+	require.NoError(t, uc.RequestReset("user@example.com"))
+	require.Len(t, mailer.messages, 1)
+	assert.Equal(t, "user@example.com", mailer.messages[0].To)
+	token := tokenFromLink(t, mailer.messages[0])
+
+	userID, err := uc.ResetPassword(token, "new-password")
+	require.NoError(t, err)
+	assert.Equal(t, "1", userID)
+
+	user, err := userRepo.GetByID("1")
+	require.NoError(t, err)
+	assert.True(t, newTestHasher(t).Verify("new-password", user.Password))
+
+	// Tokens are single-use
+	_, err = uc.ResetPassword(token, "another-password")
+	assert.ErrorIs(t, err, constants.ErrInvalidResetToken)
+}
+
+func TestPasswordResetUseCase_RejectsWeakPasswords(t *testing.T) {
+	uc, _, mailer := newTestPasswordResetUseCase(t, time.Hour)
+
+	require.NoError(t, uc.RequestReset("user@example.com"))
+	token := tokenFromLink(t, mailer.messages[0])
+
+	_, err := uc.ResetPassword(token, "password123")
+	var validationErr *entity.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Contains(t, validationErr.Fields, "password")
+
+	// The token can still be used with another password
+	_, err = uc.ResetPassword(token, "new-password")
+	assert.NoError(t, err)
+}
+
+func TestPasswordResetUseCase_InvalidatesOtherTokens(t *testing.T) {
+	uc, _, mailer := newTestPasswordResetUseCase(t, time.Hour)
+
+	require.NoError(t, uc.RequestReset("user@example.com"))
+	require.NoError(t, uc.RequestReset("user@example.com"))
+	require.Len(t, mailer.messages, 2)
+
+	_, err := uc.ResetPassword(tokenFromLink(t, mailer.messages[1]), "new-password")
+	require.NoError(t, err)
+
+	_, err = uc.ResetPassword(tokenFromLink(t, mailer.messages[0]), "another-password")
+	assert.ErrorIs(t, err, constants.ErrInvalidResetToken)
+}
+
+func TestPasswordResetUseCase_RejectsInvalidTokens(t *testing.T) {
+	tests := []struct {
+		name       string
+		expiration time.Duration
+		token      func(t *testing.T, mailer *recordingMailer) string
+	}{
+		{
+			name:       "Unknown token",
+			expiration: time.Hour,
+			token: func(t *testing.T, mailer *recordingMailer) string {
+				return "unknown"
+			},
+		},
+		{
+			name:       "Expired token",
+			expiration: -time.Minute,
+			token: func(t *testing.T, mailer *recordingMailer) string {
+				return tokenFromLink(t, mailer.messages[0])
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc, userRepo, mailer := newTestPasswordResetUseCase(t, tt.expiration)
+			require.NoError(t, uc.RequestReset("user@example.com"))
+
+			_, err := uc.ResetPassword(tt.token(t, mailer), "new-password")
+			assert.ErrorIs(t, err, constants.ErrInvalidResetToken)
+
+			user, err := userRepo.GetByID("1")
+			require.NoError(t, err)
+			assert.True(t, newTestHasher(t).Verify("old-password", user.Password))
+		})
+	}
+}
+
+func TestPasswordResetUseCase_UnknownEmail(t *testing.T) {
+	uc, _, mailer := newTestPasswordResetUseCase(t, time.Hour)
+
+	assert.NoError(t, uc.RequestReset("nobody@example.com"))
+	assert.Empty(t, mailer.messages)
+}
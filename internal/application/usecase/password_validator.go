@@ -0,0 +1,43 @@
+package usecase
+
+import (
+	"web-server/internal/domain/entity"
+	"web-server/internal/domain/service"
+)
+
+// PasswordValidator checks new passwords against the password policy and the
+// breached password list, wherever users choose a password.
+type PasswordValidator struct {
+	policy   entity.PasswordPolicy
+	breaches service.BreachedPasswordChecker
+}
+
+func NewPasswordValidator(policy entity.PasswordPolicy, breaches service.BreachedPasswordChecker) *PasswordValidator {
+	return &PasswordValidator{
+		policy:   policy,
+		breaches: breaches,
+	}
+}
+
+// Validate returns an *entity.ValidationError for the request field if the
+// password chosen by the user breaks the policy or appears in a breach.
+func (v *PasswordValidator) Validate(field, password string, user *entity.User) error {
+	problems := v.policy.Check(password, user.Username, user.Email)
+
+	// Passwords that break the policy are rejected anyway, so the breach list
+	// is only read for passwords that would otherwise be accepted
+	if len(problems) == 0 {
+		breached, err := v.breaches.IsBreached(password)
+		if err != nil {
+			return err
+		}
+		if breached {
+			problems = append(problems, "appears in a known data breach, choose another password")
+		}
+	}
+
+	if len(problems) > 0 {
+		return entity.NewValidationError(field, problems...)
+	}
+	return nil
+}
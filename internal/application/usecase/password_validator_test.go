@@ -0,0 +1,71 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+
+	"web-server/internal/domain/entity"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// breachList is a breached password list of plaintext passwords.
+type breachList map[string]bool
+
+func (l breachList) IsBreached(password string) (bool, error) {
+	return l[password], nil
+}
+
+// failingBreachList fails every lookup.
+type failingBreachList struct{}
+
+func (failingBreachList) IsBreached(string) (bool, error) {
+	return false, errors.New("breach list unavailable")
+}
+
+// newTestPasswordValidator accepts passwords of at least eight characters
+// except "password123".
+func newTestPasswordValidator() *PasswordValidator {
+	return NewPasswordValidator(entity.PasswordPolicy{MinLength: 8}, breachList{"password123": true})
+}
+
+func TestPasswordValidator_Validate(t *testing.T) {
+	validator := NewPasswordValidator(
+		entity.PasswordPolicy{MinLength: 8, RequiredClasses: []string{entity.PasswordClassDigit}},
+		breachList{"trustno1234": true},
+	)
+	user := &entity.User{Username: "johndoe", Email: "john@example.com"}
+
+	assert.NoError(t, validator.Validate("password", "kabocha-squash-7", user))
+
+	var validationErr *entity.ValidationError
+
+	err := validator.Validate("new_password", "short", user)
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, map[string][]string{
+		"new_password": {"must be at least 8 characters long", "must contain a digit"},
+	}, validationErr.Fields)
+
+	err = validator.Validate("password", "johndoe-2024", user)
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, []string{"must not contain the username or email address"}, validationErr.Fields["password"])
+
+	err = validator.Validate("password", "trustno1234", user)
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, []string{"appears in a known data breach, choose another password"}, validationErr.Fields["password"])
+}
+
+func TestPasswordValidator_BreachListError(t *testing.T) {
+	validator := NewPasswordValidator(entity.PasswordPolicy{MinLength: 8}, failingBreachList{})
+	user := &entity.User{Username: "johndoe", Email: "john@example.com"}
+
+	err := validator.Validate("password", "kabocha-squash-7", user)
+	require.Error(t, err)
+	var validationErr *entity.ValidationError
+	assert.False(t, errors.As(err, &validationErr))
+
+	// Policy violations are reported without reading the list
+	err = validator.Validate("password", "short", user)
+	assert.ErrorAs(t, err, &validationErr)
+}
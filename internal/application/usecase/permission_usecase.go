@@ -0,0 +1,144 @@
+package usecase
+
+import (
+	"sort"
+
+	"web-server/internal/domain/constants"
+	"web-server/internal/domain/entity"
+	"web-server/internal/domain/repository"
+)
+
+// PermissionUseCase maps roles to the permissions they are granted. The admin
+// role has every permission; the permissions of other roles come from the
+// configuration and, taking precedence, the role permission repository.
+type PermissionUseCase struct {
+	roleRepo   repository.RolePermissionRepository
+	configured map[string][]string // Role permissions from the configuration
+}
+
+func NewPermissionUseCase(roleRepo repository.RolePermissionRepository, configured map[string][]string) *PermissionUseCase {
+	return &PermissionUseCase{
+		roleRepo:   roleRepo,
+		configured: configured,
+	}
+}
+
+// Roles returns every known role with its permissions.
+func (uc *PermissionUseCase) Roles() (map[string][]string, error) {
+	stored, err := uc.roleRepo.ListRoles()
+	if err != nil {
+		return nil, err
+	}
+
+	roles := map[string][]string{entity.RoleUser: {}}
+	for role, permissions := range uc.configured {
+		roles[role] = validPermissions(permissions)
+	}
+	for role, permissions := range stored {
+		roles[role] = validPermissions(permissions)
+	}
+	roles[entity.RoleAdmin] = entity.Permissions()
+
+	return roles, nil
+}
+
+// RoleExists reports whether users can be given the role.
+func (uc *PermissionUseCase) RoleExists(role string) (bool, error) {
+	roles, err := uc.Roles()
+	if err != nil {
+		return false, err
+	}
+
+	_, exists := roles[role]
+	return exists, nil
+}
+
+// HasPermission reports whether the role is granted the permission.
+func (uc *PermissionUseCase) HasPermission(role, permission string) (bool, error) {
+	if role == entity.RoleAdmin {
+		return true, nil
+	}
+
+	roles, err := uc.Roles()
+	if err != nil {
+		return false, err
+	}
+
+	for _, p := range roles[role] {
+		if p == permission {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Covers reports whether the role is granted every permission of the other
+// role, so that acting on users with the other role gives the role nothing it
+// does not already have. The roles are loaded once for both.
+func (uc *PermissionUseCase) Covers(role, other string) (bool, error) {
+	if role == entity.RoleAdmin {
+		return true, nil
+	}
+
+	roles, err := uc.Roles()
+	if err != nil {
+		return false, err
+	}
+
+	granted := make(map[string]bool, len(roles[role]))
+	for _, permission := range roles[role] {
+		granted[permission] = true
+	}
+	for _, permission := range roles[other] {
+		if !granted[permission] {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// SetRolePermissions grants a role exactly the given permissions, creating the
+// role if it does not exist yet.
+func (uc *PermissionUseCase) SetRolePermissions(role string, permissions []string) error {
+	if role == "" {
+		return constants.ErrUnknownRole
+	}
+	if role == entity.RoleAdmin {
+		return constants.ErrBuiltInRole
+	}
+
+	for _, permission := range permissions {
+		if !entity.IsValidPermission(permission) {
+			return constants.ErrInvalidPermission
+		}
+	}
+
+	return uc.roleRepo.SetPermissions(role, validPermissions(permissions))
+}
+
+// ResetRolePermissions removes the stored permissions of a role, so that the
+// configured ones apply again.
+func (uc *PermissionUseCase) ResetRolePermissions(role string) error {
+	if role == entity.RoleAdmin {
+		return constants.ErrBuiltInRole
+	}
+
+	return uc.roleRepo.DeleteRole(role)
+}
+
+// validPermissions returns the known permissions in the list, sorted and
+// without duplicates. Unknown permissions in the configuration are ignored.
+func validPermissions(permissions []string) []string {
+	seen := make(map[string]bool)
+	valid := make([]string, 0, len(permissions))
+	for _, permission := range permissions {
+		if entity.IsValidPermission(permission) && !seen[permission] {
+			seen[permission] = true
+			valid = append(valid, permission)
+		}
+	}
+	sort.Strings(valid)
+
+	return valid
+}
@@ -0,0 +1,131 @@
+package usecase
+
+import (
+	"testing"
+
+	"web-server/internal/domain/constants"
+	"web-server/internal/domain/entity"
+	"web-server/internal/infrastructure/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestPermissionUseCase() *PermissionUseCase {
+	return NewPermissionUseCase(
+		repository.NewInMemoryRolePermissionRepository(),
+		map[string][]string{
+			"support": {entity.PermissionUsersRead, "users:unknown"},
+		},
+	)
+}
+
+func TestPermissionUseCase_HasPermission(t *testing.T) {
+	uc := newTestPermissionUseCase()
+
+	tests := []struct {
+		name       string
+		role       string
+		permission string
+		want       bool
+	}{
+		{name: "Admin has every permission", role: entity.RoleAdmin, permission: entity.PermissionUsersDelete, want: true},
+		{name: "User has no permissions", role: entity.RoleUser, permission: entity.PermissionUsersRead, want: false},
+		{name: "Configured role", role: "support", permission: entity.PermissionUsersRead, want: true},
+		{name: "Configured role lacks permission", role: "support", permission: entity.PermissionUsersWrite, want: false},
+		{name: "Unknown role", role: "auditor", permission: entity.PermissionUsersRead, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := uc.HasPermission(tt.role, tt.permission)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPermissionUseCase_Covers(t *testing.T) {
+	uc := newTestPermissionUseCase()
+
+	tests := []struct {
+		name  string
+		role  string
+		other string
+		want  bool
+	}{
+		{name: "Admin covers every role", role: entity.RoleAdmin, other: "support", want: true},
+		{name: "Role covers itself", role: "support", other: "support", want: true},
+		{name: "Role covers a role without permissions", role: "support", other: entity.RoleUser, want: true},
+		{name: "Role does not cover admin", role: "support", other: entity.RoleAdmin, want: false},
+		{name: "User does not cover a role with permissions", role: entity.RoleUser, other: "support", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := uc.Covers(tt.role, tt.other)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// countingRoleRepository counts the queries for the role permissions.
+type countingRoleRepository struct {
+	*repository.InMemoryRolePermissionRepository
+	lists int
+}
+
+func (r *countingRoleRepository) ListRoles() (map[string][]string, error) {
+	r.lists++
+	return r.InMemoryRolePermissionRepository.ListRoles()
+}
+
+func TestPermissionUseCase_CoversLoadsRolesOnce(t *testing.T) {
+	roleRepo := &countingRoleRepository{InMemoryRolePermissionRepository: repository.NewInMemoryRolePermissionRepository()}
+	uc := NewPermissionUseCase(roleRepo, nil)
+	require.NoError(t, uc.SetRolePermissions("support", []string{entity.PermissionUsersRead, entity.PermissionUsersWrite}))
+
+	covers, err := uc.Covers("support", entity.RoleAdmin)
+	require.NoError(t, err)
+	assert.False(t, covers)
+	assert.Equal(t, 1, roleRepo.lists)
+}
+
+func TestPermissionUseCase_Roles(t *testing.T) {
+	roles, err := newTestPermissionUseCase().Roles()
+	require.NoError(t, err)
+
+	assert.Equal(t, entity.Permissions(), roles[entity.RoleAdmin])
+	assert.Empty(t, roles[entity.RoleUser])
+	assert.Equal(t, []string{entity.PermissionUsersRead}, roles["support"], "unknown configured permissions are ignored")
+}
+
+func TestPermissionUseCase_SetRolePermissions(t *testing.T) {
+	uc := newTestPermissionUseCase()
+
+	// Stored permissions replace the configured ones
+	require.NoError(t, uc.SetRolePermissions("support", []string{entity.PermissionUsersWrite}))
+	ok, err := uc.HasPermission("support", entity.PermissionUsersRead)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// New roles need no code changes
+	require.NoError(t, uc.SetRolePermissions("auditor", []string{entity.PermissionUsersRead, entity.PermissionRolesRead}))
+	exists, err := uc.RoleExists("auditor")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	roles, err := uc.Roles()
+	require.NoError(t, err)
+	assert.Equal(t, []string{entity.PermissionRolesRead, entity.PermissionUsersRead}, roles["auditor"])
+
+	assert.ErrorIs(t, uc.SetRolePermissions("auditor", []string{"everything"}), constants.ErrInvalidPermission)
+	assert.ErrorIs(t, uc.SetRolePermissions(entity.RoleAdmin, nil), constants.ErrBuiltInRole)
+
+	// Resetting falls back to the configuration
+	require.NoError(t, uc.ResetRolePermissions("support"))
+	ok, err = uc.HasPermission("support", entity.PermissionUsersRead)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
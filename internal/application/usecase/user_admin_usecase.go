@@ -0,0 +1,226 @@
+package usecase
+
+import (
+	"errors"
+	"time"
+
+	"web-server/internal/domain/constants"
+	"web-server/internal/domain/entity"
+	"web-server/internal/domain/repository"
+	"web-server/internal/domain/service"
+
+	"github.com/google/uuid"
+)
+
+// UserAdminUseCase lets admins change the role and status of user accounts.
+// Admins can only manage users whose role, before and after the change, has no
+// permissions beyond their own. Every change ends the sessions of the user,
+// since their tokens still carry the old role, and is recorded in the audit
+// log with the acting admin.
+type UserAdminUseCase struct {
+	userRepo    repository.UserRepository
+	auditRepo   repository.AuditRepository
+	permissions *PermissionUseCase
+	resets      *PasswordResetUseCase
+	sessions    service.SessionRevoker
+}
+
+func NewUserAdminUseCase(
+	userRepo repository.UserRepository,
+	auditRepo repository.AuditRepository,
+	permissions *PermissionUseCase,
+	resets *PasswordResetUseCase,
+	sessions service.SessionRevoker,
+) *UserAdminUseCase {
+	return &UserAdminUseCase{
+		userRepo:    userRepo,
+		auditRepo:   auditRepo,
+		permissions: permissions,
+		resets:      resets,
+		sessions:    sessions,
+	}
+}
+
+// ChangeRole gives the user another role. The actor needs every permission of
+// both the current and the new role.
+func (uc *UserAdminUseCase) ChangeRole(actor entity.Actor, userID, role string) (*entity.User, error) {
+	if actor.UserID == userID {
+		return nil, constants.ErrSelfModification
+	}
+
+	exists, err := uc.permissions.RoleExists(role)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, constants.ErrUnknownRole
+	}
+
+	user, err := uc.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.authorize(actor, user.Role, role); err != nil {
+		return nil, err
+	}
+
+	oldRole := user.Role
+	if oldRole == role {
+		return user, nil
+	}
+
+	previous := *user
+	user.Role = role
+	if err := uc.userRepo.Update(user); err != nil {
+		return nil, err
+	}
+
+	if err := uc.complete(actor, &previous, entity.AuditActionRoleChanged, oldRole, role); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// SetDisabled disables or enables the user's account.
+func (uc *UserAdminUseCase) SetDisabled(actor entity.Actor, userID string, disabled bool) (*entity.User, error) {
+	if actor.UserID == userID {
+		return nil, constants.ErrSelfModification
+	}
+
+	user, err := uc.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.authorize(actor, user.Role); err != nil {
+		return nil, err
+	}
+
+	if user.Disabled == disabled {
+		return user, nil
+	}
+
+	previous := *user
+	user.Disabled = disabled
+	if err := uc.userRepo.Update(user); err != nil {
+		return nil, err
+	}
+
+	action := entity.AuditActionUserEnabled
+	if disabled {
+		action = entity.AuditActionUserDisabled
+	}
+
+	if err := uc.complete(actor, &previous, action, "", ""); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// ForcePasswordReset requires the user to choose a new password before their
+// next login and emails them a reset link.
+func (uc *UserAdminUseCase) ForcePasswordReset(actor entity.Actor, userID string) (*entity.User, error) {
+	user, err := uc.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.authorize(actor, user.Role); err != nil {
+		return nil, err
+	}
+
+	previous := *user
+	user, err = uc.resets.ForceReset(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.complete(actor, &previous, entity.AuditActionPasswordResetForced, "", ""); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// Impersonate checks that the actor may act as the user and records it in the
+// audit log with the reason given. Admins, disabled users and users whose role
+// has permissions the actor lacks cannot be impersonated, nor can the actor
+// impersonate themselves or start another impersonation while impersonating.
+// The caller issues the impersonation token.
+func (uc *UserAdminUseCase) Impersonate(actor entity.Actor, userID, reason string) (*entity.User, error) {
+	if actor.UserID == userID || actor.IsImpersonated() {
+		return nil, constants.ErrNotImpersonatable
+	}
+
+	user, err := uc.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Role == entity.RoleAdmin || user.Disabled {
+		return nil, constants.ErrNotImpersonatable
+	}
+
+	// Acting as the user must not give the actor permissions of their own
+	covers, err := uc.permissions.Covers(actor.Role, user.Role)
+	if err != nil {
+		return nil, err
+	}
+	if !covers {
+		return nil, constants.ErrNotImpersonatable
+	}
+
+	return user, uc.record(actor, user.ID, entity.AuditActionImpersonated, "", reason)
+}
+
+// AuditLog returns the administrative actions performed on the user, oldest first.
+func (uc *UserAdminUseCase) AuditLog(userID string) ([]*entity.AuditEntry, error) {
+	return uc.auditRepo.ListByUser(userID)
+}
+
+// authorize returns constants.ErrAccessDenied unless the actor has every
+// permission of the roles, so that nobody can manage users above their own
+// privileges or hand out permissions they do not hold.
+func (uc *UserAdminUseCase) authorize(actor entity.Actor, roles ...string) error {
+	for _, role := range roles {
+		covers, err := uc.permissions.Covers(actor.Role, role)
+		if err != nil {
+			return err
+		}
+		if !covers {
+			return constants.ErrAccessDenied
+		}
+	}
+
+	return nil
+}
+
+// complete ends the sessions of the changed user and records the change in
+// the audit log. If either fails, the user is restored to previous, so that no
+// change takes effect while old tokens stay valid or without an audit entry.
+func (uc *UserAdminUseCase) complete(actor entity.Actor, previous *entity.User, action, oldValue, newValue string) error {
+	err := uc.sessions.LogoutAll(previous.ID)
+	if err == nil {
+		err = uc.record(actor, previous.ID, action, oldValue, newValue)
+	}
+	if err == nil {
+		return nil
+	}
+
+	if undoErr := uc.userRepo.Update(previous); undoErr != nil {
+		return errors.Join(err, undoErr)
+	}
+	return err
+}
+
+func (uc *UserAdminUseCase) record(actor entity.Actor, userID, action, oldValue, newValue string) error {
+	return uc.auditRepo.Create(&entity.AuditEntry{
+		ID:        uuid.New().String(),
+		ActorID:   actor.UserID,
+		UserID:    userID,
+		Action:    action,
+		OldValue:  oldValue,
+		NewValue:  newValue,
+		CreatedAt: time.Now(),
+	})
+}
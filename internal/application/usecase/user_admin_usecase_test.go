@@ -0,0 +1,280 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"web-server/internal/domain/constants"
+	"web-server/internal/domain/entity"
+	"web-server/internal/infrastructure/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingSessions records the users logged out everywhere, or fails with err.
+type recordingSessions struct {
+	loggedOut []string
+	err       error
+}
+
+func (s *recordingSessions) LogoutAll(userID string) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.loggedOut = append(s.loggedOut, userID)
+	return nil
+}
+
+func newTestUserAdminUseCase(t *testing.T) (*UserAdminUseCase, *repository.InMemoryUserRepository, *recordingMailer) {
+	uc, userRepo, mailer, _ := newTestUserAdminUseCaseWithSessions(t)
+	return uc, userRepo, mailer
+}
+
+func newTestUserAdminUseCaseWithSessions(t *testing.T) (*UserAdminUseCase, *repository.InMemoryUserRepository, *recordingMailer, *recordingSessions) {
+	t.Helper()
+	resets, userRepo, mailer := newTestPasswordResetUseCase(t, time.Hour)
+	require.NoError(t, userRepo.Create(&entity.User{ID: "admin", Email: "admin@example.com", Role: entity.RoleAdmin}))
+
+	sessions := &recordingSessions{}
+	uc := NewUserAdminUseCase(
+		userRepo,
+		repository.NewInMemoryAuditRepository(),
+		NewPermissionUseCase(repository.NewInMemoryRolePermissionRepository(), map[string][]string{
+			"support": {entity.PermissionUsersImpersonate},
+			"auditor": {entity.PermissionUsersRead},
+			"manager": {entity.PermissionUsersRead, entity.PermissionUsersWrite, entity.PermissionRolesWrite},
+		}),
+		resets,
+		sessions,
+	)
+	return uc, userRepo, mailer, sessions
+}
+
+func TestUserAdminUseCase_ChangeRole(t *testing.T) {
+	uc, userRepo, _ := newTestUserAdminUseCase(t)
+	admin := entity.Actor{UserID: "admin", Role: entity.RoleAdmin}
+
+	user, err := uc.ChangeRole(admin, "1", "support")
+	require.NoError(t, err)
+	assert.Equal(t, "support", user.Role)
+
+	stored, err := userRepo.GetByID("1")
+	require.NoError(t, err)
+	assert.Equal(t, "support", stored.Role)
+
+	entries, err := uc.AuditLog("1")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "admin", entries[0].ActorID)
+	assert.Equal(t, entity.AuditActionRoleChanged, entries[0].Action)
+	assert.Equal(t, "support", entries[0].NewValue)
+
+	_, err = uc.ChangeRole(admin, "1", "superuser")
+	assert.ErrorIs(t, err, constants.ErrUnknownRole)
+
+	_, err = uc.ChangeRole(admin, "admin", entity.RoleUser)
+	assert.ErrorIs(t, err, constants.ErrSelfModification)
+}
+
+func TestUserAdminUseCase_SetDisabled(t *testing.T) {
+	uc, _, _ := newTestUserAdminUseCase(t)
+	admin := entity.Actor{UserID: "admin", Role: entity.RoleAdmin}
+
+	user, err := uc.SetDisabled(admin, "1", true)
+	require.NoError(t, err)
+	assert.True(t, user.Disabled)
+
+	// Unchanged status is not recorded again
+	_, err = uc.SetDisabled(admin, "1", true)
+	require.NoError(t, err)
+
+	user, err = uc.SetDisabled(admin, "1", false)
+	require.NoError(t, err)
+	assert.False(t, user.Disabled)
+
+	entries, err := uc.AuditLog("1")
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, entity.AuditActionUserDisabled, entries[0].Action)
+	assert.Equal(t, entity.AuditActionUserEnabled, entries[1].Action)
+
+	_, err = uc.SetDisabled(admin, "admin", true)
+	assert.ErrorIs(t, err, constants.ErrSelfModification)
+}
+
+func TestUserAdminUseCase_ForcePasswordReset(t *testing.T) {
+	uc, userRepo, mailer := newTestUserAdminUseCase(t)
+	admin := entity.Actor{UserID: "admin", Role: entity.RoleAdmin}
+
+	user, err := uc.ForcePasswordReset(admin, "1")
+	require.NoError(t, err)
+	assert.True(t, user.PasswordResetRequired)
+	require.Len(t, mailer.messages, 1)
+
+	// Resetting the password lifts the requirement
+	_, err = uc.resets.ResetPassword(tokenFromLink(t, mailer.messages[0]), "new-password")
+	require.NoError(t, err)
+
+	stored, err := userRepo.GetByID("1")
+	require.NoError(t, err)
+	assert.False(t, stored.PasswordResetRequired)
+
+	entries, err := uc.AuditLog("1")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, entity.AuditActionPasswordResetForced, entries[0].Action)
+}
+
+func TestUserAdminUseCase_Impersonate(t *testing.T) {
+	uc, userRepo, _ := newTestUserAdminUseCase(t)
+	admin := entity.Actor{UserID: "admin", Role: entity.RoleAdmin}
+	support := entity.Actor{UserID: "support", Role: "support"}
+
+	require.NoError(t, userRepo.Create(&entity.User{ID: "auditor", Email: "auditor@example.com", Role: "auditor"}))
+	require.NoError(t, userRepo.Create(&entity.User{ID: "disabled", Email: "disabled@example.com", Role: entity.RoleUser, Disabled: true}))
+	require.NoError(t, userRepo.Create(&entity.User{ID: "other-admin", Email: "other@example.com", Role: entity.RoleAdmin}))
+
+	user, err := uc.Impersonate(support, "1", "ticket 4521")
+	require.NoError(t, err)
+	assert.Equal(t, "1", user.ID)
+
+	entries, err := uc.AuditLog("1")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "support", entries[0].ActorID)
+	assert.Equal(t, entity.AuditActionImpersonated, entries[0].Action)
+	assert.Equal(t, "ticket 4521", entries[0].NewValue)
+
+	tests := []struct {
+		name   string
+		actor  entity.Actor
+		userID string
+	}{
+		{name: "Admin", actor: admin, userID: "other-admin"},
+		{name: "Self", actor: admin, userID: "admin"},
+		{name: "Disabled user", actor: admin, userID: "disabled"},
+		{name: "Role with permissions the actor lacks", actor: support, userID: "auditor"},
+		{
+			name:   "While impersonating",
+			actor:  entity.Actor{UserID: "auditor", Role: "auditor", ImpersonatorID: "admin"},
+			userID: "1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := uc.Impersonate(tt.actor, tt.userID, "")
+			assert.ErrorIs(t, err, constants.ErrNotImpersonatable)
+		})
+	}
+
+	// Admins have every permission
+	_, err = uc.Impersonate(admin, "auditor", "")
+	assert.NoError(t, err)
+
+	_, err = uc.Impersonate(admin, "missing", "")
+	assert.ErrorIs(t, err, constants.ErrUserNotFound)
+}
+
+func TestUserAdminUseCase_LogsOutChangedUser(t *testing.T) {
+	uc, _, _, sessions := newTestUserAdminUseCaseWithSessions(t)
+	admin := entity.Actor{UserID: "admin", Role: entity.RoleAdmin}
+
+	_, err := uc.ChangeRole(admin, "1", "support")
+	require.NoError(t, err)
+	_, err = uc.SetDisabled(admin, "1", true)
+	require.NoError(t, err)
+	_, err = uc.ForcePasswordReset(admin, "1")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"1", "1", "1"}, sessions.loggedOut)
+
+	// Nothing changes, so nobody is logged out
+	_, err = uc.SetDisabled(admin, "1", true)
+	require.NoError(t, err)
+	assert.Len(t, sessions.loggedOut, 3)
+}
+
+func TestUserAdminUseCase_UndoesChangeIfLogoutFails(t *testing.T) {
+	uc, userRepo, _, sessions := newTestUserAdminUseCaseWithSessions(t)
+	admin := entity.Actor{UserID: "admin", Role: entity.RoleAdmin}
+	sessions.err = errors.New("revocation store unavailable")
+
+	original, err := userRepo.GetByID("1")
+	require.NoError(t, err)
+	want := *original
+
+	_, err = uc.ChangeRole(admin, "1", "support")
+	assert.ErrorIs(t, err, sessions.err)
+	_, err = uc.SetDisabled(admin, "1", true)
+	assert.ErrorIs(t, err, sessions.err)
+	_, err = uc.ForcePasswordReset(admin, "1")
+	assert.ErrorIs(t, err, sessions.err)
+
+	stored, err := userRepo.GetByID("1")
+	require.NoError(t, err)
+	assert.Equal(t, want, *stored)
+
+	entries, err := uc.AuditLog("1")
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestUserAdminUseCase_RequiresTargetPermissions(t *testing.T) {
+	uc, userRepo, _ := newTestUserAdminUseCase(t)
+	manager := entity.Actor{UserID: "manager", Role: "manager"}
+
+	require.NoError(t, userRepo.Create(&entity.User{ID: "support", Email: "support@example.com", Role: "support"}))
+
+	// Roles within the manager's own permissions can be managed and handed out
+	_, err := uc.ChangeRole(manager, "1", "auditor")
+	require.NoError(t, err)
+	_, err = uc.SetDisabled(manager, "1", true)
+	require.NoError(t, err)
+	_, err = uc.ForcePasswordReset(manager, "1")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		change func() error
+	}{
+		{name: "Promote to admin", change: func() error {
+			_, err := uc.ChangeRole(manager, "1", entity.RoleAdmin)
+			return err
+		}},
+		{name: "Grant permissions the actor lacks", change: func() error {
+			_, err := uc.ChangeRole(manager, "1", "support")
+			return err
+		}},
+		{name: "Demote an admin", change: func() error {
+			_, err := uc.ChangeRole(manager, "admin", entity.RoleUser)
+			return err
+		}},
+		{name: "Change a role with permissions the actor lacks", change: func() error {
+			_, err := uc.ChangeRole(manager, "support", entity.RoleUser)
+			return err
+		}},
+		{name: "Disable an admin", change: func() error {
+			_, err := uc.SetDisabled(manager, "admin", true)
+			return err
+		}},
+		{name: "Force a password reset on an admin", change: func() error {
+			_, err := uc.ForcePasswordReset(manager, "admin")
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorIs(t, tt.change(), constants.ErrAccessDenied)
+		})
+	}
+
+	stored, err := userRepo.GetByID("admin")
+	require.NoError(t, err)
+	assert.Equal(t, entity.RoleAdmin, stored.Role)
+	assert.False(t, stored.Disabled)
+	assert.False(t, stored.PasswordResetRequired)
+}
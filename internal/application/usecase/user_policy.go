@@ -0,0 +1,56 @@
+package usecase
+
+import (
+	"web-server/internal/domain/constants"
+	"web-server/internal/domain/entity"
+	"web-server/internal/domain/repository"
+)
+
+// UserPolicy decides who may act on a user account: users on their own, and
+// users whose role is granted the permission the action requires on any
+// account whose role has no permissions beyond their own.
+type UserPolicy struct {
+	permissions *PermissionUseCase
+	userRepo    repository.UserRepository
+}
+
+func NewUserPolicy(permissions *PermissionUseCase, userRepo repository.UserRepository) *UserPolicy {
+	return &UserPolicy{
+		permissions: permissions,
+		userRepo:    userRepo,
+	}
+}
+
+// Authorize returns constants.ErrAccessDenied unless the actor may perform an
+// action requiring the permission on the user's account, and
+// constants.ErrUserNotFound if another user's account does not exist.
+func (p *UserPolicy) Authorize(actor entity.Actor, userID, permission string) error {
+	if actor.UserID != "" && actor.UserID == userID {
+		return nil
+	}
+
+	allowed, err := p.permissions.HasPermission(actor.Role, permission)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return constants.ErrAccessDenied
+	}
+
+	user, err := p.userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+
+	// Acting on a more privileged account, e.g. changing its email, must not
+	// be a way to take it over
+	covers, err := p.permissions.Covers(actor.Role, user.Role)
+	if err != nil {
+		return err
+	}
+	if !covers {
+		return constants.ErrAccessDenied
+	}
+
+	return nil
+}
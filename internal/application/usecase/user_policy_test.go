@@ -0,0 +1,112 @@
+package usecase
+
+import (
+	"testing"
+
+	"web-server/internal/domain/constants"
+	"web-server/internal/domain/entity"
+	"web-server/internal/infrastructure/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserPolicy_Authorize(t *testing.T) {
+	users := repository.NewInMemoryUserRepository()
+	for _, user := range []*entity.User{
+		{ID: "1", Role: entity.RoleUser},
+		{ID: "2", Role: entity.RoleUser},
+		{ID: "3", Role: entity.RoleAdmin},
+		{ID: "4", Role: "auditor"},
+	} {
+		require.NoError(t, users.Create(user))
+	}
+
+	policy := NewUserPolicy(NewPermissionUseCase(
+		repository.NewInMemoryRolePermissionRepository(),
+		map[string][]string{
+			"support": {entity.PermissionUsersRead, entity.PermissionUsersWrite},
+			"auditor": {entity.PermissionUsersRead, entity.PermissionRolesRead},
+		},
+	), users)
+
+	tests := []struct {
+		name       string
+		actor      entity.Actor
+		userID     string
+		permission string
+		wantErr    error
+	}{
+		{
+			name:       "User acts on own account",
+			actor:      entity.Actor{UserID: "1", Role: entity.RoleUser},
+			userID:     "1",
+			permission: entity.PermissionUsersDelete,
+		},
+		{
+			name:       "User cannot act on another account",
+			actor:      entity.Actor{UserID: "1", Role: entity.RoleUser},
+			userID:     "2",
+			permission: entity.PermissionUsersRead,
+			wantErr:    constants.ErrAccessDenied,
+		},
+		{
+			name:       "Admin acts on any account",
+			actor:      entity.Actor{UserID: "1", Role: entity.RoleAdmin},
+			userID:     "2",
+			permission: entity.PermissionUsersDelete,
+		},
+		{
+			name:       "Role with the permission",
+			actor:      entity.Actor{UserID: "1", Role: "support"},
+			userID:     "2",
+			permission: entity.PermissionUsersRead,
+		},
+		{
+			name:       "Role without the permission",
+			actor:      entity.Actor{UserID: "1", Role: "support"},
+			userID:     "2",
+			permission: entity.PermissionUsersDelete,
+			wantErr:    constants.ErrAccessDenied,
+		},
+		{
+			name:       "Role cannot act on an admin",
+			actor:      entity.Actor{UserID: "1", Role: "support"},
+			userID:     "3",
+			permission: entity.PermissionUsersWrite,
+			wantErr:    constants.ErrAccessDenied,
+		},
+		{
+			name:       "Role cannot act on a role with other permissions",
+			actor:      entity.Actor{UserID: "1", Role: "support"},
+			userID:     "4",
+			permission: entity.PermissionUsersRead,
+			wantErr:    constants.ErrAccessDenied,
+		},
+		{
+			name:       "Unknown user",
+			actor:      entity.Actor{UserID: "1", Role: "support"},
+			userID:     "5",
+			permission: entity.PermissionUsersRead,
+			wantErr:    constants.ErrUserNotFound,
+		},
+		{
+			name:       "Anonymous actor",
+			actor:      entity.Actor{},
+			userID:     "",
+			permission: entity.PermissionUsersRead,
+			wantErr:    constants.ErrAccessDenied,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Authorize(tt.actor, tt.userID, tt.permission)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -1,18 +1,106 @@
 package usecase
 
 import (
+	"errors"
+
+	"web-server/internal/domain/constants"
 	"web-server/internal/domain/entity"
 	"web-server/internal/domain/repository"
+	"web-server/internal/domain/service"
 )
 
 type UserUseCase struct {
-	userRepo repository.UserRepository
+	userRepo  repository.UserRepository
+	hasher    service.PasswordHasher
+	passwords *PasswordValidator
 }
 
-func NewUserUseCase(repo repository.UserRepository) *UserUseCase {
+func NewUserUseCase(
+	repo repository.UserRepository,
+	hasher service.PasswordHasher,
+	passwords *PasswordValidator,
+) *UserUseCase {
 	return &UserUseCase{
-		userRepo: repo,
+		userRepo:  repo,
+		hasher:    hasher,
+		passwords: passwords,
+	}
+}
+
+// ValidatePassword returns an *entity.ValidationError for the password field
+// if the user may not choose the password.
+func (uc *UserUseCase) ValidatePassword(password string, user *entity.User) error {
+	return uc.passwords.Validate("password", password, user)
+}
+
+// ChangePassword replaces the password of a user who knows their current one.
+// A wrong current password or a new password that may not be chosen is
+// reported as an *entity.ValidationError.
+func (uc *UserUseCase) ChangePassword(userID, currentPassword, newPassword string) error {
+	user, err := uc.userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+
+	if !uc.hasher.Verify(currentPassword, user.Password) {
+		return entity.NewValidationError("current_password", "is incorrect")
+	}
+
+	if err := uc.passwords.Validate("new_password", newPassword, user); err != nil {
+		return err
+	}
+
+	hashed, err := uc.hasher.Hash(newPassword)
+	if err != nil {
+		return err
+	}
+
+	user.Password = hashed
+	return uc.userRepo.Update(user)
+}
+
+// CheckPassword reports whether the password is the user's current one.
+func (uc *UserUseCase) CheckPassword(user *entity.User, password string) bool {
+	return uc.hasher.Verify(password, user.Password)
+}
+
+// HashPassword hashes a password for storage.
+func (uc *UserUseCase) HashPassword(password string) (string, error) {
+	return uc.hasher.Hash(password)
+}
+
+// Authenticate returns the user with the email if the password matches.
+// Unknown emails and wrong passwords both return constants.ErrInvalidCredentials.
+func (uc *UserUseCase) Authenticate(email, password string) (*entity.User, error) {
+	user, err := uc.userRepo.GetByEmail(email)
+	if errors.Is(err, constants.ErrUserNotFound) {
+		return nil, constants.ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !uc.hasher.Verify(password, user.Password) {
+		return nil, constants.ErrInvalidCredentials
 	}
+
+	return user, nil
+}
+
+// UpgradePasswordHash rehashes the password of an authenticated user if the
+// stored hash was made with an outdated algorithm or parameters.
+func (uc *UserUseCase) UpgradePasswordHash(user *entity.User, password string) error {
+	if !uc.hasher.NeedsRehash(user.Password) {
+		return nil
+	}
+
+	hashed, err := uc.hasher.Hash(password)
+	if err != nil {
+		return err
+	}
+
+	user.Password = hashed
+	return uc.userRepo.Update(user)
 }
 
 func (uc *UserUseCase) CreateUser(user *entity.User) error {
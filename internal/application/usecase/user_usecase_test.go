@@ -3,15 +3,34 @@ package usecase
 import (
 	"testing"
 
+	"web-server/internal/domain/constants"
 	"web-server/internal/domain/entity"
+	"web-server/internal/domain/service"
+	"web-server/internal/infrastructure/config"
+	"web-server/internal/infrastructure/hasher"
 	"web-server/internal/infrastructure/repository"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+// newTestHasher returns an argon2id hasher with cheap parameters.
+func newTestHasher(t *testing.T) service.PasswordHasher {
+	t.Helper()
+	passwordHasher, err := hasher.New(config.PasswordHashConfig{
+		Algorithm:         "argon2id",
+		BcryptCost:        4,
+		Argon2Memory:      1024,
+		Argon2Iterations:  1,
+		Argon2Parallelism: 1,
+	})
+	require.NoError(t, err)
+	return passwordHasher
+}
+
 func TestUserUseCase(t *testing.T) {
 	repo := repository.NewInMemoryUserRepository()
-	useCase := NewUserUseCase(repo)
+	useCase := NewUserUseCase(repo, newTestHasher(t), newTestPasswordValidator())
 
 	t.Run("Create User", func(t *testing.T) {
 		user := &entity.User{
@@ -98,3 +117,112 @@ func TestUserUseCase(t *testing.T) {
 		assert.Nil(t, user)
 	})
 }
+
+func TestUserUseCase_Authenticate(t *testing.T) {
+	repo := repository.NewInMemoryUserRepository()
+	useCase := NewUserUseCase(repo, newTestHasher(t), newTestPasswordValidator())
+
+	hashed, err := useCase.HashPassword("password123")
+	require.NoError(t, err)
+	require.NoError(t, repo.Create(&entity.User{ID: "1", Email: "test@example.com", Password: hashed}))
+
+	user, err := useCase.Authenticate("test@example.com", "password123")
+	require.NoError(t, err)
+	assert.Equal(t, "1", user.ID)
+
+	_, err = useCase.Authenticate("test@example.com", "wrong")
+	assert.ErrorIs(t, err, constants.ErrInvalidCredentials)
+
+	_, err = useCase.Authenticate("unknown@example.com", "password123")
+	assert.ErrorIs(t, err, constants.ErrInvalidCredentials)
+}
+
+func TestUserUseCase_UpgradePasswordHash(t *testing.T) {
+	repo := repository.NewInMemoryUserRepository()
+
+	legacy, err := hasher.New(config.PasswordHashConfig{
+		Algorithm:         "bcrypt",
+		BcryptCost:        4,
+		Argon2Memory:      1024,
+		Argon2Iterations:  1,
+		Argon2Parallelism: 1,
+	})
+	require.NoError(t, err)
+	hashed, err := legacy.Hash("password123")
+	require.NoError(t, err)
+	require.NoError(t, repo.Create(&entity.User{ID: "1", Email: "test@example.com", Password: hashed}))
+
+	passwordHasher := newTestHasher(t)
+	useCase := NewUserUseCase(repo, passwordHasher, newTestPasswordValidator())
+
+	// bcrypt hashes still verify after switching to argon2id
+	user, err := useCase.Authenticate("test@example.com", "password123")
+	require.NoError(t, err)
+	require.NoError(t, useCase.UpgradePasswordHash(user, "password123"))
+
+	upgraded, err := repo.GetByID("1")
+	require.NoError(t, err)
+	assert.NotEqual(t, hashed, upgraded.Password)
+	assert.False(t, passwordHasher.NeedsRehash(upgraded.Password))
+	assert.True(t, passwordHasher.Verify("password123", upgraded.Password))
+
+	// Current hashes are left alone
+	require.NoError(t, useCase.UpgradePasswordHash(upgraded, "password123"))
+	current, err := repo.GetByID("1")
+	require.NoError(t, err)
+	assert.Equal(t, upgraded.Password, current.Password)
+}
+
+func TestUserUseCase_ChangePassword(t *testing.T) {
+	repo := repository.NewInMemoryUserRepository()
+	passwordHasher := newTestHasher(t)
+	useCase := NewUserUseCase(repo, passwordHasher, newTestPasswordValidator())
+
+	hashed, err := useCase.HashPassword("old-password")
+	require.NoError(t, err)
+	require.NoError(t, repo.Create(&entity.User{ID: "1", Username: "test", Email: "test@example.com", Password: hashed}))
+
+	tests := []struct {
+		name            string
+		currentPassword string
+		newPassword     string
+		wantFields      map[string][]string
+	}{
+		{
+			name:            "Wrong current password",
+			currentPassword: "wrong-password",
+			newPassword:     "kabocha-squash",
+			wantFields:      map[string][]string{"current_password": {"is incorrect"}},
+		},
+		{
+			name:            "Too short",
+			currentPassword: "old-password",
+			newPassword:     "short",
+			wantFields:      map[string][]string{"new_password": {"must be at least 8 characters long"}},
+		},
+		{
+			name:            "Breached",
+			currentPassword: "old-password",
+			newPassword:     "password123",
+			wantFields:      map[string][]string{"new_password": {"appears in a known data breach, choose another password"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := useCase.ChangePassword("1", tt.currentPassword, tt.newPassword)
+			var validationErr *entity.ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			assert.Equal(t, tt.wantFields, validationErr.Fields)
+
+			user, err := repo.GetByID("1")
+			require.NoError(t, err)
+			assert.True(t, passwordHasher.Verify("old-password", user.Password))
+		})
+	}
+
+	require.NoError(t, useCase.ChangePassword("1", "old-password", "kabocha-squash"))
+	user, err := repo.GetByID("1")
+	require.NoError(t, err)
+	assert.True(t, passwordHasher.Verify("kabocha-squash", user.Password))
+}
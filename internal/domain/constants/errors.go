@@ -13,12 +13,122 @@ var (
 	ErrUnexpectedSigningMethod = errors.New("unexpected JWT signing method")
 	ErrInvalidRefreshToken     = errors.New("invalid refresh token")
 	ErrInvalidTokenType        = errors.New("invalid token type")
+	ErrRefreshTokenReused      = errors.New("refresh token reuse detected")
+	ErrRefreshTokenRevoked     = errors.New("refresh token revoked")
+	ErrInvalidAccessToken      = errors.New("invalid access token")
+	ErrAccessTokenRevoked      = errors.New("access token revoked")
+	ErrInvalidSigningKey       = errors.New("invalid signing key")
+	ErrSigningKeyNotFound      = errors.New("signing key not found")
+	ErrSigningKeyExists        = errors.New("signing key already exists")
+	ErrPrimaryKeyRemoval       = errors.New("primary signing key cannot be removed")
+)
+
+// MFA errors.
+var (
+	ErrInvalidMFACode    = errors.New("invalid MFA code")
+	ErrInvalidMFAToken   = errors.New("invalid MFA token")
+	ErrMFANotEnrolled    = errors.New("MFA is not enrolled")
+	ErrMFAAlreadyEnabled = errors.New("MFA is already enabled")
+	ErrMFARequired       = errors.New("MFA is required for this role")
+	ErrMFAChanged        = errors.New("MFA settings changed concurrently")
+)
+
+// Password reset errors.
+var (
+	ErrInvalidResetToken = errors.New("invalid or expired password reset token")
+)
+
+// Email verification errors.
+var (
+	ErrInvalidVerificationToken = errors.New("invalid or expired email verification token")
+	ErrEmailNotVerified         = errors.New("email address is not verified")
+)
+
+// Login errors.
+var (
+	ErrInvalidCredentials = errors.New("invalid email or password")
+)
+
+// Magic link errors.
+var (
+	ErrInvalidLoginLink = errors.New("invalid or expired login link")
+)
+
+// External login errors.
+var (
+	ErrInvalidOIDCState         = errors.New("invalid or expired OIDC login state")
+	ErrInvalidIDToken           = errors.New("invalid ID token")
+	ErrOIDCCodeExchange         = errors.New("OIDC authorization code exchange failed")
+	ErrExternalEmailNotVerified = errors.New("email address is not verified by the identity provider")
+)
+
+// Login throttling errors.
+var (
+	ErrTooManyLoginAttempts = errors.New("too many failed login attempts")
+)
+
+// API key errors.
+var (
+	ErrInvalidAPIKey      = errors.New("invalid API key")
+	ErrInvalidAPIKeyScope = errors.New("invalid API key scope")
+)
+
+// OAuth errors.
+var (
+	ErrInvalidClient = errors.New("invalid client credentials")
+	ErrInvalidScope  = errors.New("invalid scope")
+)
+
+// Encryption errors.
+var (
+	ErrInvalidEnvelope = errors.New("invalid encryption envelope")
+	ErrEnvelopeExpired = errors.New("encryption envelope timestamp out of range")
+	ErrLegacyEnvelope  = errors.New("legacy encryption format is disabled")
+
+	ErrEncryptionKeyNotFound       = errors.New("encryption key not found")
+	ErrInvalidEncryptionKey        = errors.New("invalid encryption key")
+	ErrEncryptionKeyExists         = errors.New("encryption key already exists")
+	ErrPrimaryEncryptionKeyRemoval = errors.New("primary encryption key cannot be removed")
+
+	ErrInvalidEncapsulatedKey    = errors.New("invalid encapsulated key")
+	ErrEncryptionSessionNotFound = errors.New("encryption session not found")
+)
+
+// Authorization errors.
+var (
+	ErrInvalidPermission = errors.New("invalid permission")
+	ErrUnknownRole       = errors.New("unknown role")
+	ErrBuiltInRole       = errors.New("permissions of the admin role cannot be changed")
+	ErrAccessDenied      = errors.New("access denied")
+)
+
+// User administration errors.
+var (
+	ErrAccountDisabled       = errors.New("account is disabled")
+	ErrPasswordResetRequired = errors.New("password reset required")
+	ErrSelfModification      = errors.New("admins cannot change their own role or status")
+	ErrNotImpersonatable     = errors.New("user cannot be impersonated")
 )
 
 // Repository errors.
 var (
 	ErrUserNotFound      = errors.New("user not found")
 	ErrUserAlreadyExists = errors.New("user already exists")
+
+	ErrRefreshTokenNotFound = errors.New("refresh token not found")
+	ErrSessionNotFound      = errors.New("session not found")
+
+	ErrPasswordResetTokenNotFound = errors.New("password reset token not found")
+	ErrLoginLinkNotFound          = errors.New("login link not found")
+
+	ErrLoginAttemptNotFound = errors.New("login attempt not found")
+	ErrLoginAttemptChanged  = errors.New("login attempt changed concurrently")
+
+	ErrAPIKeyNotFound = errors.New("API key not found")
+
+	ErrOAuthClientNotFound = errors.New("OAuth client not found")
+
+	ErrUserIdentityNotFound = errors.New("user identity not found")
 )
 
 // Authentication Errors.
@@ -43,6 +153,41 @@ func ErrInvalidToken() ErrorResponse {
 	}
 }
 
+func ErrTokenRevoked() ErrorResponse {
+	return ErrorResponse{
+		Code:    "TOKEN_REVOKED",
+		Message: "Token has been revoked",
+	}
+}
+
+func ErrEmailVerificationRequired() ErrorResponse {
+	return ErrorResponse{
+		Code:    "EMAIL_NOT_VERIFIED",
+		Message: "Email address must be verified",
+	}
+}
+
+func ErrLoginLockedOut() ErrorResponse {
+	return ErrorResponse{
+		Code:    "TOO_MANY_LOGIN_ATTEMPTS",
+		Message: "Too many failed login attempts, try again later",
+	}
+}
+
+func ErrAPIKeyRejected() ErrorResponse {
+	return ErrorResponse{
+		Code:    "INVALID_API_KEY",
+		Message: "Invalid, expired or revoked API key",
+	}
+}
+
+func ErrInsufficientScope() ErrorResponse {
+	return ErrorResponse{
+		Code:    "INSUFFICIENT_SCOPE",
+		Message: "API key scope does not allow this request",
+	}
+}
+
 func ErrRoleNotFound() ErrorResponse {
 	return ErrorResponse{
 		Code:    "ROLE_NOT_FOUND",
@@ -57,6 +202,34 @@ func ErrInsufficientPermissions() ErrorResponse {
 	}
 }
 
+func ErrImpersonationForbidden() ErrorResponse {
+	return ErrorResponse{
+		Code:    "IMPERSONATION_FORBIDDEN",
+		Message: "Not allowed while impersonating a user",
+	}
+}
+
+func ErrTokenScopeInsufficient() ErrorResponse {
+	return ErrorResponse{
+		Code:    "INSUFFICIENT_SCOPE",
+		Message: "Token scope does not allow this request",
+	}
+}
+
+func ErrUserTokenRequired() ErrorResponse {
+	return ErrorResponse{
+		Code:    "USER_TOKEN_REQUIRED",
+		Message: "Only available to users, not to OAuth clients",
+	}
+}
+
+func ErrCSRFTokenInvalid() ErrorResponse {
+	return ErrorResponse{
+		Code:    "CSRF_TOKEN_INVALID",
+		Message: "Missing or invalid CSRF token",
+	}
+}
+
 // Encryption Errors.
 func ErrRequestBodyRead() ErrorResponse {
 	return ErrorResponse{
@@ -79,6 +252,48 @@ func ErrDecryption() ErrorResponse {
 	}
 }
 
+func ErrMalformedEnvelope() ErrorResponse {
+	return ErrorResponse{
+		Code:    "INVALID_ENCRYPTED_BODY",
+		Message: "Encrypted body is malformed or could not be decrypted",
+	}
+}
+
+func ErrStaleEnvelope() ErrorResponse {
+	return ErrorResponse{
+		Code:    "STALE_ENCRYPTED_BODY",
+		Message: "Encrypted body timestamp is too far from the server time",
+	}
+}
+
+func ErrUnknownEncryptionKey() ErrorResponse {
+	return ErrorResponse{
+		Code:    "UNKNOWN_ENCRYPTION_KEY",
+		Message: "Encrypted body names an unknown key",
+	}
+}
+
+func ErrLegacyEnvelopeRejected() ErrorResponse {
+	return ErrorResponse{
+		Code:    "LEGACY_ENCRYPTION_DISABLED",
+		Message: "Legacy encrypted bodies are no longer accepted",
+	}
+}
+
+func ErrUnknownEncryptionSession() ErrorResponse {
+	return ErrorResponse{
+		Code:    "UNKNOWN_ENCRYPTION_SESSION",
+		Message: "Encryption session is unknown or expired, repeat the handshake",
+	}
+}
+
+func ErrEncryptionSessionRequired() ErrorResponse {
+	return ErrorResponse{
+		Code:    "ENCRYPTION_SESSION_REQUIRED",
+		Message: "Encrypted requests need a session key from the handshake",
+	}
+}
+
 // Generic Errors.
 func ErrInternalServer() ErrorResponse {
 	return ErrorResponse{
@@ -11,10 +11,88 @@ func TestErrorConstants(t *testing.T) {
 	assert.Equal(t, "unexpected JWT signing method", ErrUnexpectedSigningMethod.Error())
 	assert.Equal(t, "invalid refresh token", ErrInvalidRefreshToken.Error())
 	assert.Equal(t, "invalid token type", ErrInvalidTokenType.Error())
+	assert.Equal(t, "refresh token reuse detected", ErrRefreshTokenReused.Error())
+	assert.Equal(t, "refresh token revoked", ErrRefreshTokenRevoked.Error())
+	assert.Equal(t, "invalid access token", ErrInvalidAccessToken.Error())
+	assert.Equal(t, "access token revoked", ErrAccessTokenRevoked.Error())
+	assert.Equal(t, "invalid signing key", ErrInvalidSigningKey.Error())
+	assert.Equal(t, "signing key not found", ErrSigningKeyNotFound.Error())
+	assert.Equal(t, "signing key already exists", ErrSigningKeyExists.Error())
+	assert.Equal(t, "primary signing key cannot be removed", ErrPrimaryKeyRemoval.Error())
+
+	// Test MFA errors
+	assert.Equal(t, "invalid MFA code", ErrInvalidMFACode.Error())
+	assert.Equal(t, "invalid MFA token", ErrInvalidMFAToken.Error())
+	assert.Equal(t, "MFA is not enrolled", ErrMFANotEnrolled.Error())
+	assert.Equal(t, "MFA is already enabled", ErrMFAAlreadyEnabled.Error())
+	assert.Equal(t, "MFA is required for this role", ErrMFARequired.Error())
+	assert.Equal(t, "MFA settings changed concurrently", ErrMFAChanged.Error())
+
+	// Test Password reset errors
+	assert.Equal(t, "invalid or expired password reset token", ErrInvalidResetToken.Error())
+
+	// Test Email verification errors
+	assert.Equal(t, "invalid or expired email verification token", ErrInvalidVerificationToken.Error())
+	assert.Equal(t, "email address is not verified", ErrEmailNotVerified.Error())
+
+	// Test Login errors
+	assert.Equal(t, "invalid email or password", ErrInvalidCredentials.Error())
+
+	// Test Magic link errors
+	assert.Equal(t, "invalid or expired login link", ErrInvalidLoginLink.Error())
+
+	// Test External login errors
+	assert.Equal(t, "invalid or expired OIDC login state", ErrInvalidOIDCState.Error())
+	assert.Equal(t, "invalid ID token", ErrInvalidIDToken.Error())
+	assert.Equal(t, "OIDC authorization code exchange failed", ErrOIDCCodeExchange.Error())
+	assert.Equal(t, "email address is not verified by the identity provider", ErrExternalEmailNotVerified.Error())
+
+	// Test Login throttling errors
+	assert.Equal(t, "too many failed login attempts", ErrTooManyLoginAttempts.Error())
+
+	// Test API key errors
+	assert.Equal(t, "invalid API key", ErrInvalidAPIKey.Error())
+	assert.Equal(t, "invalid API key scope", ErrInvalidAPIKeyScope.Error())
+
+	// Test OAuth errors
+	assert.Equal(t, "invalid client credentials", ErrInvalidClient.Error())
+	assert.Equal(t, "invalid scope", ErrInvalidScope.Error())
+
+	// Test Encryption errors
+	assert.Equal(t, "invalid encryption envelope", ErrInvalidEnvelope.Error())
+	assert.Equal(t, "encryption envelope timestamp out of range", ErrEnvelopeExpired.Error())
+	assert.Equal(t, "legacy encryption format is disabled", ErrLegacyEnvelope.Error())
+	assert.Equal(t, "encryption key not found", ErrEncryptionKeyNotFound.Error())
+	assert.Equal(t, "invalid encryption key", ErrInvalidEncryptionKey.Error())
+	assert.Equal(t, "encryption key already exists", ErrEncryptionKeyExists.Error())
+	assert.Equal(t, "primary encryption key cannot be removed", ErrPrimaryEncryptionKeyRemoval.Error())
+	assert.Equal(t, "invalid encapsulated key", ErrInvalidEncapsulatedKey.Error())
+	assert.Equal(t, "encryption session not found", ErrEncryptionSessionNotFound.Error())
+
+	// Test Authorization errors
+	assert.Equal(t, "invalid permission", ErrInvalidPermission.Error())
+	assert.Equal(t, "unknown role", ErrUnknownRole.Error())
+	assert.Equal(t, "permissions of the admin role cannot be changed", ErrBuiltInRole.Error())
+	assert.Equal(t, "access denied", ErrAccessDenied.Error())
+
+	// Test User administration errors
+	assert.Equal(t, "account is disabled", ErrAccountDisabled.Error())
+	assert.Equal(t, "password reset required", ErrPasswordResetRequired.Error())
+	assert.Equal(t, "admins cannot change their own role or status", ErrSelfModification.Error())
+	assert.Equal(t, "user cannot be impersonated", ErrNotImpersonatable.Error())
 
 	// Test Repository errors
 	assert.Equal(t, "user not found", ErrUserNotFound.Error())
 	assert.Equal(t, "user already exists", ErrUserAlreadyExists.Error())
+	assert.Equal(t, "refresh token not found", ErrRefreshTokenNotFound.Error())
+	assert.Equal(t, "session not found", ErrSessionNotFound.Error())
+	assert.Equal(t, "password reset token not found", ErrPasswordResetTokenNotFound.Error())
+	assert.Equal(t, "login link not found", ErrLoginLinkNotFound.Error())
+	assert.Equal(t, "login attempt not found", ErrLoginAttemptNotFound.Error())
+	assert.Equal(t, "login attempt changed concurrently", ErrLoginAttemptChanged.Error())
+	assert.Equal(t, "API key not found", ErrAPIKeyNotFound.Error())
+	assert.Equal(t, "OAuth client not found", ErrOAuthClientNotFound.Error())
+	assert.Equal(t, "user identity not found", ErrUserIdentityNotFound.Error())
 }
 
 func TestAuthenticationErrors(t *testing.T) {
@@ -42,6 +120,36 @@ func TestAuthenticationErrors(t *testing.T) {
 			wantCode: "INVALID_TOKEN",
 			wantMsg:  "Invalid token",
 		},
+		{
+			name:     "Token revoked",
+			errFunc:  ErrTokenRevoked,
+			wantCode: "TOKEN_REVOKED",
+			wantMsg:  "Token has been revoked",
+		},
+		{
+			name:     "Email verification required",
+			errFunc:  ErrEmailVerificationRequired,
+			wantCode: "EMAIL_NOT_VERIFIED",
+			wantMsg:  "Email address must be verified",
+		},
+		{
+			name:     "Login locked out",
+			errFunc:  ErrLoginLockedOut,
+			wantCode: "TOO_MANY_LOGIN_ATTEMPTS",
+			wantMsg:  "Too many failed login attempts, try again later",
+		},
+		{
+			name:     "API key rejected",
+			errFunc:  ErrAPIKeyRejected,
+			wantCode: "INVALID_API_KEY",
+			wantMsg:  "Invalid, expired or revoked API key",
+		},
+		{
+			name:     "Insufficient scope",
+			errFunc:  ErrInsufficientScope,
+			wantCode: "INSUFFICIENT_SCOPE",
+			wantMsg:  "API key scope does not allow this request",
+		},
 		{
 			name:     "Role not found",
 			errFunc:  ErrRoleNotFound,
@@ -54,6 +162,30 @@ func TestAuthenticationErrors(t *testing.T) {
 			wantCode: "INSUFFICIENT_PERMISSIONS",
 			wantMsg:  "Insufficient permissions",
 		},
+		{
+			name:     "Impersonation forbidden",
+			errFunc:  ErrImpersonationForbidden,
+			wantCode: "IMPERSONATION_FORBIDDEN",
+			wantMsg:  "Not allowed while impersonating a user",
+		},
+		{
+			name:     "Token scope insufficient",
+			errFunc:  ErrTokenScopeInsufficient,
+			wantCode: "INSUFFICIENT_SCOPE",
+			wantMsg:  "Token scope does not allow this request",
+		},
+		{
+			name:     "User token required",
+			errFunc:  ErrUserTokenRequired,
+			wantCode: "USER_TOKEN_REQUIRED",
+			wantMsg:  "Only available to users, not to OAuth clients",
+		},
+		{
+			name:     "CSRF token invalid",
+			errFunc:  ErrCSRFTokenInvalid,
+			wantCode: "CSRF_TOKEN_INVALID",
+			wantMsg:  "Missing or invalid CSRF token",
+		},
 	}
 
 	for _, tt := range tests {
@@ -90,6 +222,42 @@ func TestEncryptionErrors(t *testing.T) {
 			wantCode: "DECRYPTION_ERROR",
 			wantMsg:  "Decryption error occurred",
 		},
+		{
+			name:     "Malformed envelope",
+			errFunc:  ErrMalformedEnvelope,
+			wantCode: "INVALID_ENCRYPTED_BODY",
+			wantMsg:  "Encrypted body is malformed or could not be decrypted",
+		},
+		{
+			name:     "Stale envelope",
+			errFunc:  ErrStaleEnvelope,
+			wantCode: "STALE_ENCRYPTED_BODY",
+			wantMsg:  "Encrypted body timestamp is too far from the server time",
+		},
+		{
+			name:     "Unknown encryption key",
+			errFunc:  ErrUnknownEncryptionKey,
+			wantCode: "UNKNOWN_ENCRYPTION_KEY",
+			wantMsg:  "Encrypted body names an unknown key",
+		},
+		{
+			name:     "Legacy envelope rejected",
+			errFunc:  ErrLegacyEnvelopeRejected,
+			wantCode: "LEGACY_ENCRYPTION_DISABLED",
+			wantMsg:  "Legacy encrypted bodies are no longer accepted",
+		},
+		{
+			name:     "Unknown encryption session",
+			errFunc:  ErrUnknownEncryptionSession,
+			wantCode: "UNKNOWN_ENCRYPTION_SESSION",
+			wantMsg:  "Encryption session is unknown or expired, repeat the handshake",
+		},
+		{
+			name:     "Encryption session required",
+			errFunc:  ErrEncryptionSessionRequired,
+			wantCode: "ENCRYPTION_SESSION_REQUIRED",
+			wantMsg:  "Encrypted requests need a session key from the handshake",
+		},
 	}
 
 	for _, tt := range tests {
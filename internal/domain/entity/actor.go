@@ -0,0 +1,16 @@
+package entity
+
+// Actor is the authenticated user a request is made by.
+type Actor struct {
+	UserID string
+	Role   string
+
+	// ImpersonatorID is the admin acting as the user with an impersonation
+	// token, empty for the user's own requests.
+	ImpersonatorID string
+}
+
+// IsImpersonated reports whether the request is made by an admin acting as the user.
+func (a Actor) IsImpersonated() bool {
+	return a.ImpersonatorID != ""
+}
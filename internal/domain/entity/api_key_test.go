@@ -0,0 +1,76 @@
+package entity
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateAPIKey(t *testing.T) {
+	key, prefix, hash, err := GenerateAPIKey()
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(key, "wsk_"))
+	assert.True(t, strings.HasPrefix(key, prefix))
+	assert.Less(t, len(prefix), len(key))
+	assert.Equal(t, HashOneTimeToken(key), hash)
+}
+
+func TestAPIKey_IsActive(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Minute)
+
+	tests := []struct {
+		name string
+		key  APIKey
+		want bool
+	}{
+		{name: "Without expiry", key: APIKey{}, want: true},
+		{name: "Not expired", key: APIKey{ExpiresAt: &future}, want: true},
+		{name: "Expired", key: APIKey{ExpiresAt: &past}, want: false},
+		{name: "Revoked", key: APIKey{RevokedAt: &past}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.key.IsActive(now))
+		})
+	}
+}
+
+func TestAPIKey_Scopes(t *testing.T) {
+	tests := []struct {
+		name      string
+		scopes    []string
+		method    string
+		wantAllow bool
+		wantRole  string
+	}{
+		{name: "Unscoped key can write", method: http.MethodDelete, wantAllow: true, wantRole: "admin"},
+		{name: "Read scope can read", scopes: []string{"read"}, method: http.MethodGet, wantAllow: true, wantRole: "user"},
+		{name: "Read scope cannot write", scopes: []string{"read"}, method: http.MethodPost, wantAllow: false, wantRole: "user"},
+		{name: "Write scope can write", scopes: []string{"write"}, method: http.MethodPut, wantAllow: true, wantRole: "user"},
+		{name: "Admin scope keeps the role", scopes: []string{"read", "admin"}, method: http.MethodGet, wantAllow: true, wantRole: "admin"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := APIKey{Scopes: tt.scopes}
+			assert.Equal(t, tt.wantAllow, key.AllowsMethod(tt.method))
+			assert.Equal(t, tt.wantRole, key.Role("admin"))
+			assert.Equal(t, "user", key.Role("user"))
+
+			// Custom roles are limited the same way as admins
+			wantCustomRole := "support"
+			if tt.wantRole == "user" {
+				wantCustomRole = "user"
+			}
+			assert.Equal(t, wantCustomRole, key.Role("support"))
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package entity
+
+import "time"
+
+// Actions recorded in the audit log.
+const (
+	AuditActionRoleChanged         = "role_changed"
+	AuditActionUserDisabled        = "user_disabled"
+	AuditActionUserEnabled         = "user_enabled"
+	AuditActionPasswordResetForced = "password_reset_forced"
+	AuditActionImpersonated        = "impersonated" // NewValue holds the reason given by the admin
+)
+
+// AuditEntry records an administrative action on a user account and who
+// performed it.
+type AuditEntry struct {
+	ID        string    `json:"id"`
+	ActorID   string    `json:"actor_id"` // User who performed the action
+	UserID    string    `json:"user_id"`  // User the action was performed on
+	Action    string    `json:"action"`
+	OldValue  string    `json:"old_value,omitempty"`
+	NewValue  string    `json:"new_value,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
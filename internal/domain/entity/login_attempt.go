@@ -0,0 +1,48 @@
+package entity
+
+import "time"
+
+// LoginAttempt counts the recent failed logins for a key, such as an email
+// address or a client IP.
+type LoginAttempt struct {
+	Key           string    `json:"key"`
+	Failures      int       `json:"failures"`
+	LastFailureAt time.Time `json:"last_failure_at"`
+}
+
+// LoginThrottlePolicy decides how long a key has to wait after failed logins.
+// After FreeAttempts failures every further failure doubles the wait, starting
+// at BaseDelay, and MaxFailures failures lock the key for LockoutDuration.
+type LoginThrottlePolicy struct {
+	FreeAttempts    int
+	MaxFailures     int
+	BaseDelay       time.Duration
+	LockoutDuration time.Duration
+}
+
+// LockedUntil returns the time until which no login is accepted for the key.
+// It is the zero time if the key may log in right away.
+func (p LoginThrottlePolicy) LockedUntil(attempt *LoginAttempt) time.Time {
+	if attempt == nil || attempt.Failures <= p.FreeAttempts {
+		return time.Time{}
+	}
+
+	if attempt.Failures >= p.MaxFailures {
+		return attempt.LastFailureAt.Add(p.LockoutDuration)
+	}
+
+	delay := p.BaseDelay
+	for i := p.FreeAttempts + 1; i < attempt.Failures && delay < p.LockoutDuration; i++ {
+		delay *= 2
+	}
+	if delay > p.LockoutDuration {
+		delay = p.LockoutDuration
+	}
+
+	return attempt.LastFailureAt.Add(delay)
+}
+
+// IsExpired reports whether the failures are old enough to be forgotten.
+func (p LoginThrottlePolicy) IsExpired(attempt *LoginAttempt, now time.Time) bool {
+	return now.Sub(attempt.LastFailureAt) >= p.LockoutDuration
+}
@@ -0,0 +1,49 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoginThrottlePolicy_LockedUntil(t *testing.T) {
+	policy := LoginThrottlePolicy{
+		FreeAttempts:    2,
+		MaxFailures:     6,
+		BaseDelay:       time.Second,
+		LockoutDuration: time.Minute,
+	}
+	last := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		failures  int
+		wantDelay time.Duration
+	}{
+		{name: "No failures", failures: 0},
+		{name: "Within free attempts", failures: 2},
+		{name: "First delayed failure", failures: 3, wantDelay: time.Second},
+		{name: "Delay doubles", failures: 4, wantDelay: 2 * time.Second},
+		{name: "Delay doubles again", failures: 5, wantDelay: 4 * time.Second},
+		{name: "Locked out", failures: 6, wantDelay: time.Minute},
+		{name: "Stays locked out", failures: 9, wantDelay: time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			until := policy.LockedUntil(&LoginAttempt{Failures: tt.failures, LastFailureAt: last})
+			if tt.wantDelay == 0 {
+				assert.True(t, until.IsZero())
+				return
+			}
+			assert.Equal(t, last.Add(tt.wantDelay), until)
+		})
+	}
+
+	t.Run("Delay is capped at the lockout duration", func(t *testing.T) {
+		capped := LoginThrottlePolicy{FreeAttempts: 0, MaxFailures: 100, BaseDelay: time.Second, LockoutDuration: time.Minute}
+		until := capped.LockedUntil(&LoginAttempt{Failures: 50, LastFailureAt: last})
+		assert.Equal(t, last.Add(time.Minute), until)
+	})
+}
@@ -0,0 +1,19 @@
+package entity
+
+import "time"
+
+// LoginLink records a passwordless login link emailed to a user. The link
+// carries a signed token naming the record, which is claimed on first use so
+// that the link works only once.
+type LoginLink struct {
+	ID        string     `json:"id"`
+	UserID    string     `json:"user_id"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+}
+
+// IsValid reports whether the link is unused and not expired at the given time.
+func (l *LoginLink) IsValid(now time.Time) bool {
+	return l.UsedAt == nil && now.Before(l.ExpiresAt)
+}
@@ -0,0 +1,77 @@
+package entity
+
+import "time"
+
+// oauthClientSecretPrefix marks OAuth client secrets so that they are easy to
+// recognize, for example by secret scanners.
+const oauthClientSecretPrefix = "wcs_"
+
+// ClientSubjectPrefix starts the subject of tokens issued to OAuth clients,
+// which keeps it apart from user IDs.
+const ClientSubjectPrefix = "client:"
+
+// ScopeIntrospect lets a client, such as a resource server, introspect and
+// revoke the tokens of users besides its own. Unlike the other scopes it is
+// not a permission and is never granted to the client's tokens.
+const ScopeIntrospect = "introspect"
+
+// OAuthClient is another service that authenticates with a client ID and
+// secret to use the OAuth endpoints, such as token introspection. Only the
+// hash of the secret is stored. With the client credentials grant, a client
+// gets access tokens limited to its scopes, which are permission names, see
+// also ScopeIntrospect.
+type OAuthClient struct {
+	ID         string     `json:"client_id"`
+	Name       string     `json:"name"`
+	SecretHash string     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// GenerateOAuthClientSecret creates a random client secret and the hash to store.
+func GenerateOAuthClientSecret() (secret, hash string, err error) {
+	token, _, err := GenerateOneTimeToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	secret = oauthClientSecretPrefix + token
+	return secret, HashOneTimeToken(secret), nil
+}
+
+// IsActive reports whether the client has not been revoked.
+func (c *OAuthClient) IsActive() bool {
+	return c.RevokedAt == nil
+}
+
+// Subject returns the subject of the tokens issued to the client.
+func (c *OAuthClient) Subject() string {
+	return ClientSubjectPrefix + c.ID
+}
+
+// HasScope reports whether the client may request the scope.
+func (c *OAuthClient) HasScope(scope string) bool {
+	for _, s := range c.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// TokenScopes returns the scopes the client may request for its tokens.
+func (c *OAuthClient) TokenScopes() []string {
+	scopes := make([]string, 0, len(c.Scopes))
+	for _, s := range c.Scopes {
+		if s != ScopeIntrospect {
+			scopes = append(scopes, s)
+		}
+	}
+	return scopes
+}
+
+// IsValidClientScope reports whether a client can be registered with the scope.
+func IsValidClientScope(scope string) bool {
+	return scope == ScopeIntrospect || IsValidPermission(scope)
+}
@@ -1,20 +0,0 @@
-package entity
-
-import (
-	"golang.org/x/crypto/bcrypt"
-)
-
-// HashPassword creates a bcrypt hash of the password.
-func HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
-		return "", err
-	}
-	return string(bytes), nil
-}
-
-// CheckPassword checks if the provided password matches the hash.
-func CheckPassword(password, hash string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-	return err == nil
-}
@@ -0,0 +1,100 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Character classes a password policy can require.
+const (
+	PasswordClassUpper  = "upper"  // An uppercase letter
+	PasswordClassLower  = "lower"  // A lowercase letter
+	PasswordClassDigit  = "digit"  // A digit
+	PasswordClassSymbol = "symbol" // Anything that is neither a letter nor a digit
+)
+
+// minPersonalInfoLength is the shortest username or email local part that
+// passwords may not contain, so that short names don't rule out common words.
+const minPersonalInfoLength = 3
+
+// PasswordPolicy describes the passwords users may choose. Lengths are counted
+// in characters.
+type PasswordPolicy struct {
+	MinLength       int
+	MaxLength       int      // 0 for no limit
+	RequiredClasses []string // See the PasswordClass constants
+}
+
+// Validate checks that the policy itself is consistent.
+func (p PasswordPolicy) Validate() error {
+	if p.MinLength < 1 {
+		return errors.New("minimum password length must be positive")
+	}
+	if p.MaxLength != 0 && p.MaxLength < p.MinLength {
+		return fmt.Errorf("maximum password length %d is below the minimum %d", p.MaxLength, p.MinLength)
+	}
+	for _, class := range p.RequiredClasses {
+		if passwordClasses[class] == nil {
+			return fmt.Errorf("unknown password character class %q", class)
+		}
+	}
+	return nil
+}
+
+// Check returns the rules the password breaks for the given user, or nil if
+// it follows the policy. Passwords may not contain the username or email.
+func (p PasswordPolicy) Check(password, username, email string) []string {
+	var problems []string
+
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		problems = append(problems, fmt.Sprintf("must be at least %d characters long", p.MinLength))
+	}
+	if p.MaxLength != 0 && length > p.MaxLength {
+		problems = append(problems, fmt.Sprintf("must be at most %d characters long", p.MaxLength))
+	}
+
+	for _, class := range p.RequiredClasses {
+		if !strings.ContainsFunc(password, passwordClasses[class]) {
+			problems = append(problems, "must contain "+passwordClassNames[class])
+		}
+	}
+
+	if containsPersonalInfo(password, username, email) {
+		problems = append(problems, "must not contain the username or email address")
+	}
+
+	return problems
+}
+
+var passwordClasses = map[string]func(rune) bool{
+	PasswordClassUpper:  unicode.IsUpper,
+	PasswordClassLower:  unicode.IsLower,
+	PasswordClassDigit:  unicode.IsDigit,
+	PasswordClassSymbol: func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) },
+}
+
+var passwordClassNames = map[string]string{
+	PasswordClassUpper:  "an uppercase letter",
+	PasswordClassLower:  "a lowercase letter",
+	PasswordClassDigit:  "a digit",
+	PasswordClassSymbol: "a symbol",
+}
+
+// containsPersonalInfo reports whether the password contains the username,
+// the email address or its local part, ignoring case.
+func containsPersonalInfo(password, username, email string) bool {
+	password = strings.ToLower(password)
+	localPart, _, _ := strings.Cut(email, "@")
+
+	for _, info := range []string{username, email, localPart} {
+		info = strings.ToLower(strings.TrimSpace(info))
+		if utf8.RuneCountInString(info) >= minPersonalInfoLength && strings.Contains(password, info) {
+			return true
+		}
+	}
+	return false
+}
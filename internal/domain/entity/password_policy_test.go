@@ -0,0 +1,87 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPasswordPolicy_Check(t *testing.T) {
+	policy := PasswordPolicy{
+		MinLength:       8,
+		MaxLength:       16,
+		RequiredClasses: []string{PasswordClassUpper, PasswordClassDigit, PasswordClassSymbol},
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		want     []string
+	}{
+		{name: "Valid password", password: "Correct-Horse9", want: nil},
+		{
+			name:     "Too short",
+			password: "Ab1!",
+			want:     []string{"must be at least 8 characters long"},
+		},
+		{
+			name:     "Too long",
+			password: "Correct-Horse-Battery9",
+			want:     []string{"must be at most 16 characters long"},
+		},
+		{
+			name:     "Missing classes",
+			password: "correcthorse",
+			want: []string{
+				"must contain an uppercase letter",
+				"must contain a digit",
+				"must contain a symbol",
+			},
+		},
+		{
+			name:     "Length counts characters",
+			password: "Ünïcødé-1",
+			want:     nil,
+		},
+		{
+			name:     "Contains username",
+			password: "My-JohnDoe-9",
+			want:     []string{"must not contain the username or email address"},
+		},
+		{
+			name:     "Contains email local part",
+			password: "Jdoe.mail-42",
+			want:     []string{"must not contain the username or email address"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, policy.Check(tt.password, "johndoe", "jdoe.mail@example.com"))
+		})
+	}
+}
+
+func TestPasswordPolicy_Check_ShortPersonalInfo(t *testing.T) {
+	policy := PasswordPolicy{MinLength: 8}
+
+	// Names shorter than three characters would rule out too many passwords
+	assert.Nil(t, policy.Check("kabocha-squash", "ab", "ab@example.com"))
+}
+
+func TestPasswordPolicy_Validate(t *testing.T) {
+	assert.NoError(t, PasswordPolicy{MinLength: 8, MaxLength: 64, RequiredClasses: []string{PasswordClassLower}}.Validate())
+	assert.NoError(t, PasswordPolicy{MinLength: 8}.Validate())
+	assert.Error(t, PasswordPolicy{MinLength: 0}.Validate())
+	assert.Error(t, PasswordPolicy{MinLength: 8, MaxLength: 4}.Validate())
+	assert.Error(t, PasswordPolicy{MinLength: 8, RequiredClasses: []string{"emoji"}}.Validate())
+}
+
+func TestValidationError(t *testing.T) {
+	err := NewValidationError("password", "must be at least 8 characters long", "must contain a digit")
+	err.Fields["current_password"] = []string{"is incorrect"}
+
+	assert.Equal(t,
+		"validation failed: current_password is incorrect; password must be at least 8 characters long, must contain a digit",
+		err.Error())
+}
@@ -0,0 +1,45 @@
+package entity
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+)
+
+const oneTimeTokenSize = 32 // 256-bit random tokens
+
+// PasswordResetToken is a single-use token emailed to a user who forgot their
+// password. Only the hash of the token is stored.
+type PasswordResetToken struct {
+	ID        string     `json:"id"`
+	UserID    string     `json:"user_id"`
+	TokenHash string     `json:"-"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+}
+
+// IsValid reports whether the token is unused and not expired at the given time.
+func (t *PasswordResetToken) IsValid(now time.Time) bool {
+	return t.UsedAt == nil && now.Before(t.ExpiresAt)
+}
+
+// GenerateOneTimeToken creates a random URL-safe token and the hash to store for it.
+func GenerateOneTimeToken() (token, hash string, err error) {
+	raw := make([]byte, oneTimeTokenSize)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", err
+	}
+
+	token = base64.RawURLEncoding.EncodeToString(raw)
+	return token, HashOneTimeToken(token), nil
+}
+
+// HashOneTimeToken hashes a one-time token for storage and lookup. The tokens
+// are long random values, so a fast unsalted hash is sufficient.
+func HashOneTimeToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
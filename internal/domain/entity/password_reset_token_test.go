@@ -0,0 +1,54 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateOneTimeToken(t *testing.T) {
+	token, hash, err := GenerateOneTimeToken()
+	require.NoError(t, err)
+
+	assert.NotEqual(t, token, hash)
+	assert.Equal(t, hash, HashOneTimeToken(token))
+
+	other, _, err := GenerateOneTimeToken()
+	require.NoError(t, err)
+	assert.NotEqual(t, token, other)
+}
+
+func TestPasswordResetToken_IsValid(t *testing.T) {
+	now := time.Now()
+	usedAt := now.Add(-time.Minute)
+
+	tests := []struct {
+		name  string
+		token PasswordResetToken
+		want  bool
+	}{
+		{
+			name:  "Unused and not expired",
+			token: PasswordResetToken{ExpiresAt: now.Add(time.Hour)},
+			want:  true,
+		},
+		{
+			name:  "Expired",
+			token: PasswordResetToken{ExpiresAt: now.Add(-time.Second)},
+			want:  false,
+		},
+		{
+			name:  "Used",
+			token: PasswordResetToken{ExpiresAt: now.Add(time.Hour), UsedAt: &usedAt},
+			want:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.token.IsValid(now))
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package entity
+
+import "sort"
+
+// Built-in roles. Any other role is defined by the permissions it is granted,
+// see usecase.PermissionUseCase.
+const (
+	RoleAdmin = "admin" // Has every permission
+	RoleUser  = "user"  // Role of new users, no permissions by default
+)
+
+// Permissions that can be granted to roles. Users never need a permission to
+// act on their own account.
+const (
+	PermissionUsersRead        = "users:read"        // Read any user, their sessions and API keys
+	PermissionUsersWrite       = "users:write"       // Change, log out and unlock any user
+	PermissionUsersDelete      = "users:delete"      // Delete any user
+	PermissionUsersImpersonate = "users:impersonate" // Act as another user with a short-lived token
+	PermissionRolesRead        = "roles:read"        // Read role permissions and MFA role policies
+	PermissionRolesWrite       = "roles:write"       // Change role permissions and MFA role policies
+	PermissionClientsRead      = "clients:read"      // List OAuth clients
+	PermissionClientsWrite     = "clients:write"     // Register and revoke OAuth clients
+	PermissionEncryptionRead   = "encryption:read"   // List payload encryption keys
+)
+
+var permissions = []string{
+	PermissionUsersRead,
+	PermissionUsersWrite,
+	PermissionUsersDelete,
+	PermissionUsersImpersonate,
+	PermissionRolesRead,
+	PermissionRolesWrite,
+	PermissionClientsRead,
+	PermissionClientsWrite,
+	PermissionEncryptionRead,
+}
+
+// Permissions returns every known permission, sorted.
+func Permissions() []string {
+	all := append([]string(nil), permissions...)
+	sort.Strings(all)
+	return all
+}
+
+// IsValidPermission reports whether permission is a known permission.
+func IsValidPermission(permission string) bool {
+	for _, p := range permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
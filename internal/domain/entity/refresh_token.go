@@ -0,0 +1,31 @@
+package entity
+
+import "time"
+
+// RefreshToken records an issued refresh token so it can be rotated and revoked.
+// Every token issued by a login starts a new family; rotating a token keeps the
+// family so that reuse of an old member can revoke all of its descendants.
+type RefreshToken struct {
+	ID        string     `json:"id"` // matches the jti claim of the token
+	FamilyID  string     `json:"family_id"`
+	UserID    string     `json:"user_id"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// IsUsed reports whether the token has already been exchanged for a new pair.
+func (t *RefreshToken) IsUsed() bool {
+	return t.UsedAt != nil
+}
+
+// IsRevoked reports whether the token has been revoked.
+func (t *RefreshToken) IsRevoked() bool {
+	return t.RevokedAt != nil
+}
+
+// IsExpired reports whether the token is expired at the given time.
+func (t *RefreshToken) IsExpired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
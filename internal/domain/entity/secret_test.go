@@ -0,0 +1,44 @@
+package entity
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptSecret(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, 32)
+
+	t.Run("Round trip", func(t *testing.T) {
+		encrypted, err := EncryptSecret(key, "JBSWY3DPEHPK3PXP")
+		require.NoError(t, err)
+		assert.NotContains(t, encrypted, "JBSWY3DPEHPK3PXP")
+
+		decrypted, err := DecryptSecret(key, encrypted)
+		require.NoError(t, err)
+		assert.Equal(t, "JBSWY3DPEHPK3PXP", decrypted)
+	})
+
+	t.Run("Uses a fresh nonce every time", func(t *testing.T) {
+		first, err := EncryptSecret(key, "secret")
+		require.NoError(t, err)
+		second, err := EncryptSecret(key, "secret")
+		require.NoError(t, err)
+		assert.NotEqual(t, first, second)
+	})
+
+	t.Run("Fails with wrong key", func(t *testing.T) {
+		encrypted, err := EncryptSecret(key, "secret")
+		require.NoError(t, err)
+
+		_, err = DecryptSecret(bytes.Repeat([]byte{2}, 32), encrypted)
+		assert.Error(t, err)
+	})
+
+	t.Run("Fails with truncated data", func(t *testing.T) {
+		_, err := DecryptSecret(key, "AAAA")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,26 @@
+package entity
+
+import "time"
+
+// Session is a login on one device. It shares its ID with the refresh token
+// family the login started, so that revoking the session revokes the family.
+type Session struct {
+	ID              string     `json:"id"`
+	UserID          string     `json:"user_id"`
+	UserAgent       string     `json:"user_agent"`
+	IP              string     `json:"ip"`
+	CreatedAt       time.Time  `json:"created_at"`
+	LastRefreshedAt time.Time  `json:"last_refreshed_at"`
+	ExpiresAt       time.Time  `json:"expires_at"` // Expiry of the latest refresh token
+	RevokedAt       *time.Time `json:"revoked_at,omitempty"`
+}
+
+// IsRevoked reports whether the session has been logged out.
+func (s *Session) IsRevoked() bool {
+	return s.RevokedAt != nil
+}
+
+// IsActive reports whether the session can still be refreshed at the given time.
+func (s *Session) IsActive(now time.Time) bool {
+	return !s.IsRevoked() && now.Before(s.ExpiresAt)
+}
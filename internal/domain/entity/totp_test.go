@@ -0,0 +1,94 @@
+package entity
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rfcSecret is the SHA-1 seed of the RFC 6238 test vectors.
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestTOTPCode(t *testing.T) {
+	// RFC 6238 appendix B, truncated to six digits
+	tests := []struct {
+		name string
+		unix int64
+		want string
+	}{
+		{name: "1970", unix: 59, want: "287082"},
+		{name: "2005", unix: 1111111109, want: "081804"},
+		{name: "2009", unix: 1234567890, want: "005924"},
+		{name: "2033", unix: 2000000000, want: "279037"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := TOTPCode(rfcSecret, TOTPStep(time.Unix(tt.unix, 0)))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, code)
+		})
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	require.NoError(t, err)
+
+	now := time.Now()
+	current := TOTPStep(now)
+	code, err := TOTPCode(secret, current)
+	require.NoError(t, err)
+
+	t.Run("Accepts current code", func(t *testing.T) {
+		step, ok := ValidateTOTP(secret, code, now, 0)
+		assert.True(t, ok)
+		assert.Equal(t, current, step)
+	})
+
+	t.Run("Accepts previous step", func(t *testing.T) {
+		previous, err := TOTPCode(secret, current-1)
+		require.NoError(t, err)
+		_, ok := ValidateTOTP(secret, previous, now, 0)
+		assert.True(t, ok)
+	})
+
+	t.Run("Rejects old code", func(t *testing.T) {
+		old, err := TOTPCode(secret, current-5)
+		require.NoError(t, err)
+		_, ok := ValidateTOTP(secret, old, now, 0)
+		assert.False(t, ok)
+	})
+
+	t.Run("Rejects replayed code", func(t *testing.T) {
+		_, ok := ValidateTOTP(secret, code, now, current)
+		assert.False(t, ok)
+	})
+}
+
+func TestTOTPProvisioningURI(t *testing.T) {
+	uri := TOTPProvisioningURI("SECRET", "Web Server", "user@example.com")
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/Web%20Server:user@example.com?"))
+	assert.Contains(t, uri, "secret=SECRET")
+	assert.Contains(t, uri, "issuer=Web+Server")
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, hashes, err := GenerateRecoveryCodes()
+	require.NoError(t, err)
+	assert.Len(t, codes, RecoveryCodesCount)
+	assert.Len(t, hashes, RecoveryCodesCount)
+	assert.NotContains(t, hashes, codes[0])
+
+	settings := MFASettings{RecoveryCodes: hashes}
+
+	// Codes are accepted regardless of case and separators, but only once
+	assert.True(t, settings.UseRecoveryCode(strings.ToUpper(strings.ReplaceAll(codes[3], "-", ""))))
+	assert.False(t, settings.UseRecoveryCode(codes[3]))
+	assert.Len(t, settings.RecoveryCodes, RecoveryCodesCount-1)
+	assert.False(t, settings.UseRecoveryCode("not-a-code"))
+}
@@ -4,8 +4,13 @@ type User struct {
 	ID       string `json:"id"`
 	Username string `json:"username" binding:"required,min=3,max=50"`
 	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password,omitempty" binding:"required,min=6"`
-	Role     string `json:"role" binding:"required,oneof=user admin"`
+	Password string `json:"password,omitempty" binding:"required"`
+	Role     string `json:"role"` // Only changed by admins, see usecase.UserAdminUseCase
+
+	EmailVerified         bool        `json:"email_verified"`
+	Disabled              bool        `json:"disabled"`                // Disabled users cannot log in
+	PasswordResetRequired bool        `json:"password_reset_required"` // Set when an admin forces a password reset
+	MFA                   MFASettings `json:"-"`
 }
 
 // NewUser creates a new user with default role.
@@ -15,6 +20,6 @@ func NewUser(username, email, password string) *User {
 		Username: username,
 		Email:    email,
 		Password: password,
-		Role:     "user", // Default role
+		Role:     RoleUser, // Default role
 	}
 }
@@ -0,0 +1,15 @@
+package entity
+
+import "time"
+
+// UserIdentity links a user to their account at an external OpenID Connect
+// provider. The issuer and subject identify the external account for good,
+// while the email is only what the provider reported when it was linked.
+type UserIdentity struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	Issuer    string    `json:"issuer"`
+	Subject   string    `json:"subject"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
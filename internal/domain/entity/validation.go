@@ -0,0 +1,32 @@
+package entity
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ValidationError lists the problems found with the fields of a request, such
+// as a password that does not follow the password policy.
+type ValidationError struct {
+	Fields map[string][]string // Problems by JSON field name
+}
+
+// NewValidationError returns a validation error with problems for one field.
+func NewValidationError(field string, problems ...string) *ValidationError {
+	return &ValidationError{Fields: map[string][]string{field: problems}}
+}
+
+func (e *ValidationError) Error() string {
+	fields := make([]string, 0, len(e.Fields))
+	for field := range e.Fields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	parts := make([]string, 0, len(fields))
+	for _, field := range fields {
+		parts = append(parts, fmt.Sprintf("%s %s", field, strings.Join(e.Fields[field], ", ")))
+	}
+	return "validation failed: " + strings.Join(parts, "; ")
+}
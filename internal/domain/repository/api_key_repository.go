@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"time"
+
+	"web-server/internal/domain/entity"
+)
+
+type APIKeyRepository interface {
+	Create(key *entity.APIKey) error
+	GetByID(id string) (*entity.APIKey, error)
+	GetByHash(keyHash string) (*entity.APIKey, error)
+	ListByUser(userID string) ([]*entity.APIKey, error)
+	Revoke(id string, revokedAt time.Time) error
+	TouchLastUsed(id string, usedAt time.Time) error
+}
@@ -0,0 +1,10 @@
+package repository
+
+import "web-server/internal/domain/entity"
+
+// AuditRepository stores the audit log of administrative actions.
+type AuditRepository interface {
+	Create(entry *entity.AuditEntry) error
+	// ListByUser returns the entries about the user, oldest first.
+	ListByUser(userID string) ([]*entity.AuditEntry, error)
+}
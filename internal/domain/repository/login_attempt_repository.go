@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"time"
+
+	"web-server/internal/domain/entity"
+)
+
+// LoginAttemptRepository counts failed logins per key.
+type LoginAttemptRepository interface {
+	// Get returns constants.ErrLoginAttemptNotFound if the key has no failures.
+	Get(key string) (*entity.LoginAttempt, error)
+	// SwapFailures sets the failures of the key and the time of the last one,
+	// provided the key still has the old number of failures, which is 0 for a
+	// key without any. Otherwise it returns constants.ErrLoginAttemptChanged,
+	// so that concurrent attempts are never counted from the same state.
+	SwapFailures(key string, old, failures int, at time.Time) error
+	// ForgetFailure takes back one failure of the key, if it has any.
+	ForgetFailure(key string) error
+	Reset(key string) error
+}
@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"time"
+
+	"web-server/internal/domain/entity"
+)
+
+type LoginLinkRepository interface {
+	Create(link *entity.LoginLink) error
+	GetByID(id string) (*entity.LoginLink, error)
+	// MarkUsed atomically marks an unused link as used. It returns
+	// constants.ErrInvalidLoginLink if the link was already used.
+	MarkUsed(id string, usedAt time.Time) error
+	DeleteByUser(userID string) error
+}
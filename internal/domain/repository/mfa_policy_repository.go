@@ -0,0 +1,7 @@
+package repository
+
+// MFAPolicyRepository stores the roles whose users must use a second factor.
+type MFAPolicyRepository interface {
+	ListRequiredRoles() ([]string, error)
+	SetRoleRequired(role string, required bool) error
+}
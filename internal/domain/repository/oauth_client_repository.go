@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"time"
+
+	"web-server/internal/domain/entity"
+)
+
+type OAuthClientRepository interface {
+	Create(client *entity.OAuthClient) error
+	GetByID(id string) (*entity.OAuthClient, error)
+	List() ([]*entity.OAuthClient, error)
+	Revoke(id string, revokedAt time.Time) error
+}
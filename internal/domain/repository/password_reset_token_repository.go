@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"time"
+
+	"web-server/internal/domain/entity"
+)
+
+type PasswordResetTokenRepository interface {
+	Create(token *entity.PasswordResetToken) error
+	GetByHash(tokenHash string) (*entity.PasswordResetToken, error)
+	// MarkUsed atomically marks an unused token as used. It returns
+	// constants.ErrInvalidResetToken if the token was already used.
+	MarkUsed(id string, usedAt time.Time) error
+	DeleteByUser(userID string) error
+}
@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"time"
+
+	"web-server/internal/domain/entity"
+)
+
+type RefreshTokenRepository interface {
+	Create(token *entity.RefreshToken) error
+	GetByID(id string) (*entity.RefreshToken, error)
+	// MarkUsed atomically marks an unused token as used. It returns
+	// constants.ErrRefreshTokenReused if the token was already used.
+	MarkUsed(id string, usedAt time.Time) error
+	RevokeFamily(familyID string, revokedAt time.Time) error
+	RevokeByUser(userID string, revokedAt time.Time) error
+}
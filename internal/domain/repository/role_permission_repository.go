@@ -0,0 +1,10 @@
+package repository
+
+// RolePermissionRepository stores the permissions granted to roles. A stored
+// role replaces the permissions configured for it.
+type RolePermissionRepository interface {
+	ListRoles() (map[string][]string, error)
+	SetPermissions(role string, permissions []string) error
+	// DeleteRole removes the stored permissions of a role. Unknown roles are ignored.
+	DeleteRole(role string) error
+}
@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"time"
+
+	"web-server/internal/domain/entity"
+)
+
+type SessionRepository interface {
+	Create(session *entity.Session) error
+	GetByID(id string) (*entity.Session, error)
+	// ListByUser returns the sessions of the user, most recent first.
+	ListByUser(userID string) ([]*entity.Session, error)
+	// Touch records a refresh of the session. Unknown sessions are ignored,
+	// since refresh token families from before sessions were tracked have none.
+	Touch(id string, refreshedAt, expiresAt time.Time) error
+	// Revoke revokes an active session. Unknown sessions are ignored.
+	Revoke(id string, revokedAt time.Time) error
+	RevokeByUser(userID string, revokedAt time.Time) error
+}
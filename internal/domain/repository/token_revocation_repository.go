@@ -0,0 +1,19 @@
+package repository
+
+import "time"
+
+// TokenRevocationRepository tracks access tokens that must be rejected before
+// they expire, either individually by jti or for a whole user by cutoff time.
+type TokenRevocationRepository interface {
+	// RevokeToken revokes a single token until it would have expired anyway.
+	RevokeToken(jti string, expiresAt time.Time) error
+	// RevokeTokenOnce revokes a token like RevokeToken and reports whether it
+	// was not revoked before. Of concurrent calls for the same token only one
+	// reports true, so single-use tokens cannot be redeemed twice.
+	RevokeTokenOnce(jti string, expiresAt time.Time) (bool, error)
+	IsTokenRevoked(jti string) (bool, error)
+	// RevokeAllForUser rejects every token of the user issued before validAfter.
+	RevokeAllForUser(userID string, validAfter time.Time) error
+	// GetTokensValidAfter returns the zero time if the user has no cutoff.
+	GetTokensValidAfter(userID string) (time.Time, error)
+}
@@ -0,0 +1,11 @@
+package repository
+
+import "web-server/internal/domain/entity"
+
+type UserIdentityRepository interface {
+	Create(identity *entity.UserIdentity) error
+	// GetBySubject returns the identity of the account with the given subject
+	// at the issuer, or constants.ErrUserIdentityNotFound.
+	GetBySubject(issuer, subject string) (*entity.UserIdentity, error)
+	Delete(id string) error
+}
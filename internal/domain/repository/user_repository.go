@@ -6,7 +6,13 @@ type UserRepository interface {
 	Create(user *entity.User) error
 	GetByID(id string) (*entity.User, error)
 	GetByEmail(email string) (*entity.User, error)
+	// Update stores every field of the user except the MFA settings, which
+	// only change through SwapMFA.
 	Update(user *entity.User) error
+	// SwapMFA replaces the MFA settings of the user, provided they still equal
+	// old. Otherwise it returns constants.ErrMFAChanged, so that a code or a
+	// recovery code cannot be used by two concurrent requests.
+	SwapMFA(userID string, old, mfa entity.MFASettings) error
 	Delete(id string) error
 	List() ([]*entity.User, error)
 }
@@ -0,0 +1,7 @@
+package service
+
+// BreachedPasswordChecker looks passwords up in a list of passwords exposed in
+// data breaches.
+type BreachedPasswordChecker interface {
+	IsBreached(password string) (bool, error)
+}
@@ -0,0 +1,8 @@
+package service
+
+// EmailVerificationTokens issues and checks the signed tokens in email
+// verification links. A token is bound to the address it was sent to.
+type EmailVerificationTokens interface {
+	GenerateEmailVerificationToken(userID, email string) (string, error)
+	ValidateEmailVerificationToken(token string) (userID, email string, err error)
+}
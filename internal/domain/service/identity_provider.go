@@ -0,0 +1,40 @@
+package service
+
+// ExternalIdentity is what an OpenID Connect provider asserts about the user
+// who signed in there, taken from a verified ID token.
+type ExternalIdentity struct {
+	Issuer            string
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	PreferredUsername string
+}
+
+// IdentityProvider runs the authorization code flow with PKCE against an
+// external OpenID Connect provider.
+type IdentityProvider interface {
+	// AuthCodeURL returns the URL of the provider's login page. The state and
+	// nonce come back in the callback and the ID token, the code challenge is
+	// the S256 hash of the code verifier.
+	AuthCodeURL(state, nonce, codeChallenge string) (string, error)
+	// Exchange redeems the authorization code with the code verifier and
+	// returns the identity from the ID token, after checking its signature,
+	// issuer, audience, expiry and nonce.
+	Exchange(code, codeVerifier, nonce string) (*ExternalIdentity, error)
+}
+
+// OIDCLoginState is what a login started at the provider needs to be completed
+// by the same browser: the state sent to the provider, the nonce expected in
+// the ID token and the PKCE code verifier.
+type OIDCLoginState struct {
+	State        string
+	Nonce        string
+	CodeVerifier string
+}
+
+// OIDCStateTokens seals the login state into a signed token kept by the
+// browser, so the server keeps nothing between redirect and callback.
+type OIDCStateTokens interface {
+	GenerateOIDCStateToken(state OIDCLoginState) (string, error)
+	ValidateOIDCStateToken(token string) (*OIDCLoginState, error)
+}
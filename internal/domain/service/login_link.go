@@ -0,0 +1,10 @@
+package service
+
+import "time"
+
+// LoginLinkTokens issues and checks the signed tokens in passwordless login
+// links. A token names the stored login link it was issued for.
+type LoginLinkTokens interface {
+	GenerateLoginLinkToken(userID, linkID string, expiresAt time.Time) (string, error)
+	ValidateLoginLinkToken(token string) (userID, linkID string, err error)
+}
@@ -0,0 +1,12 @@
+package service
+
+// PasswordHasher hashes passwords for storage and verifies them at login.
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	// Verify reports whether the password matches the hash. Hashes made with
+	// any supported algorithm or parameters are accepted.
+	Verify(password, hash string) bool
+	// NeedsRehash reports whether the hash was made with another algorithm or
+	// other parameters than new hashes, and should be replaced at the next login.
+	NeedsRehash(hash string) bool
+}
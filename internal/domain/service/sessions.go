@@ -0,0 +1,7 @@
+package service
+
+// SessionRevoker ends every session of a user, so that tokens issued before a
+// change to their account stop working.
+type SessionRevoker interface {
+	LogoutAll(userID string) error
+}
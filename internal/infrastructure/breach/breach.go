@@ -0,0 +1,65 @@
+package breach
+
+import (
+	"crypto/sha1" //nolint:gosec // SHA-1 is the format breach lists are published in
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"web-server/internal/domain/service"
+)
+
+// prefixLength is the length of the hash prefixes ranges are grouped by, as in
+// the k-anonymity range API of Have I Been Pwned.
+const prefixLength = 5
+
+// New returns a checker for the breached password list at path: a directory
+// of range files or a single file of full hashes. An empty path disables the
+// check.
+func New(path string) (service.BreachedPasswordChecker, error) {
+	if path == "" {
+		return disabled{}, nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("breached password list: %w", err)
+	}
+	if info.IsDir() {
+		return NewRangeDirectory(path), nil
+	}
+	return LoadHashFile(path)
+}
+
+// disabled accepts every password.
+type disabled struct{}
+
+func (disabled) IsBreached(string) (bool, error) {
+	return false, nil
+}
+
+// hashPassword returns the uppercase hex SHA-1 hash of the password.
+func hashPassword(password string) string {
+	sum := sha1.Sum([]byte(password)) //nolint:gosec // See import
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// parseLine parses a "HASH:COUNT" line, where the count is optional. Lines
+// with a count of zero are padding and are reported as not listed.
+func parseLine(line string) (hash string, listed bool, err error) {
+	hash, count, hasCount := strings.Cut(strings.TrimSpace(line), ":")
+	if hash == "" || strings.Trim(hash, "0123456789abcdefABCDEF") != "" {
+		return "", false, fmt.Errorf("invalid hash %q", hash)
+	}
+	if !hasCount {
+		return strings.ToUpper(hash), true, nil
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(count))
+	if err != nil {
+		return "", false, fmt.Errorf("invalid count %q", count)
+	}
+	return strings.ToUpper(hash), n > 0, nil
+}
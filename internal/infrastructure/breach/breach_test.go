@@ -0,0 +1,105 @@
+package breach
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// SHA-1 of "password" is 5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8.
+const (
+	passwordPrefix = "5BAA6"
+	passwordSuffix = "1E4C9B93F3F0682250B6CF8331B7EE68FD8"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+}
+
+func TestRangeDirectory(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, passwordPrefix),
+		"003D68EB55068C33ACE09247EE4C639306B:3\r\n"+passwordSuffix+":9545824\r\n")
+
+	checker, err := New(dir)
+	require.NoError(t, err)
+
+	breached, err := checker.IsBreached("password")
+	require.NoError(t, err)
+	assert.True(t, breached)
+
+	// No range file for the prefix
+	breached, err = checker.IsBreached("correct horse battery staple")
+	require.NoError(t, err)
+	assert.False(t, breached)
+}
+
+func TestRangeDirectory_PaddingAndExtension(t *testing.T) {
+	dir := t.TempDir()
+	// Padding entries have a count of zero
+	writeFile(t, filepath.Join(dir, passwordPrefix+".txt"), passwordSuffix+":0\n")
+
+	breached, err := NewRangeDirectory(dir).IsBreached("password")
+	require.NoError(t, err)
+	assert.False(t, breached)
+
+	writeFile(t, filepath.Join(dir, passwordPrefix+".txt"), passwordSuffix+":1\n")
+	breached, err = NewRangeDirectory(dir).IsBreached("password")
+	require.NoError(t, err)
+	assert.True(t, breached)
+}
+
+func TestRangeDirectory_InvalidLine(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, passwordPrefix), "not a hash\n")
+
+	_, err := NewRangeDirectory(dir).IsBreached("password")
+	assert.Error(t, err)
+}
+
+func TestHashFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "top-passwords.txt")
+	writeFile(t, path,
+		passwordPrefix+passwordSuffix+":9545824\n"+
+			"7C4A8D09CA3762AF61E59520943DC26494F8941B\n") // 123456
+
+	checker, err := New(path)
+	require.NoError(t, err)
+
+	for _, password := range []string{"password", "123456"} {
+		breached, err := checker.IsBreached(password)
+		require.NoError(t, err)
+		assert.True(t, breached, password)
+	}
+
+	breached, err := checker.IsBreached("correct horse battery staple")
+	require.NoError(t, err)
+	assert.False(t, breached)
+}
+
+func TestLoadHashFile_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hashes.txt")
+
+	writeFile(t, path, passwordSuffix+":1\n") // Suffix without prefix
+	_, err := LoadHashFile(path)
+	assert.Error(t, err)
+
+	writeFile(t, path, passwordPrefix+passwordSuffix+":many\n")
+	_, err = LoadHashFile(path)
+	assert.Error(t, err)
+}
+
+func TestNew(t *testing.T) {
+	checker, err := New("")
+	require.NoError(t, err)
+	breached, err := checker.IsBreached("password")
+	require.NoError(t, err)
+	assert.False(t, breached)
+
+	_, err = New(filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,53 @@
+package breach
+
+import (
+	"bufio"
+	"crypto/sha1" //nolint:gosec // See breach.go
+	"fmt"
+	"os"
+)
+
+// HashFile checks passwords against a file of full SHA-1 hashes, one
+// "HASH:COUNT" or "HASH" line per password, which is loaded into memory. It
+// suits lists of the most common passwords; use a RangeDirectory for complete
+// breach corpora.
+type HashFile struct {
+	hashes map[string]struct{}
+}
+
+// LoadHashFile reads the hashes in the file at path.
+func LoadHashFile(path string) (*HashFile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	hashes := make(map[string]struct{})
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		if scanner.Text() == "" {
+			continue
+		}
+		hash, listed, err := parseLine(scanner.Text())
+		if err == nil && len(hash) != 2*sha1.Size {
+			err = fmt.Errorf("invalid SHA-1 hash %q", hash)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", path, line, err)
+		}
+		if listed {
+			hashes[hash] = struct{}{}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return &HashFile{hashes: hashes}, nil
+}
+
+func (f *HashFile) IsBreached(password string) (bool, error) {
+	_, found := f.hashes[hashPassword(password)]
+	return found, nil
+}
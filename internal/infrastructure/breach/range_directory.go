@@ -0,0 +1,62 @@
+package breach
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// RangeDirectory checks passwords against a directory with one file per SHA-1
+// prefix, in the format of the Have I Been Pwned range API: a file named after
+// the first five hex characters of the hashes (optionally with a .txt
+// extension) holds "SUFFIX:COUNT" lines with the rest of each hash. Only the
+// file of the password's prefix is read for a check.
+type RangeDirectory struct {
+	dir string
+}
+
+func NewRangeDirectory(dir string) *RangeDirectory {
+	return &RangeDirectory{dir: dir}
+}
+
+func (d *RangeDirectory) IsBreached(password string) (bool, error) {
+	hash := hashPassword(password)
+	prefix, suffix := hash[:prefixLength], hash[prefixLength:]
+
+	file, err := d.open(prefix)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		if scanner.Text() == "" {
+			continue
+		}
+		listedSuffix, listed, err := parseLine(scanner.Text())
+		if err != nil {
+			return false, fmt.Errorf("%s line %d: %w", file.Name(), line, err)
+		}
+		if listed && listedSuffix == suffix {
+			return true, nil
+		}
+	}
+
+	return false, scanner.Err()
+}
+
+// open opens the range file of the prefix, with or without extension.
+func (d *RangeDirectory) open(prefix string) (*os.File, error) {
+	file, err := os.Open(filepath.Join(d.dir, prefix))
+	if errors.Is(err, fs.ErrNotExist) {
+		return os.Open(filepath.Join(d.dir, prefix+".txt"))
+	}
+	return file, err
+}
@@ -3,6 +3,8 @@ package config
 import (
 	"crypto/rand"
 	"os"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -10,20 +12,183 @@ import (
 )
 
 const (
-	jwtKeySize           = 32 // Size for JWT secret keys (256 bits)
-	encryptionKeySize    = 32 // Size for AES-256 encryption key
-	encryptionNonceSize  = 12 // Size for AES-GCM nonce
-	accessTokenDuration  = 15 * time.Minute
-	refreshTokenDuration = 7 * 24 * time.Hour
+	jwtKeySize                = 32 // Size for JWT secret keys (256 bits)
+	encryptionKeySize         = 32 // Size for AES-256 encryption key
+	encryptionNonceSize       = 12 // Size for AES-GCM nonce
+	accessTokenDuration       = 15 * time.Minute
+	refreshTokenDuration      = 7 * 24 * time.Hour
+	mfaTokenDuration          = 5 * time.Minute
+	impersonationDuration     = 15 * time.Minute
+	defaultMFAIssuer          = "Web Server"
+	passwordResetDuration     = time.Hour
+	emailVerificationDuration = 24 * time.Hour
+	magicLinkDuration         = 15 * time.Minute
+	defaultEmailVerification  = "optional"
+	loginFreeAttempts         = 3
+	loginBaseDelay            = time.Second
+	defaultLoginMaxFailures   = 5
+	defaultLoginMaxFailuresIP = 20
+	defaultLoginLockout       = 15 * time.Minute
+	defaultMailer             = "log"
+	defaultMailFrom           = "no-reply@localhost"
+	defaultMailDir            = "mail"
+	defaultSMTPPort           = "587"
+	defaultAppURL             = "http://localhost:8080"
+	defaultPasswordHash       = "argon2id"
+	defaultBcryptCost         = 12
+	defaultArgon2Memory       = 19 * 1024 // KiB, the OWASP recommendation for argon2id
+	defaultArgon2Iterations   = 2
+	defaultArgon2Parallelism  = 1
+	defaultPasswordMinLength  = 8
+	defaultPasswordMaxLength  = 64
+	defaultCookieSameSite     = "strict"
+	defaultOIDCScopes         = "openid,email,profile"
+	encryptionSessionDuration = time.Hour
+	defaultEncryptionSessions = 10000
 )
 
 type Config struct {
-	JWTSecret            []byte
-	JWTExpiration        time.Duration
-	JWTRefreshSecret     []byte
-	JWTRefreshExpiration time.Duration
-	EncryptionKey        []byte
-	EncryptionNonce      []byte
+	JWTSecret                   []byte
+	JWTExpiration               time.Duration
+	JWTRefreshSecret            []byte
+	JWTRefreshExpiration        time.Duration
+	JWTSigningKeys              []KeyFile // Asymmetric access token keys, the first one signs
+	JWTVerifyLegacySecret       bool      // Accept HS256 access tokens signed with JWTSecret for one access token lifetime after loading JWTSigningKeys
+	EncryptionKey               []byte
+	EncryptionNonce             []byte              // Only used by the legacy encryption format
+	EncryptionLegacy            bool                // Still accept and send the legacy encryption format
+	EncryptionKeys              []KeyFile           // Additional payload encryption keys, read from files
+	EncryptionPrimaryKey        string              // ID of the key new payloads are encrypted with
+	MFASecretKey                []byte              // AES-256 key TOTP secrets are encrypted with at rest
+	MFAIssuer                   string              // Issuer shown in authenticator apps
+	MFATokenExpiration          time.Duration       // Lifetime of the login challenge token
+	ImpersonationExpiration     time.Duration       // Lifetime of the access tokens admins act as users with
+	MFARequiredRoles            []string            // Roles that always require a second factor
+	RolePermissions             map[string][]string // Permissions of roles other than admin, see usecase.PermissionUseCase
+	AppURL                      string              // Base URL of the frontend, used for links in emails
+	PasswordResetExpiration     time.Duration
+	EmailVerification           string // optional, restricted or required, see usecase.EmailVerificationUseCase
+	EmailVerificationExpiration time.Duration
+	MagicLinkExpiration         time.Duration // Lifetime of passwordless login links
+	TrustedProxies              []string      // Proxies whose X-Forwarded-For header gives the client IP, none by default
+	Mail                        MailConfig
+	LoginThrottle               LoginThrottleConfig
+	PasswordHash                PasswordHashConfig
+	PasswordPolicy              PasswordPolicyConfig
+	Cookie                      CookieConfig
+	OIDC                        OIDCConfig
+	EncryptionSession           EncryptionSessionConfig
+}
+
+// EncryptionSessionConfig configures the per-client payload encryption keys
+// clients agree on with the key exchange handshake.
+type EncryptionSessionConfig struct {
+	ExchangeKey string        // PEM file of the X25519 handshake key, generated at startup if empty
+	TTL         time.Duration // Lifetime of a session key
+	MaxSessions int           // Session keys kept at once, the oldest are dropped first
+	Required    bool          // Reject encrypted requests without a session key
+}
+
+// OIDCConfig configures login through an external OpenID Connect provider.
+// An empty issuer disables it.
+type OIDCConfig struct {
+	Issuer       string // Discovery is fetched from Issuer + /.well-known/openid-configuration
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string // Callback registered at the provider, see handler.OIDCHandler
+	Scopes       []string
+}
+
+// CookieConfig configures the session cookies of clients that log in with the
+// cookie session mode.
+type CookieConfig struct {
+	Domain   string // Empty for the host of the request only
+	Secure   bool   // Only send the cookies over HTTPS
+	SameSite string // strict, lax or none
+}
+
+// PasswordPolicyConfig configures the passwords users may choose.
+type PasswordPolicyConfig struct {
+	MinLength             int
+	MaxLength             int      // 0 for no limit
+	RequiredClasses       []string // upper, lower, digit and symbol
+	BreachedPasswordsPath string   // Directory of SHA-1 range files or file of SHA-1 hashes, empty to skip the check
+}
+
+// PasswordHashConfig selects the algorithm and parameters new password hashes
+// are made with. Hashes with other parameters are upgraded at the next login.
+type PasswordHashConfig struct {
+	Algorithm         string // argon2id or bcrypt
+	BcryptCost        int
+	Argon2Memory      int // KiB
+	Argon2Iterations  int
+	Argon2Parallelism int
+}
+
+// LoginThrottleConfig configures the backoff and lockout after failed logins.
+type LoginThrottleConfig struct {
+	FreeAttempts     int           // Failures before logins are delayed
+	BaseDelay        time.Duration // Delay after the first delayed failure, doubled for every further one
+	MaxFailures      int           // Failures that lock an account
+	MaxFailuresPerIP int           // Failures that lock a client IP
+	LockoutDuration  time.Duration
+}
+
+// MailConfig selects and configures the mailer outgoing emails are sent with.
+type MailConfig struct {
+	Driver       string // log, file, memory or smtp
+	From         string
+	Dir          string // Directory the file driver writes messages to
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+}
+
+// KeyFile points to a key file and the key ID the key is known by.
+type KeyFile struct {
+	ID   string
+	Path string
+}
+
+// parseList parses a comma separated list, skipping empty entries.
+func parseList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseRolePermissions parses semicolon separated role=permissions entries,
+// with the permissions of a role separated by spaces, e.g.
+// "support=users:read users:write;auditor=users:read".
+func parseRolePermissions(value string) map[string][]string {
+	roles := make(map[string][]string)
+	for _, entry := range strings.Split(value, ";") {
+		role, permissions, found := strings.Cut(entry, "=")
+		role = strings.TrimSpace(role)
+		if !found || role == "" {
+			continue
+		}
+		roles[role] = append(roles[role], strings.Fields(permissions)...)
+	}
+	return roles
+}
+
+// parseKeyFiles parses a comma separated list of id=path pairs.
+func parseKeyFiles(value string) []KeyFile {
+	var files []KeyFile
+	for _, entry := range parseList(value) {
+		id, path, found := strings.Cut(entry, "=")
+		if !found || id == "" || path == "" {
+			continue
+		}
+		files = append(files, KeyFile{ID: id, Path: path})
+	}
+	return files
 }
 
 func generateRandomBytes(n int) ([]byte, error) {
@@ -70,24 +235,174 @@ func initConfig() {
 			panic(err)
 		}
 
+		mfaKey, err := generateRandomBytes(encryptionKeySize)
+		if err != nil {
+			panic(err)
+		}
+
 		configInstance = &Config{
-			JWTSecret:            jwtSecret,
-			JWTExpiration:        accessTokenDuration,
-			JWTRefreshSecret:     refreshSecret,
-			JWTRefreshExpiration: refreshTokenDuration,
-			EncryptionKey:        encKey,
-			EncryptionNonce:      nonce,
+			JWTSecret:                   jwtSecret,
+			JWTExpiration:               accessTokenDuration,
+			JWTRefreshSecret:            refreshSecret,
+			JWTRefreshExpiration:        refreshTokenDuration,
+			EncryptionKey:               encKey,
+			EncryptionNonce:             nonce,
+			MFASecretKey:                mfaKey,
+			MFAIssuer:                   defaultMFAIssuer,
+			MFATokenExpiration:          mfaTokenDuration,
+			ImpersonationExpiration:     impersonationDuration,
+			AppURL:                      defaultAppURL,
+			PasswordResetExpiration:     passwordResetDuration,
+			EmailVerification:           defaultEmailVerification,
+			EmailVerificationExpiration: emailVerificationDuration,
+			MagicLinkExpiration:         magicLinkDuration,
+			Mail: MailConfig{
+				Driver: defaultMailer,
+				From:   defaultMailFrom,
+				Dir:    defaultMailDir,
+			},
+			LoginThrottle: LoginThrottleConfig{
+				FreeAttempts:     loginFreeAttempts,
+				BaseDelay:        loginBaseDelay,
+				MaxFailures:      defaultLoginMaxFailures,
+				MaxFailuresPerIP: defaultLoginMaxFailuresIP,
+				LockoutDuration:  defaultLoginLockout,
+			},
+			PasswordHash: PasswordHashConfig{
+				Algorithm:         defaultPasswordHash,
+				BcryptCost:        defaultBcryptCost,
+				Argon2Memory:      defaultArgon2Memory,
+				Argon2Iterations:  defaultArgon2Iterations,
+				Argon2Parallelism: defaultArgon2Parallelism,
+			},
+			PasswordPolicy: PasswordPolicyConfig{
+				MinLength: defaultPasswordMinLength,
+				MaxLength: defaultPasswordMaxLength,
+			},
+			Cookie: CookieConfig{
+				Secure:   true,
+				SameSite: defaultCookieSameSite,
+			},
+			OIDC: OIDCConfig{
+				Scopes: parseList(defaultOIDCScopes),
+			},
+			EncryptionSession: EncryptionSessionConfig{
+				TTL:         encryptionSessionDuration,
+				MaxSessions: defaultEncryptionSessions,
+			},
 		}
 		return
 	}
 
 	// If .env file exists, use values from it
 	configInstance = &Config{
-		JWTSecret:            []byte(os.Getenv("JWT_SECRET")),
-		JWTExpiration:        accessTokenDuration,
-		JWTRefreshSecret:     []byte(os.Getenv("JWT_REFRESH_SECRET")),
-		JWTRefreshExpiration: refreshTokenDuration,
-		EncryptionKey:        []byte(os.Getenv("ENCRYPTION_KEY")),
-		EncryptionNonce:      []byte(os.Getenv("ENCRYPTION_NONCE")),
+		JWTSecret:                   []byte(os.Getenv("JWT_SECRET")),
+		JWTExpiration:               accessTokenDuration,
+		JWTRefreshSecret:            []byte(os.Getenv("JWT_REFRESH_SECRET")),
+		JWTRefreshExpiration:        refreshTokenDuration,
+		JWTSigningKeys:              parseKeyFiles(os.Getenv("JWT_SIGNING_KEYS")),
+		JWTVerifyLegacySecret:       getEnvBool("JWT_VERIFY_LEGACY_SECRET", false),
+		EncryptionKey:               []byte(os.Getenv("ENCRYPTION_KEY")),
+		EncryptionNonce:             []byte(os.Getenv("ENCRYPTION_NONCE")),
+		EncryptionLegacy:            getEnvBool("ENCRYPTION_LEGACY", false),
+		EncryptionKeys:              parseKeyFiles(os.Getenv("ENCRYPTION_KEYS")),
+		EncryptionPrimaryKey:        os.Getenv("ENCRYPTION_PRIMARY_KEY"),
+		MFASecretKey:                []byte(os.Getenv("MFA_SECRET_KEY")),
+		MFAIssuer:                   getEnv("MFA_ISSUER", defaultMFAIssuer),
+		MFATokenExpiration:          mfaTokenDuration,
+		ImpersonationExpiration:     impersonationDuration,
+		MFARequiredRoles:            parseList(os.Getenv("MFA_REQUIRED_ROLES")),
+		RolePermissions:             parseRolePermissions(os.Getenv("ROLE_PERMISSIONS")),
+		AppURL:                      getEnv("APP_URL", defaultAppURL),
+		PasswordResetExpiration:     passwordResetDuration,
+		EmailVerification:           getEnv("EMAIL_VERIFICATION", defaultEmailVerification),
+		EmailVerificationExpiration: emailVerificationDuration,
+		MagicLinkExpiration:         getEnvDuration("MAGIC_LINK_EXPIRATION", magicLinkDuration),
+		TrustedProxies:              parseList(os.Getenv("TRUSTED_PROXIES")),
+		Mail: MailConfig{
+			Driver:       getEnv("MAILER", defaultMailer),
+			From:         getEnv("MAIL_FROM", defaultMailFrom),
+			Dir:          getEnv("MAIL_DIR", defaultMailDir),
+			SMTPHost:     os.Getenv("SMTP_HOST"),
+			SMTPPort:     getEnv("SMTP_PORT", defaultSMTPPort),
+			SMTPUsername: os.Getenv("SMTP_USERNAME"),
+			SMTPPassword: os.Getenv("SMTP_PASSWORD"),
+		},
+		LoginThrottle: LoginThrottleConfig{
+			FreeAttempts:     loginFreeAttempts,
+			BaseDelay:        loginBaseDelay,
+			MaxFailures:      getEnvInt("LOGIN_MAX_FAILURES", defaultLoginMaxFailures),
+			MaxFailuresPerIP: getEnvInt("LOGIN_MAX_FAILURES_PER_IP", defaultLoginMaxFailuresIP),
+			LockoutDuration:  getEnvDuration("LOGIN_LOCKOUT_DURATION", defaultLoginLockout),
+		},
+		PasswordHash: PasswordHashConfig{
+			Algorithm:         getEnv("PASSWORD_HASH_ALGORITHM", defaultPasswordHash),
+			BcryptCost:        getEnvInt("BCRYPT_COST", defaultBcryptCost),
+			Argon2Memory:      getEnvInt("ARGON2_MEMORY", defaultArgon2Memory),
+			Argon2Iterations:  getEnvInt("ARGON2_ITERATIONS", defaultArgon2Iterations),
+			Argon2Parallelism: getEnvInt("ARGON2_PARALLELISM", defaultArgon2Parallelism),
+		},
+		PasswordPolicy: PasswordPolicyConfig{
+			MinLength:             getEnvInt("PASSWORD_MIN_LENGTH", defaultPasswordMinLength),
+			MaxLength:             getEnvInt("PASSWORD_MAX_LENGTH", defaultPasswordMaxLength),
+			RequiredClasses:       parseList(os.Getenv("PASSWORD_REQUIRED_CLASSES")),
+			BreachedPasswordsPath: os.Getenv("BREACHED_PASSWORDS_PATH"),
+		},
+		Cookie: CookieConfig{
+			Domain:   os.Getenv("COOKIE_DOMAIN"),
+			Secure:   getEnvBool("COOKIE_SECURE", true),
+			SameSite: getEnv("COOKIE_SAME_SITE", defaultCookieSameSite),
+		},
+		OIDC: OIDCConfig{
+			Issuer:       os.Getenv("OIDC_ISSUER"),
+			ClientID:     os.Getenv("OIDC_CLIENT_ID"),
+			ClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+			RedirectURL:  os.Getenv("OIDC_REDIRECT_URL"),
+			Scopes:       parseList(getEnv("OIDC_SCOPES", defaultOIDCScopes)),
+		},
+		EncryptionSession: EncryptionSessionConfig{
+			ExchangeKey: os.Getenv("ENCRYPTION_EXCHANGE_KEY"),
+			TTL:         getEnvDuration("ENCRYPTION_SESSION_TTL", encryptionSessionDuration),
+			MaxSessions: getEnvInt("ENCRYPTION_SESSION_MAX", defaultEncryptionSessions),
+			Required:    getEnvBool("ENCRYPTION_SESSION_REQUIRED", false),
+		},
+	}
+}
+
+// getEnv returns the environment variable or the fallback if it is unset.
+func getEnv(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+	return fallback
+}
+
+// getEnvInt returns the environment variable as an integer, or the fallback if
+// it is unset or not a number.
+func getEnvInt(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// getEnvDuration returns the environment variable as a duration such as "15m",
+// or the fallback if it is unset or invalid.
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// getEnvBool returns the environment variable as a boolean such as "true" or
+// "0", or the fallback if it is unset or invalid.
+func getEnvBool(key string, fallback bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return fallback
 	}
+	return value
 }
@@ -1,7 +1,11 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -54,16 +58,17 @@ func TestGetConfig_WithEnvFile(t *testing.T) {
 		"ENCRYPTION_NONCE":   "test-encryption-nonce",
 	}
 
+	// godotenv reads .env from the working directory and keeps variables
+	// that are already set, so start from a directory holding only ours.
+	var env strings.Builder
 	for k, v := range envVars {
-		os.Setenv(k, v)
+		unsetEnv(t, k)
+		fmt.Fprintf(&env, "%s=%s\n", k, v)
 	}
-	defer func() {
-		for k := range envVars {
-			os.Unsetenv(k)
-		}
-		// Reset singleton for other tests
-		configInstance = nil
-	}()
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, ".env"), []byte(env.String()), 0o600))
+	t.Chdir(dir)
+	resetConfig(t)
 
 	config := GetConfig()
 	assert.NotNil(t, config)
@@ -92,12 +97,10 @@ func TestGetConfig_WithoutEnvFile(t *testing.T) {
 		"ENCRYPTION_NONCE",
 	}
 	for _, v := range envVars {
-		os.Unsetenv(v)
+		unsetEnv(t, v)
 	}
-	defer func() {
-		// Reset singleton for other tests
-		configInstance = nil
-	}()
+	t.Chdir(t.TempDir())
+	resetConfig(t)
 
 	config := GetConfig()
 	assert.NotNil(t, config)
@@ -120,6 +123,23 @@ func TestGetConfig_WithoutEnvFile(t *testing.T) {
 	assert.Equal(t, config.EncryptionNonce, config2.EncryptionNonce)
 }
 
+// unsetEnv unsets key for the rest of the test and restores it afterwards.
+func unsetEnv(t *testing.T, key string) {
+	t.Setenv(key, "")
+	os.Unsetenv(key)
+}
+
+// resetConfig makes the next GetConfig load the config again, before and
+// after the test.
+func resetConfig(t *testing.T) {
+	reset := func() {
+		configInstance = nil
+		configOnce = sync.Once{}
+	}
+	reset()
+	t.Cleanup(reset)
+}
+
 func TestConstants(t *testing.T) {
 	assert.Equal(t, 32, jwtKeySize)
 	assert.Equal(t, 32, encryptionKeySize)
@@ -127,3 +147,115 @@ func TestConstants(t *testing.T) {
 	assert.Equal(t, 15*time.Minute, accessTokenDuration)
 	assert.Equal(t, 7*24*time.Hour, refreshTokenDuration)
 }
+
+func TestParseKeyFiles(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  []KeyFile
+	}{
+		{
+			name:  "Empty value",
+			value: "",
+			want:  nil,
+		},
+		{
+			name:  "Multiple keys",
+			value: "2025-01=keys/new.pem, 2024-06=keys/old.pem",
+			want: []KeyFile{
+				{ID: "2025-01", Path: "keys/new.pem"},
+				{ID: "2024-06", Path: "keys/old.pem"},
+			},
+		},
+		{
+			name:  "Skips malformed entries",
+			value: "keys/private.pem,=keys/x.pem,current=keys/current.pem",
+			want: []KeyFile{
+				{ID: "current", Path: "keys/current.pem"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, parseKeyFiles(tt.value))
+		})
+	}
+}
+
+func TestParseRolePermissions(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  map[string][]string
+	}{
+		{
+			name:  "Empty value",
+			value: "",
+			want:  map[string][]string{},
+		},
+		{
+			name:  "Multiple roles",
+			value: "support=users:read users:write; auditor = users:read",
+			want: map[string][]string{
+				"support": {"users:read", "users:write"},
+				"auditor": {"users:read"},
+			},
+		},
+		{
+			name:  "Role without permissions",
+			value: "guest=;=users:read;broken",
+			want: map[string][]string{
+				"guest": nil,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, parseRolePermissions(tt.value))
+		})
+	}
+}
+
+func TestGetEnvNumbers(t *testing.T) {
+	tests := []struct {
+		name         string
+		value        string
+		wantInt      int
+		wantDuration time.Duration
+	}{
+		{
+			name:         "Unset",
+			value:        "",
+			wantInt:      5,
+			wantDuration: time.Minute,
+		},
+		{
+			name:         "Invalid",
+			value:        "abc",
+			wantInt:      5,
+			wantDuration: time.Minute,
+		},
+		{
+			name:         "Integer",
+			value:        "10",
+			wantInt:      10,
+			wantDuration: time.Minute, // A duration needs a unit
+		},
+		{
+			name:         "Duration",
+			value:        "30m",
+			wantInt:      5,
+			wantDuration: 30 * time.Minute,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TEST_CONFIG_VALUE", tt.value)
+			assert.Equal(t, tt.wantInt, getEnvInt("TEST_CONFIG_VALUE", 5))
+			assert.Equal(t, tt.wantDuration, getEnvDuration("TEST_CONFIG_VALUE", time.Minute))
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package hasher
+
+import (
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// bcryptAlgorithm hashes passwords with bcrypt. Hashes keep the standard
+// $2a$<cost>$ encoding, which is what passwords were stored with before the
+// hasher was configurable.
+type bcryptAlgorithm struct {
+	cost int
+}
+
+func (a *bcryptAlgorithm) hash(password string) (string, error) {
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), a.cost)
+	if err != nil {
+		return "", err
+	}
+	return string(bytes), nil
+}
+
+func (a *bcryptAlgorithm) verify(password, hash string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+func (a *bcryptAlgorithm) identifies(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
+
+func (a *bcryptAlgorithm) outdated(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost != a.cost
+}
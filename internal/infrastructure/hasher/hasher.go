@@ -0,0 +1,79 @@
+package hasher
+
+import (
+	"errors"
+	"fmt"
+
+	"web-server/internal/domain/service"
+	"web-server/internal/infrastructure/config"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var errInvalidHash = errors.New("invalid password hash")
+
+// algorithm hashes passwords with one algorithm and its current parameters.
+type algorithm interface {
+	hash(password string) (string, error)
+	verify(password, hash string) bool
+	// identifies reports whether the hash was made with this algorithm.
+	identifies(hash string) bool
+	// outdated reports whether the hash was made with other parameters.
+	outdated(hash string) bool
+}
+
+// Hasher hashes new passwords with the configured algorithm and verifies
+// hashes of every supported algorithm, so that the algorithm can be changed
+// without invalidating existing passwords.
+type Hasher struct {
+	current    algorithm
+	algorithms []algorithm
+}
+
+// New returns the password hasher selected by the configuration.
+func New(cfg config.PasswordHashConfig) (service.PasswordHasher, error) {
+	if cfg.BcryptCost < bcrypt.MinCost || cfg.BcryptCost > bcrypt.MaxCost {
+		return nil, fmt.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+	if cfg.Argon2Memory < 1 || cfg.Argon2Iterations < 1 || cfg.Argon2Parallelism < 1 || cfg.Argon2Parallelism > 255 {
+		return nil, errors.New("argon2id memory, iterations and parallelism must be positive")
+	}
+
+	bcryptHasher := &bcryptAlgorithm{cost: cfg.BcryptCost}
+	argon2idHasher := &argon2idAlgorithm{params: argon2idParams{
+		memory:      uint32(cfg.Argon2Memory),
+		iterations:  uint32(cfg.Argon2Iterations),
+		parallelism: uint8(cfg.Argon2Parallelism),
+		saltLength:  argon2idSaltLength,
+		keyLength:   argon2idKeyLength,
+	}}
+
+	hasher := &Hasher{algorithms: []algorithm{argon2idHasher, bcryptHasher}}
+	switch cfg.Algorithm {
+	case "argon2id":
+		hasher.current = argon2idHasher
+	case "bcrypt":
+		hasher.current = bcryptHasher
+	default:
+		return nil, fmt.Errorf("unknown password hash algorithm %q", cfg.Algorithm)
+	}
+
+	return hasher, nil
+}
+
+func (h *Hasher) Hash(password string) (string, error) {
+	return h.current.hash(password)
+}
+
+func (h *Hasher) Verify(password, hash string) bool {
+	for _, a := range h.algorithms {
+		if a.identifies(hash) {
+			return a.verify(password, hash)
+		}
+	}
+	return false
+}
+
+func (h *Hasher) NeedsRehash(hash string) bool {
+	return !h.current.identifies(hash) || h.current.outdated(hash)
+}
@@ -0,0 +1,143 @@
+package hasher
+
+import (
+	"strings"
+	"testing"
+
+	"web-server/internal/domain/service"
+	"web-server/internal/infrastructure/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testConfig uses cheap parameters to keep the tests fast.
+func testConfig(algorithm string) config.PasswordHashConfig {
+	return config.PasswordHashConfig{
+		Algorithm:         algorithm,
+		BcryptCost:        bcrypt.MinCost,
+		Argon2Memory:      1024,
+		Argon2Iterations:  1,
+		Argon2Parallelism: 1,
+	}
+}
+
+func newTestHasher(t *testing.T, cfg config.PasswordHashConfig) service.PasswordHasher {
+	t.Helper()
+	hasher, err := New(cfg)
+	require.NoError(t, err)
+	return hasher
+}
+
+func TestHasher_HashAndVerify(t *testing.T) {
+	for _, algorithm := range []string{"argon2id", "bcrypt"} {
+		t.Run(algorithm, func(t *testing.T) {
+			hasher := newTestHasher(t, testConfig(algorithm))
+
+			tests := []struct {
+				name     string
+				password string
+			}{
+				{name: "Valid password hash", password: "mypassword123"},
+				{name: "Empty password hash", password: ""},
+				{name: "Long password hash", password: "verylongpasswordthatishardtoguess123!@#"},
+			}
+
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					hash, err := hasher.Hash(tt.password)
+					require.NoError(t, err)
+					assert.NotEqual(t, tt.password, hash)
+					assert.True(t, hasher.Verify(tt.password, hash))
+					assert.False(t, hasher.Verify(tt.password+"x", hash))
+					assert.False(t, hasher.NeedsRehash(hash))
+				})
+			}
+		})
+	}
+}
+
+func TestHasher_Argon2idFormat(t *testing.T) {
+	hasher := newTestHasher(t, testConfig("argon2id"))
+
+	hash, err := hasher.Hash("mypassword123")
+	require.NoError(t, err)
+
+	parts := strings.Split(hash, "$")
+	require.Len(t, parts, 6)
+	assert.Equal(t, "argon2id", parts[1])
+	assert.Equal(t, "v=19", parts[2])
+	assert.Equal(t, "m=1024,t=1,p=1", parts[3])
+
+	// Salts are random
+	other, err := hasher.Hash("mypassword123")
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, other)
+}
+
+func TestHasher_Verify_InvalidHash(t *testing.T) {
+	hasher := newTestHasher(t, testConfig("argon2id"))
+
+	for _, hash := range []string{
+		"invalid_hash",
+		"",
+		"$argon2id$v=19$m=1024,t=1,p=1$c2FsdA",
+		"$argon2id$v=18$m=1024,t=1,p=1$c2FsdHNhbHQ$a2V5",
+		"$argon2id$v=19$m=0,t=1,p=1$c2FsdHNhbHQ$a2V5",
+		"$argon2id$v=19$m=1024,t=1,p=1$!!!$a2V5",
+	} {
+		assert.False(t, hasher.Verify("mypassword123", hash), hash)
+	}
+}
+
+func TestHasher_NeedsRehash(t *testing.T) {
+	bcryptHasher := newTestHasher(t, testConfig("bcrypt"))
+	bcryptHash, err := bcryptHasher.Hash("mypassword123")
+	require.NoError(t, err)
+
+	argon2idHasher := newTestHasher(t, testConfig("argon2id"))
+	argon2idHash, err := argon2idHasher.Hash("mypassword123")
+	require.NoError(t, err)
+
+	stronger := testConfig("argon2id")
+	stronger.Argon2Iterations = 2
+	strongerHasher := newTestHasher(t, stronger)
+
+	costlier := testConfig("bcrypt")
+	costlier.BcryptCost = bcrypt.MinCost + 1
+	costlierHasher := newTestHasher(t, costlier)
+
+	// Hashes of other algorithms are still accepted but need a rehash
+	assert.True(t, argon2idHasher.Verify("mypassword123", bcryptHash))
+	assert.True(t, argon2idHasher.NeedsRehash(bcryptHash))
+	assert.True(t, bcryptHasher.Verify("mypassword123", argon2idHash))
+	assert.True(t, bcryptHasher.NeedsRehash(argon2idHash))
+
+	// So are hashes with outdated parameters
+	assert.True(t, strongerHasher.Verify("mypassword123", argon2idHash))
+	assert.True(t, strongerHasher.NeedsRehash(argon2idHash))
+	assert.True(t, costlierHasher.Verify("mypassword123", bcryptHash))
+	assert.True(t, costlierHasher.NeedsRehash(bcryptHash))
+}
+
+func TestNew_InvalidConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(cfg *config.PasswordHashConfig)
+	}{
+		{name: "Unknown algorithm", modify: func(cfg *config.PasswordHashConfig) { cfg.Algorithm = "md5" }},
+		{name: "Bcrypt cost too low", modify: func(cfg *config.PasswordHashConfig) { cfg.BcryptCost = 1 }},
+		{name: "Zero argon2id memory", modify: func(cfg *config.PasswordHashConfig) { cfg.Argon2Memory = 0 }},
+		{name: "Too much parallelism", modify: func(cfg *config.PasswordHashConfig) { cfg.Argon2Parallelism = 256 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig("argon2id")
+			tt.modify(&cfg)
+			_, err := New(cfg)
+			assert.Error(t, err)
+		})
+	}
+}
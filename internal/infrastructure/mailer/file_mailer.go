@@ -0,0 +1,41 @@
+package mailer
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"web-server/internal/domain/service"
+
+	"github.com/google/uuid"
+)
+
+// FileMailer writes every email as an .eml file into a directory, so that
+// development setups and tests can inspect what would have been sent.
+type FileMailer struct {
+	dir  string
+	from string
+}
+
+func NewFileMailer(dir, from string) *FileMailer {
+	return &FileMailer{
+		dir:  dir,
+		from: from,
+	}
+}
+
+func (m *FileMailer) Send(message service.Message) error {
+	now := time.Now()
+	data, err := formatMessage(m.from, message, now)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(m.dir, 0o700); err != nil {
+		return err
+	}
+
+	// The timestamp prefix keeps the files sorted by the time they were sent
+	name := now.UTC().Format("20060102T150405.000000000") + "-" + uuid.New().String() + ".eml"
+	return os.WriteFile(filepath.Join(m.dir, name), data, 0o600)
+}
@@ -0,0 +1,33 @@
+package mailer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"web-server/internal/domain/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileMailer_Send(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+	mailer := NewFileMailer(dir, "no-reply@example.com")
+
+	require.NoError(t, mailer.Send(service.Message{
+		To:      "user@example.com",
+		Subject: "Hello",
+		Body:    "Body",
+	}))
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	data, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "From: no-reply@example.com\r\n")
+	assert.Contains(t, string(data), "To: user@example.com\r\n")
+	assert.Contains(t, string(data), "\r\n\r\nBody")
+}
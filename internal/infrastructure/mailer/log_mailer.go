@@ -0,0 +1,26 @@
+package mailer
+
+import (
+	"web-server/internal/domain/service"
+
+	"github.com/sirupsen/logrus"
+)
+
+// LogMailer writes emails to the log instead of sending them. It is meant for
+// development, where the links in the emails can be copied from the output.
+type LogMailer struct {
+	logger *logrus.Logger
+}
+
+func NewLogMailer(logger *logrus.Logger) *LogMailer {
+	return &LogMailer{logger: logger}
+}
+
+func (m *LogMailer) Send(message service.Message) error {
+	m.logger.WithFields(logrus.Fields{
+		"to":      message.To,
+		"subject": message.Subject,
+		"body":    message.Body,
+	}).Info("Email")
+	return nil
+}
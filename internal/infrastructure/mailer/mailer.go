@@ -0,0 +1,61 @@
+package mailer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"web-server/internal/domain/service"
+	"web-server/internal/infrastructure/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+var errHeaderInjection = errors.New("email header contains a line break")
+
+// New returns the mailer selected by the configuration.
+func New(cfg config.MailConfig, logger *logrus.Logger) (service.Mailer, error) {
+	switch cfg.Driver {
+	case "log":
+		return NewLogMailer(logger), nil
+	case "memory":
+		return NewMemoryMailer(), nil
+	case "file":
+		return NewFileMailer(cfg.Dir, cfg.From), nil
+	case "smtp":
+		if cfg.SMTPHost == "" {
+			return nil, errors.New("SMTP_HOST is required for the smtp mailer")
+		}
+		return NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.From), nil
+	default:
+		return nil, fmt.Errorf("unknown mailer %q", cfg.Driver)
+	}
+}
+
+// formatMessage renders a message in RFC 5322 format with CRLF line endings.
+func formatMessage(from string, message service.Message, now time.Time) ([]byte, error) {
+	headers := [][2]string{
+		{"From", from},
+		{"To", message.To},
+		{"Subject", message.Subject},
+		{"Date", now.Format(time.RFC1123Z)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "text/plain; charset=UTF-8"},
+	}
+
+	var b strings.Builder
+	for _, header := range headers {
+		// A line break would let user input add headers or recipients
+		if strings.ContainsAny(header[1], "\r\n") {
+			return nil, errHeaderInjection
+		}
+		b.WriteString(header[0] + ": " + header[1] + "\r\n")
+	}
+	b.WriteString("\r\n")
+
+	body := strings.ReplaceAll(message.Body, "\r\n", "\n")
+	b.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+
+	return []byte(b.String()), nil
+}
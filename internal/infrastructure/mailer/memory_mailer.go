@@ -0,0 +1,34 @@
+package mailer
+
+import (
+	"sync"
+
+	"web-server/internal/domain/service"
+)
+
+// MemoryMailer keeps emails in memory instead of sending them. It is meant for
+// tests and local tooling that read the links out of the sent emails.
+type MemoryMailer struct {
+	messages []service.Message
+	mutex    sync.RWMutex
+}
+
+func NewMemoryMailer() *MemoryMailer {
+	return &MemoryMailer{}
+}
+
+func (m *MemoryMailer) Send(message service.Message) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.messages = append(m.messages, message)
+	return nil
+}
+
+// Messages returns the emails sent so far, oldest first.
+func (m *MemoryMailer) Messages() []service.Message {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	return append([]service.Message(nil), m.messages...)
+}
@@ -0,0 +1,143 @@
+package mailer
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+
+	"web-server/internal/domain/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSMTPMessage is an email received by fakeSMTPServer.
+type fakeSMTPMessage struct {
+	From string
+	To   []string
+	Data string
+	Auth string
+}
+
+// fakeSMTPServer accepts a single SMTP session on localhost and reports the
+// message it received.
+func fakeSMTPServer(t *testing.T) (string, <-chan fakeSMTPMessage) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	messages := make(chan fakeSMTPMessage, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		reader := bufio.NewReader(conn)
+		reply := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+
+		var msg fakeSMTPMessage
+		reply("220 localhost ESMTP")
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			command := strings.ToUpper(line)
+
+			switch {
+			case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+				reply("250-localhost")
+				reply("250 AUTH PLAIN")
+			case strings.HasPrefix(command, "AUTH PLAIN"):
+				msg.Auth = strings.TrimSpace(line[len("AUTH PLAIN"):])
+				reply("235 Authenticated")
+			case strings.HasPrefix(command, "MAIL FROM:"):
+				msg.From = strings.Trim(line[len("MAIL FROM:"):], "<> ")
+				reply("250 OK")
+			case strings.HasPrefix(command, "RCPT TO:"):
+				msg.To = append(msg.To, strings.Trim(line[len("RCPT TO:"):], "<> "))
+				reply("250 OK")
+			case command == "DATA":
+				reply("354 End data with <CR><LF>.<CR><LF>")
+				var data strings.Builder
+				for {
+					dataLine, err := reader.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if dataLine == ".\r\n" {
+						break
+					}
+					data.WriteString(dataLine)
+				}
+				msg.Data = data.String()
+				reply("250 OK")
+			case command == "QUIT":
+				reply("221 Bye")
+				messages <- msg
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+	}()
+
+	return listener.Addr().String(), messages
+}
+
+func TestSMTPMailer_Send(t *testing.T) {
+	tests := []struct {
+		name     string
+		username string
+		password string
+		wantAuth bool
+	}{
+		{
+			name: "Without authentication",
+		},
+		{
+			name:     "With authentication",
+			username: "mailer",
+			password: "secret",
+			wantAuth: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr, messages := fakeSMTPServer(t)
+			host, port, err := net.SplitHostPort(addr)
+			require.NoError(t, err)
+
+			mailer := NewSMTPMailer(host, port, tt.username, tt.password, "no-reply@example.com")
+			err = mailer.Send(service.Message{
+				To:      "user@example.com",
+				Subject: "Reset your password",
+				Body:    "Line one\nLine two",
+			})
+			require.NoError(t, err)
+
+			msg := <-messages
+			assert.Equal(t, "no-reply@example.com", msg.From)
+			assert.Equal(t, []string{"user@example.com"}, msg.To)
+			assert.Contains(t, msg.Data, "Subject: Reset your password\r\n")
+			assert.Contains(t, msg.Data, "\r\n\r\nLine one\r\nLine two")
+			assert.Equal(t, tt.wantAuth, msg.Auth != "")
+		})
+	}
+}
+
+func TestSMTPMailer_RejectsHeaderInjection(t *testing.T) {
+	mailer := NewSMTPMailer("127.0.0.1", "1", "", "", "no-reply@example.com")
+	err := mailer.Send(service.Message{
+		To:      "user@example.com\r\nBcc: victim@example.com",
+		Subject: "Hello",
+	})
+	assert.ErrorIs(t, err, errHeaderInjection)
+}
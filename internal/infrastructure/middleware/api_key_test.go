@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"web-server/internal/domain/constants"
+	"web-server/internal/domain/entity"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeAPIKeyAuthenticator map[string]*entity.APIKey
+
+func (f fakeAPIKeyAuthenticator) AuthenticateAPIKey(key string) (*entity.User, *entity.APIKey, error) {
+	apiKey, ok := f[key]
+	if !ok {
+		return nil, nil, constants.ErrInvalidAPIKey
+	}
+	return &entity.User{ID: apiKey.UserID, Role: "admin"}, apiKey, nil
+}
+
+func TestAuthMiddleware_APIKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	manager, _ := newTestTokenManager()
+	apiKeys := fakeAPIKeyAuthenticator{
+		"wsk_full": {ID: "key-1", UserID: "user-1"},
+		"wsk_read": {ID: "key-2", UserID: "user-1", Scopes: []string{entity.APIKeyScopeRead}},
+	}
+
+	router := gin.New()
+	router.Use(AuthMiddleware(manager, apiKeys), VerifiedEmailMiddleware())
+	handler := func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("userID")+":"+c.GetString("role"))
+	}
+	router.GET("/test", handler)
+	router.POST("/test", handler)
+
+	request := func(method, key string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, "/test", nil)
+		req.Header.Set(APIKeyHeader, key)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Accepts unscoped key with the owner's role", func(t *testing.T) {
+		w := request("POST", "wsk_full")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "user-1:admin", w.Body.String())
+	})
+
+	t.Run("Read scope limits methods and role", func(t *testing.T) {
+		w := request("GET", "wsk_read")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "user-1:user", w.Body.String())
+
+		w = request("POST", "wsk_read")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "INSUFFICIENT_SCOPE")
+	})
+
+	t.Run("Rejects unknown key", func(t *testing.T) {
+		w := request("GET", "wsk_unknown")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), "INVALID_API_KEY")
+	})
+}
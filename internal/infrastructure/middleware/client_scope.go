@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"net/http"
+
+	"web-server/internal/domain/constants"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Context keys AuthMiddleware stores the OAuth client and the scopes of a
+// client token under. The subject of the token is stored under "userID".
+const (
+	ClientIDKey = "clientID"
+	ScopesKey   = "scopes"
+)
+
+// RequireScope rejects requests made with a client token that lacks any of
+// the given scopes. Requests of users are left to RequirePermission. It must
+// run after AuthMiddleware.
+func RequireScope(scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString(ClientIDKey) != "" && !hasScopes(c, scopes) {
+			c.JSON(http.StatusForbidden, constants.ErrTokenScopeInsufficient())
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// DenyClientTokens rejects requests made with client tokens. It guards the
+// routes that act on the account of the authenticated user, which clients do
+// not have. It must run after AuthMiddleware.
+func DenyClientTokens() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString(ClientIDKey) != "" {
+			c.JSON(http.StatusForbidden, constants.ErrUserTokenRequired())
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// hasScopes reports whether the client token of the request has every scope.
+func hasScopes(c *gin.Context, scopes []string) bool {
+	granted := c.GetStringSlice(ScopesKey)
+	for _, scope := range scopes {
+		found := false
+		for _, g := range granted {
+			if g == scope {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,161 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"web-server/internal/domain/constants"
+	"web-server/internal/domain/entity"
+	"web-server/internal/infrastructure/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// registerTestClients adds active OAuth clients, which client tokens need to be valid.
+func registerTestClients(t *testing.T, manager *TokenManager, ids ...string) {
+	t.Helper()
+	for _, id := range ids {
+		require.NoError(t, manager.clients.Create(&entity.OAuthClient{ID: id, Name: id, CreatedAt: time.Now()}))
+	}
+}
+
+func TestTokenManager_ClientToken(t *testing.T) {
+	manager, _ := newTestTokenManager()
+	registerTestClients(t, manager, "c-1")
+
+	token, expiresIn, err := manager.GenerateClientToken("client:c-1", "c-1", []string{"users:read", "roles:read"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(config.GetConfig().JWTExpiration.Seconds()), expiresIn)
+
+	claims, err := manager.ValidateAccessToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, "client:c-1", claims.Subject)
+	assert.Equal(t, "c-1", claims.ClientID)
+	assert.Equal(t, "users:read roles:read", claims.Scope)
+	assert.Empty(t, claims.UserID)
+	assert.Empty(t, claims.Role)
+
+	client := &entity.OAuthClient{ID: "c-1"}
+	introspection, err := manager.Introspect(token, "", client)
+	require.NoError(t, err)
+	assert.True(t, introspection.Active)
+	assert.Equal(t, "client:c-1", introspection.Subject)
+	assert.Equal(t, "c-1", introspection.ClientID)
+	assert.Equal(t, "users:read roles:read", introspection.Scope)
+
+	// Revoking the token ends it like any other access token
+	require.NoError(t, manager.RevokeToken(token, TokenTypeHintAccess, client))
+	_, err = manager.ValidateAccessToken(token)
+	assert.Error(t, err)
+}
+
+func TestTokenManager_ClientTokenEndsWithClient(t *testing.T) {
+	manager, _ := newTestTokenManager()
+	registerTestClients(t, manager, "c-1", "c-2")
+
+	revoked, _, err := manager.GenerateClientToken("client:c-1", "c-1", []string{"users:read"})
+	require.NoError(t, err)
+	active, _, err := manager.GenerateClientToken("client:c-2", "c-2", []string{"users:read"})
+	require.NoError(t, err)
+	unknown, _, err := manager.GenerateClientToken("client:c-3", "c-3", []string{"users:read"})
+	require.NoError(t, err)
+
+	require.NoError(t, manager.clients.Revoke("c-1", time.Now()))
+
+	_, err = manager.ValidateAccessToken(revoked)
+	assert.ErrorIs(t, err, constants.ErrAccessTokenRevoked)
+	_, err = manager.ValidateAccessToken(unknown)
+	assert.ErrorIs(t, err, constants.ErrAccessTokenRevoked)
+	_, err = manager.ValidateAccessToken(active)
+	assert.NoError(t, err)
+}
+
+func TestAuthMiddleware_ClientToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	manager, _ := newTestTokenManager()
+	checker := fakePermissionChecker{"admin": {"users:read", "users:write"}}
+	registerTestClients(t, manager, "c-1")
+
+	router := gin.New()
+	router.Use(AuthMiddleware(manager, nil))
+	router.GET("/whoami", func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("userID")+" "+c.GetString(ClientIDKey))
+	})
+	router.GET("/users", RequirePermission(checker, "users:read"), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	router.PUT("/users", RequirePermission(checker, "users:write"), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	router.GET("/account", DenyClientTokens(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	request := func(method, path, token string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	client, _, err := manager.GenerateClientToken("client:c-1", "c-1", []string{"users:read"})
+	require.NoError(t, err)
+	own, err := manager.GenerateTokenPair("user-1", "admin")
+	require.NoError(t, err)
+
+	t.Run("Exposes the client", func(t *testing.T) {
+		w := request("GET", "/whoami", client)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "client:c-1 c-1", w.Body.String())
+	})
+
+	t.Run("Enforces scopes instead of permissions", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, request("GET", "/users", client).Code)
+
+		w := request("PUT", "/users", client)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "INSUFFICIENT_SCOPE")
+
+		assert.Equal(t, http.StatusOK, request("PUT", "/users", own.AccessToken).Code)
+	})
+
+	t.Run("Denies account routes", func(t *testing.T) {
+		w := request("GET", "/account", client)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "USER_TOKEN_REQUIRED")
+
+		assert.Equal(t, http.StatusOK, request("GET", "/account", own.AccessToken).Code)
+	})
+}
+
+func TestRequireScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	request := func(clientID string, granted []string, required ...string) int {
+		router := gin.New()
+		router.Use(func(c *gin.Context) {
+			if clientID != "" {
+				c.Set(ClientIDKey, clientID)
+				c.Set(ScopesKey, granted)
+			}
+		})
+		router.GET("/test", RequireScope(required...), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/test", nil))
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, request("c-1", []string{"users:read", "roles:read"}, "users:read", "roles:read"))
+	assert.Equal(t, http.StatusForbidden, request("c-1", []string{"users:read"}, "users:read", "roles:read"))
+	assert.Equal(t, http.StatusForbidden, request("c-1", nil, "users:read"))
+	// Requests of users are left to RequirePermission
+	assert.Equal(t, http.StatusOK, request("", nil, "users:read"))
+}
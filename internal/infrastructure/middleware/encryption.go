@@ -2,13 +2,15 @@ package middleware
 
 import (
 	"bytes"
-	"crypto/aes"
-	"crypto/cipher"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"io"
+	"mime"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
@@ -16,141 +18,336 @@ import (
 	"web-server/internal/infrastructure/config"
 )
 
+// EncryptedContentType is the media type of encrypted bodies. Clients opt in to
+// payload encryption by sending their request body with it, or by accepting it
+// for requests without a body.
+const EncryptedContentType = "application/vnd.enc+json"
+
+// EncryptionVersionHeader names the envelope version of a request or response.
+// Clients send it to ask for versioned responses while the legacy format is
+// still enabled, and every versioned response carries it.
+const EncryptionVersionHeader = "X-Encryption-Version"
+
+// defaultEncryptionKeyID is the key ID of Config.EncryptionKey, the only key
+// the legacy format is sealed with.
+const defaultEncryptionKeyID = "default"
+
+// encryptedBody is the JSON body of an encrypted message. Version 1 carries a
+// base64 encoded envelope, see envelopeVersion1. Messages without a version
+// are in the legacy format, sealed with the static Config.EncryptionNonce.
 type encryptedBody struct {
-	Data string `json:"data"`
+	Version int    `json:"v,omitempty"`
+	Data    string `json:"data"`
 }
 
-func EncryptionMiddleware() gin.HandlerFunc {
+// EncryptionMiddleware decrypts the encrypted request bodies of clients that
+// opted in, so that handlers read plain JSON, and encrypts the JSON responses
+// to them. Clients that name a session from the key exchange use their session
+// key, others the shared keys of the key ring. Other requests pass through
+// unencrypted, except that clients of the legacy format still get encrypted
+// responses while Config.EncryptionLegacy is set.
+func EncryptionMiddleware(keys *EncryptionKeyRing, exchange *KeyExchange) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		cfg := config.GetConfig()
 
-		// Skip encryption for non-JSON requests
-		if !strings.Contains(c.GetHeader("Content-Type"), "application/json") {
+		encrypted := isEncryptedContentType(c.GetHeader("Content-Type"))
+		optedIn := encrypted || acceptsEncrypted(c.GetHeader("Accept"))
+		legacyClient := !optedIn && cfg.EncryptionLegacy && strings.Contains(c.GetHeader("Content-Type"), "application/json")
+
+		if !optedIn && !legacyClient {
 			c.Next()
 			return
 		}
 
-		// Read the request body
-		body, err := io.ReadAll(c.Request.Body)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, constants.ErrRequestBodyRead())
-			c.Abort()
-			return
+		// Create a custom response writer to intercept the response
+		writer := &encryptionResponseWriter{
+			ResponseWriter: c.Writer,
+			binding:        responseBinding(c.Request),
 		}
-		c.Request.Body.Close()
 
-		// Only encrypt if body is not empty
-		if len(body) > 0 {
-			// Create cipher
-			block, err := aes.NewCipher(cfg.EncryptionKey)
+		lookup := keys.Key
+		var legacyKey []byte
+		if sessionID := c.GetHeader(EncryptionSessionHeader); sessionID != "" {
+			key, err := exchange.SessionKey(sessionID)
 			if err != nil {
-				c.JSON(http.StatusInternalServerError, constants.ErrEncryption())
+				c.JSON(http.StatusBadRequest, constants.ErrUnknownEncryptionSession())
 				c.Abort()
 				return
 			}
 
-			aesgcm, err := cipher.NewGCM(block)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, constants.ErrEncryption())
-				c.Abort()
-				return
+			// Only the session key opens the requests of the session
+			writer.keyID, writer.key = sessionID, key
+			lookup = func(keyID string) ([]byte, error) {
+				if keyID != sessionID {
+					return nil, constants.ErrEncryptionKeyNotFound
+				}
+				return key, nil
 			}
+		} else if optedIn && cfg.EncryptionSession.Required {
+			c.JSON(http.StatusBadRequest, constants.ErrEncryptionSessionRequired())
+			c.Abort()
+			return
+		} else {
+			writer.keyID, writer.key = keys.Primary()
+			if cfg.EncryptionLegacy {
+				legacyKey, _ = keys.Key(defaultEncryptionKeyID)
+			}
+		}
 
-			// Encrypt the body
-			encrypted := aesgcm.Seal(nil, cfg.EncryptionNonce, body, nil)
-			encodedData := base64.StdEncoding.EncodeToString(encrypted)
-
-			// Replace request body with encrypted data
-			encBody := encryptedBody{Data: encodedData}
-			newBody, err := json.Marshal(encBody)
+		if encrypted {
+			body, err := io.ReadAll(c.Request.Body)
 			if err != nil {
-				c.JSON(http.StatusInternalServerError, constants.ErrEncryption())
+				c.JSON(http.StatusBadRequest, constants.ErrRequestBodyRead())
 				c.Abort()
 				return
 			}
+			c.Request.Body.Close()
+
+			if len(body) > 0 {
+				body, err = openRequestBody(c.Request, body, lookup, legacyKey)
+				if err != nil {
+					c.JSON(http.StatusBadRequest, envelopeErrorResponse(err))
+					c.Abort()
+					return
+				}
+			}
 
-			c.Request.Body = io.NopCloser(bytes.NewBuffer(newBody))
-			c.Request.ContentLength = int64(len(newBody))
+			// Hand the plaintext to the handlers as a regular JSON body
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+			c.Request.ContentLength = int64(len(body))
+			c.Request.Header.Set("Content-Type", "application/json")
 		}
 
-		// Create a custom response writer to intercept the response
-		writer := &encryptionResponseWriter{
-			ResponseWriter: c.Writer,
-			cfg:            cfg,
+		if legacyClient && legacyKey != nil && c.GetHeader(EncryptionVersionHeader) == "" {
+			writer.key, writer.legacy = legacyKey, true
 		}
 		c.Writer = writer
 
+		// A panicking handler has no response to encrypt, and whatever it
+		// buffered is dropped. The recovery middleware gets the underlying
+		// writer back, so that its error response is not held back as well.
+		finished := false
+		defer func() {
+			if !finished {
+				c.Writer = writer.ResponseWriter
+			}
+		}()
+
 		c.Next()
+		finished = true
+		writer.finish()
 	}
 }
 
+// encryptionResponseWriter buffers JSON responses and encrypts them as a
+// whole once the handlers are done, see finish. Whether a response is
+// encrypted is decided by its Content-Type when the handler first writes,
+// flushes or sends the header; other responses pass through unbuffered.
 type encryptionResponseWriter struct {
 	gin.ResponseWriter
-	cfg *config.Config
+	keyID   string
+	key     []byte
+	binding envelopeBinding
+	legacy  bool // Respond in the legacy format to clients that have not moved on
+
+	decided   bool // Whether the response is encrypted has been decided
+	encrypt   bool
+	committed bool // The handler wrote, flushed or sent the header of an encrypted response
+	buffer    bytes.Buffer
 }
 
 func (w *encryptionResponseWriter) Header() http.Header {
 	return w.ResponseWriter.Header()
 }
 
+// buffering decides on the first call whether the response is encrypted and
+// reports whether it is.
+func (w *encryptionResponseWriter) buffering() bool {
+	if !w.decided {
+		w.decided = true
+		w.encrypt = strings.Contains(w.Header().Get("Content-Type"), "application/json")
+	}
+	return w.encrypt
+}
+
 func (w *encryptionResponseWriter) Write(data []byte) (int, error) {
-	// Only encrypt JSON responses
-	if !strings.Contains(w.Header().Get("Content-Type"), "application/json") {
+	if !w.buffering() {
 		return w.ResponseWriter.Write(data)
 	}
 
-	// Create cipher
-	block, err := aes.NewCipher(w.cfg.EncryptionKey)
-	if err != nil {
-		return 0, err
+	w.committed = true
+	return w.buffer.Write(data)
+}
+
+func (w *encryptionResponseWriter) WriteString(s string) (int, error) {
+	if !w.buffering() {
+		return w.ResponseWriter.WriteString(s)
 	}
 
-	aesgcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return 0, err
+	w.committed = true
+	return w.buffer.WriteString(s)
+}
+
+// WriteHeaderNow holds the header of encrypted responses back until finish,
+// which knows their final length.
+func (w *encryptionResponseWriter) WriteHeaderNow() {
+	if !w.buffering() {
+		w.ResponseWriter.WriteHeaderNow()
+		return
+	}
+
+	w.committed = true
+}
+
+// Flush does nothing for encrypted responses, which are sent in one piece.
+func (w *encryptionResponseWriter) Flush() {
+	if !w.buffering() {
+		w.ResponseWriter.Flush()
+		return
+	}
+
+	w.committed = true
+}
+
+func (w *encryptionResponseWriter) Written() bool {
+	return w.committed || w.ResponseWriter.Written()
+}
+
+func (w *encryptionResponseWriter) Size() int {
+	if w.encrypt && !w.ResponseWriter.Written() {
+		return w.buffer.Len()
 	}
+	return w.ResponseWriter.Size()
+}
 
-	// Encrypt the response
-	encrypted := aesgcm.Seal(nil, w.cfg.EncryptionNonce, data, nil)
-	encodedData := base64.StdEncoding.EncodeToString(encrypted)
+// finish encrypts the buffered response and sends it with its header. Failing
+// that, the client gets an error instead of the plaintext.
+func (w *encryptionResponseWriter) finish() {
+	if !w.encrypt {
+		return
+	}
 
-	// Create encrypted response
-	encResp := encryptedBody{Data: encodedData}
-	newData, err := json.Marshal(encResp)
+	// Responses without a body, such as 204 No Content, have nothing to seal
+	if w.buffer.Len() == 0 {
+		w.ResponseWriter.WriteHeaderNow()
+		return
+	}
+
+	body, err := w.seal(w.buffer.Bytes())
 	if err != nil {
-		return 0, err
+		body, _ = json.Marshal(constants.ErrEncryption())
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Header().Del(EncryptionVersionHeader)
+		w.ResponseWriter.WriteHeader(http.StatusInternalServerError)
 	}
 
-	return w.ResponseWriter.Write(newData)
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.ResponseWriter.WriteHeaderNow()
+	w.ResponseWriter.Write(body)
 }
 
-func DecryptRequestBody(c *gin.Context) ([]byte, error) {
-	cfg := config.GetConfig()
+// seal encrypts a complete response body and sets the headers of the format
+// it is encrypted in.
+func (w *encryptionResponseWriter) seal(data []byte) ([]byte, error) {
+	var encResp encryptedBody
+	if w.legacy {
+		aesgcm, err := newGCM(w.key)
+		if err != nil {
+			return nil, err
+		}
+
+		// Deprecated: the static nonce is reused for every message
+		encResp.Data = base64.StdEncoding.EncodeToString(aesgcm.Seal(nil, config.GetConfig().EncryptionNonce, data, nil))
+		w.Header().Set("Deprecation", "true")
+	} else {
+		sealed, err := sealEnvelope(w.key, w.keyID, data, w.binding, time.Now())
+		if err != nil {
+			return nil, err
+		}
+
+		encResp.Version = int(envelopeVersion1)
+		encResp.Data = base64.StdEncoding.EncodeToString(sealed)
+		w.Header().Set("Content-Type", EncryptedContentType)
+		w.Header().Set(EncryptionVersionHeader, strconv.Itoa(encResp.Version))
+	}
+
+	return json.Marshal(encResp)
+}
 
+// openRequestBody decrypts an encrypted request body with the key its envelope
+// names. Legacy bodies without a version are only accepted with a legacy key,
+// which is nil unless Config.EncryptionLegacy is set.
+func openRequestBody(r *http.Request, body []byte, lookup func(keyID string) ([]byte, error), legacyKey []byte) ([]byte, error) {
 	var encBody encryptedBody
-	if err := c.ShouldBindJSON(&encBody); err != nil {
-		return nil, err
+	if err := json.Unmarshal(body, &encBody); err != nil {
+		return nil, constants.ErrInvalidEnvelope
 	}
 
 	encrypted, err := base64.StdEncoding.DecodeString(encBody.Data)
 	if err != nil {
-		return nil, err
+		return nil, constants.ErrInvalidEnvelope
 	}
 
-	block, err := aes.NewCipher(cfg.EncryptionKey)
-	if err != nil {
-		return nil, err
+	switch encBody.Version {
+	case int(envelopeVersion1):
+		return openEnvelope(encrypted, requestBinding(r), time.Now(), lookup)
+	case 0:
+		if legacyKey == nil {
+			return nil, constants.ErrLegacyEnvelope
+		}
+
+		aesgcm, err := newGCM(legacyKey)
+		if err != nil {
+			return nil, err
+		}
+
+		plaintext, err := aesgcm.Open(nil, config.GetConfig().EncryptionNonce, encrypted, nil)
+		if err != nil {
+			return nil, constants.ErrInvalidEnvelope
+		}
+		return plaintext, nil
+	default:
+		return nil, constants.ErrInvalidEnvelope
 	}
+}
 
-	aesgcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return nil, err
+// envelopeErrorResponse tells the client why its encrypted body was rejected.
+func envelopeErrorResponse(err error) constants.ErrorResponse {
+	switch {
+	case errors.Is(err, constants.ErrEnvelopeExpired):
+		return constants.ErrStaleEnvelope()
+	case errors.Is(err, constants.ErrEncryptionKeyNotFound):
+		return constants.ErrUnknownEncryptionKey()
+	case errors.Is(err, constants.ErrLegacyEnvelope):
+		return constants.ErrLegacyEnvelopeRejected()
+	default:
+		return constants.ErrMalformedEnvelope()
 	}
+}
 
-	decrypted, err := aesgcm.Open(nil, cfg.EncryptionNonce, encrypted, nil)
-	if err != nil {
-		return nil, err
+// isEncryptedContentType reports whether a Content-Type header names
+// EncryptedContentType.
+func isEncryptedContentType(value string) bool {
+	mediaType, _, err := mime.ParseMediaType(value)
+	return err == nil && mediaType == EncryptedContentType
+}
+
+// acceptsEncrypted reports whether an Accept header lists EncryptedContentType.
+func acceptsEncrypted(value string) bool {
+	for _, accepted := range strings.Split(value, ",") {
+		if isEncryptedContentType(strings.TrimSpace(accepted)) {
+			return true
+		}
 	}
+	return false
+}
+
+// requestBinding binds the envelope of a request body to its route.
+func requestBinding(r *http.Request) envelopeBinding {
+	return envelopeBinding{Direction: envelopeRequest, Method: r.Method, Path: r.URL.Path}
+}
 
-	return decrypted, nil
+// responseBinding binds the envelope of a response body to its request.
+func responseBinding(r *http.Request) envelopeBinding {
+	return envelopeBinding{Direction: envelopeResponse, Method: r.Method, Path: r.URL.Path}
 }
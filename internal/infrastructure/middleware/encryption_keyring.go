@@ -0,0 +1,184 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"web-server/internal/domain/constants"
+	"web-server/internal/infrastructure/config"
+)
+
+// encryptionKeySize is the size of the AES-256 payload encryption keys.
+const encryptionKeySize = 32
+
+// EncryptionKeyRing holds the keys payloads are encrypted with. The primary
+// key seals every new envelope; the other keys are retired and only open the
+// envelopes that name them. A new key is rolled out to all clients first and
+// made primary through the configuration once they have it, so no message in
+// flight becomes unreadable. The primary key is fixed at startup, so that
+// every instance seals with the same one.
+type EncryptionKeyRing struct {
+	keys    map[string][]byte
+	primary string
+	mutex   sync.RWMutex
+}
+
+// EncryptionKeyInfo describes a key of the ring without its key material.
+type EncryptionKeyInfo struct {
+	ID      string `json:"id" example:"2025-06"`
+	Primary bool   `json:"primary"`
+}
+
+func NewEncryptionKeyRing() *EncryptionKeyRing {
+	return &EncryptionKeyRing{
+		keys: make(map[string][]byte),
+	}
+}
+
+// LoadEncryptionKeyRing builds the key ring from the configuration. The
+// ENCRYPTION_KEY is kept under the ID "default", which also opens legacy
+// messages. The primary key is the configured one, else the first key file,
+// else the default key.
+func LoadEncryptionKeyRing(cfg *config.Config) (*EncryptionKeyRing, error) {
+	ring := NewEncryptionKeyRing()
+
+	if len(cfg.EncryptionKey) > 0 {
+		if err := ring.Add(defaultEncryptionKeyID, cfg.EncryptionKey); err != nil {
+			return nil, fmt.Errorf("encryption key %q: %w", defaultEncryptionKeyID, err)
+		}
+	}
+
+	for _, file := range cfg.EncryptionKeys {
+		data, err := os.ReadFile(file.Path)
+		if err != nil {
+			return nil, fmt.Errorf("reading encryption key %q: %w", file.ID, err)
+		}
+
+		key, err := ParseEncryptionKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("parsing encryption key %q: %w", file.ID, err)
+		}
+
+		if err := ring.Add(file.ID, key); err != nil {
+			return nil, err
+		}
+	}
+
+	primary := cfg.EncryptionPrimaryKey
+	if primary == "" && len(cfg.EncryptionKeys) > 0 {
+		primary = cfg.EncryptionKeys[0].ID
+	}
+	if primary == "" {
+		primary = defaultEncryptionKeyID
+	}
+
+	if err := ring.SetPrimary(primary); err != nil {
+		return nil, fmt.Errorf("primary encryption key %q: %w", primary, err)
+	}
+
+	return ring, nil
+}
+
+// ParseEncryptionKey parses the contents of a key file: 32 raw bytes, or the
+// same encoded as base64.
+func ParseEncryptionKey(data []byte) ([]byte, error) {
+	if len(data) == encryptionKeySize {
+		return data, nil
+	}
+
+	key, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(data)))
+	if err != nil || len(key) != encryptionKeySize {
+		return nil, constants.ErrInvalidEncryptionKey
+	}
+	return key, nil
+}
+
+// Add adds a retired key to the ring. Adding a key does not make it the
+// primary key.
+func (r *EncryptionKeyRing) Add(id string, key []byte) error {
+	if id == "" || len(id) > 255 || len(key) != encryptionKeySize {
+		return constants.ErrInvalidEncryptionKey
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.keys[id]; exists {
+		return constants.ErrEncryptionKeyExists
+	}
+
+	r.keys[id] = append([]byte(nil), key...)
+	return nil
+}
+
+// SetPrimary makes the key with the given ID seal all new envelopes. The
+// previous primary key keeps opening envelopes.
+func (r *EncryptionKeyRing) SetPrimary(id string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.keys[id]; !exists {
+		return constants.ErrEncryptionKeyNotFound
+	}
+
+	r.primary = id
+	return nil
+}
+
+// Remove drops a retired key that no client uses anymore. The primary key
+// cannot be removed.
+func (r *EncryptionKeyRing) Remove(id string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.keys[id]; !exists {
+		return constants.ErrEncryptionKeyNotFound
+	}
+	if id == r.primary {
+		return constants.ErrPrimaryEncryptionKeyRemoval
+	}
+
+	delete(r.keys, id)
+	return nil
+}
+
+// Primary returns the ID and key new envelopes are sealed with.
+func (r *EncryptionKeyRing) Primary() (string, []byte) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.primary, r.keys[r.primary]
+}
+
+// Key returns the key with the given ID, primary or retired.
+func (r *EncryptionKeyRing) Key(id string) ([]byte, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	key, exists := r.keys[id]
+	if !exists {
+		return nil, constants.ErrEncryptionKeyNotFound
+	}
+	return key, nil
+}
+
+// List describes the keys of the ring, sorted by ID.
+func (r *EncryptionKeyRing) List() []EncryptionKeyInfo {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	infos := make([]EncryptionKeyInfo, 0, len(r.keys))
+	for id := range r.keys {
+		infos = append(infos, EncryptionKeyInfo{ID: id, Primary: id == r.primary})
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].ID < infos[j].ID
+	})
+
+	return infos
+}
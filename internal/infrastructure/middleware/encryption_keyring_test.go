@@ -0,0 +1,129 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"web-server/internal/domain/constants"
+	"web-server/internal/infrastructure/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestEncryptionKeyRing() *EncryptionKeyRing {
+	keys, _ := LoadEncryptionKeyRing(config.GetConfig())
+	return keys
+}
+
+func TestLoadEncryptionKeyRing(t *testing.T) {
+	rawKey := bytes.Repeat([]byte{1}, 32)
+	encodedKey := bytes.Repeat([]byte{2}, 32)
+
+	dir := t.TempDir()
+	rawPath := filepath.Join(dir, "raw.key")
+	encodedPath := filepath.Join(dir, "encoded.key")
+	require.NoError(t, os.WriteFile(rawPath, rawKey, 0o600))
+	require.NoError(t, os.WriteFile(encodedPath, []byte(base64.StdEncoding.EncodeToString(encodedKey)+"\n"), 0o600))
+
+	files := []config.KeyFile{
+		{ID: "2025-06", Path: encodedPath},
+		{ID: "2025-01", Path: rawPath},
+	}
+
+	t.Run("First key file is primary", func(t *testing.T) {
+		ring, err := LoadEncryptionKeyRing(&config.Config{
+			EncryptionKey:  testEnvelopeKey,
+			EncryptionKeys: files,
+		})
+		require.NoError(t, err)
+
+		id, key := ring.Primary()
+		assert.Equal(t, "2025-06", id)
+		assert.Equal(t, encodedKey, key)
+
+		key, err = ring.Key("2025-01")
+		require.NoError(t, err)
+		assert.Equal(t, rawKey, key)
+
+		assert.Equal(t, []EncryptionKeyInfo{
+			{ID: "2025-01"},
+			{ID: "2025-06", Primary: true},
+			{ID: defaultEncryptionKeyID},
+		}, ring.List())
+	})
+
+	t.Run("Configured primary key", func(t *testing.T) {
+		ring, err := LoadEncryptionKeyRing(&config.Config{
+			EncryptionKey:        testEnvelopeKey,
+			EncryptionKeys:       files,
+			EncryptionPrimaryKey: defaultEncryptionKeyID,
+		})
+		require.NoError(t, err)
+
+		id, _ := ring.Primary()
+		assert.Equal(t, defaultEncryptionKeyID, id)
+	})
+
+	t.Run("Unknown primary key", func(t *testing.T) {
+		_, err := LoadEncryptionKeyRing(&config.Config{
+			EncryptionKey:        testEnvelopeKey,
+			EncryptionPrimaryKey: "2024-12",
+		})
+		assert.ErrorIs(t, err, constants.ErrEncryptionKeyNotFound)
+	})
+
+	t.Run("Invalid key file", func(t *testing.T) {
+		shortPath := filepath.Join(dir, "short.key")
+		require.NoError(t, os.WriteFile(shortPath, []byte("too short"), 0o600))
+
+		_, err := LoadEncryptionKeyRing(&config.Config{
+			EncryptionKeys: []config.KeyFile{{ID: "short", Path: shortPath}},
+		})
+		assert.ErrorIs(t, err, constants.ErrInvalidEncryptionKey)
+	})
+}
+
+func TestRotateEncryptionKey(t *testing.T) {
+	oldKey := bytes.Repeat([]byte{3}, 32)
+	newKey := bytes.Repeat([]byte{4}, 32)
+
+	ring := NewEncryptionKeyRing()
+	require.NoError(t, ring.Add("old", oldKey))
+	require.NoError(t, ring.SetPrimary("old"))
+
+	binding := envelopeBinding{Direction: envelopeRequest, Method: "POST", Path: "/echo"}
+	sealed, err := sealEnvelope(oldKey, "old", []byte(`{"message":"test"}`), binding, time.Now())
+	require.NoError(t, err)
+
+	decrypt := func() ([]byte, error) {
+		body, err := json.Marshal(encryptedBody{Version: 1, Data: base64.StdEncoding.EncodeToString(sealed)})
+		require.NoError(t, err)
+		return openRequestBody(httptest.NewRequest("POST", "/echo", nil), body, ring.Key, nil)
+	}
+
+	// Promote a new key
+	require.NoError(t, ring.Add("new", newKey))
+	assert.ErrorIs(t, ring.Add("new", newKey), constants.ErrEncryptionKeyExists)
+	require.NoError(t, ring.SetPrimary("new"))
+
+	id, _ := ring.Primary()
+	assert.Equal(t, "new", id)
+
+	// Messages sealed before the promotion still open
+	plaintext, err := decrypt()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"message":"test"}`, string(plaintext))
+
+	// Until the retired key is removed
+	assert.ErrorIs(t, ring.Remove("new"), constants.ErrPrimaryEncryptionKeyRemoval)
+	require.NoError(t, ring.Remove("old"))
+	_, err = decrypt()
+	assert.ErrorIs(t, err, constants.ErrEncryptionKeyNotFound)
+}
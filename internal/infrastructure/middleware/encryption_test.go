@@ -2,29 +2,56 @@ package middleware
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
-	"os"
+	"strconv"
 	"testing"
+	"time"
 
+	"web-server/internal/domain/constants"
 	"web-server/internal/infrastructure/config"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func setupTestRouter() *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	r := gin.New()
-	r.Use(EncryptionMiddleware())
+	r.Use(EncryptionMiddleware(newTestEncryptionKeyRing(), newTestKeyExchange()))
 	return r
 }
 
-func TestEncryptionMiddleware(t *testing.T) {
-	router := setupTestRouter()
+// sealTestRequest encrypts a request body for the route the way clients do.
+func sealTestRequest(t *testing.T, method, path string, plaintext []byte, now time.Time) []byte {
+	keyID, key := newTestEncryptionKeyRing().Primary()
+	sealed, err := sealEnvelope(key, keyID, plaintext, envelopeBinding{Direction: envelopeRequest, Method: method, Path: path}, now)
+	require.NoError(t, err)
+
+	body, err := json.Marshal(encryptedBody{Version: int(envelopeVersion1), Data: base64.StdEncoding.EncodeToString(sealed)})
+	require.NoError(t, err)
+	return body
+}
 
+// openTestResponse decrypts a response body for the route the way clients do.
+func openTestResponse(t *testing.T, method, path string, w *httptest.ResponseRecorder) []byte {
+	var encResp encryptedBody
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &encResp))
+	data, err := base64.StdEncoding.DecodeString(encResp.Data)
+	require.NoError(t, err)
+
+	plaintext, err := openEnvelope(data, envelopeBinding{Direction: envelopeResponse, Method: method, Path: path}, time.Now(),
+		newTestEncryptionKeyRing().Key)
+	require.NoError(t, err)
+	return plaintext
+}
+
+func TestEncryptionMiddleware(t *testing.T) {
 	t.Run("Handles non-JSON request", func(t *testing.T) {
+		router := setupTestRouter()
 		router.POST("/test", func(c *gin.Context) {
 			c.String(http.StatusOK, "plain text")
 		})
@@ -38,107 +65,150 @@ func TestEncryptionMiddleware(t *testing.T) {
 		assert.Equal(t, "plain text", w.Body.String())
 	})
 
-	t.Run("Encrypts JSON request and response", func(t *testing.T) {
+	t.Run("Passes plain JSON through", func(t *testing.T) {
+		router := setupTestRouter()
 		router.POST("/test", func(c *gin.Context) {
-			var data map[string]interface{}
-			decrypted, err := DecryptRequestBody(c)
-			assert.NoError(t, err)
-
-			err = json.Unmarshal(decrypted, &data)
-			assert.NoError(t, err)
-			assert.Equal(t, "test", data["message"])
-
-			c.JSON(http.StatusOK, map[string]string{"response": "success"})
+			var data map[string]string
+			require.NoError(t, c.ShouldBindJSON(&data))
+			c.JSON(http.StatusOK, data)
 		})
 
-		reqBody := map[string]string{"message": "test"}
-		reqJSON, _ := json.Marshal(reqBody)
-
 		w := httptest.NewRecorder()
-		req := httptest.NewRequest("POST", "/test", bytes.NewBuffer(reqJSON))
+		req := httptest.NewRequest("POST", "/test", bytes.NewBufferString(`{"message":"test"}`))
 		req.Header.Set("Content-Type", "application/json")
 		router.ServeHTTP(w, req)
 
 		assert.Equal(t, http.StatusOK, w.Code)
-
-		// Verify response is encrypted
-		var encResp encryptedBody
-		err := json.Unmarshal(w.Body.Bytes(), &encResp)
-		assert.NoError(t, err)
-		assert.NotEmpty(t, encResp.Data)
+		assert.JSONEq(t, `{"message":"test"}`, w.Body.String())
 	})
 
-	t.Run("Handles empty JSON body", func(t *testing.T) {
+	t.Run("Decrypts request and encrypts response", func(t *testing.T) {
+		router := setupTestRouter()
 		router.POST("/test", func(c *gin.Context) {
-			c.JSON(http.StatusOK, map[string]string{"status": "ok"})
+			// Handlers bind the plaintext as usual
+			var data map[string]string
+			require.NoError(t, c.ShouldBindJSON(&data))
+			assert.Equal(t, "test", data["message"])
+
+			c.JSON(http.StatusOK, map[string]string{"response": "success"})
 		})
 
 		w := httptest.NewRecorder()
-		req := httptest.NewRequest("POST", "/test", bytes.NewBuffer([]byte{}))
-		req.Header.Set("Content-Type", "application/json")
+		req := httptest.NewRequest("POST", "/test", bytes.NewBuffer(sealTestRequest(t, "POST", "/test", []byte(`{"message":"test"}`), time.Now())))
+		req.Header.Set("Content-Type", EncryptedContentType)
 		router.ServeHTTP(w, req)
 
 		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, EncryptedContentType, w.Header().Get("Content-Type"))
+		assert.Equal(t, "1", w.Header().Get(EncryptionVersionHeader))
+		assert.JSONEq(t, `{"response":"success"}`, string(openTestResponse(t, "POST", "/test", w)))
 	})
 
-	t.Run("Handles invalid JSON request", func(t *testing.T) {
-		router.POST("/test", func(c *gin.Context) {
-			c.JSON(http.StatusOK, map[string]string{"status": "ok"})
+	t.Run("Encrypts response when accepted", func(t *testing.T) {
+		router := setupTestRouter()
+		router.GET("/test", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"message": "test"})
 		})
 
 		w := httptest.NewRecorder()
-		req := httptest.NewRequest("POST", "/test", bytes.NewBufferString("invalid json"))
-		req.Header.Set("Content-Type", "application/json")
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.Header.Set("Accept", "text/html, "+EncryptedContentType+";q=0.9")
 		router.ServeHTTP(w, req)
 
-		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"message":"test"}`, string(openTestResponse(t, "GET", "/test", w)))
 	})
-}
 
-func TestDecryptRequestBody(t *testing.T) {
-	router := setupTestRouter()
-
-	t.Run("Successfully decrypts valid request", func(t *testing.T) {
+	t.Run("Handles empty encrypted body", func(t *testing.T) {
+		router := setupTestRouter()
 		router.POST("/test", func(c *gin.Context) {
-			decrypted, err := DecryptRequestBody(c)
-			assert.NoError(t, err)
-
-			var data map[string]interface{}
-			err = json.Unmarshal(decrypted, &data)
-			assert.NoError(t, err)
-			assert.Equal(t, "test", data["message"])
-
 			c.JSON(http.StatusOK, map[string]string{"status": "ok"})
 		})
 
-		reqBody := map[string]string{"message": "test"}
-		reqJSON, _ := json.Marshal(reqBody)
-
 		w := httptest.NewRecorder()
-		req := httptest.NewRequest("POST", "/test", bytes.NewBuffer(reqJSON))
-		req.Header.Set("Content-Type", "application/json")
+		req := httptest.NewRequest("POST", "/test", bytes.NewBuffer([]byte{}))
+		req.Header.Set("Content-Type", EncryptedContentType)
 		router.ServeHTTP(w, req)
 
 		assert.Equal(t, http.StatusOK, w.Code)
 	})
+}
 
-	t.Run("Handles invalid encrypted data", func(t *testing.T) {
-		router.POST("/test", func(c *gin.Context) {
-			_, err := DecryptRequestBody(c)
-			assert.Error(t, err)
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid encrypted data"})
+func TestEncryptionMiddleware_RejectsEnvelopes(t *testing.T) {
+	cfg := config.GetConfig()
+	now := time.Now()
+
+	// Sealed with a key the server does not have
+	sealed, err := sealEnvelope(bytes.Repeat([]byte{9}, 32), "unknown", []byte(`{}`),
+		envelopeBinding{Direction: envelopeRequest, Method: "POST", Path: "/test"}, now)
+	require.NoError(t, err)
+	unknownKey, err := json.Marshal(encryptedBody{Version: 1, Data: base64.StdEncoding.EncodeToString(sealed)})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		body     []byte
+		wantCode string
+	}{
+		{
+			name:     "Invalid JSON",
+			body:     []byte("invalid json"),
+			wantCode: constants.ErrMalformedEnvelope().Code,
+		},
+		{
+			name:     "Invalid base64",
+			body:     []byte(`{"v":1,"data":"invalid-base64"}`),
+			wantCode: constants.ErrMalformedEnvelope().Code,
+		},
+		{
+			name:     "Unknown version",
+			body:     []byte(`{"v":2,"data":""}`),
+			wantCode: constants.ErrMalformedEnvelope().Code,
+		},
+		{
+			name:     "Sealed for another route",
+			body:     sealTestRequest(t, "POST", "/other", []byte(`{}`), now),
+			wantCode: constants.ErrMalformedEnvelope().Code,
+		},
+		{
+			name:     "Stale timestamp",
+			body:     sealTestRequest(t, "POST", "/test", []byte(`{}`), now.Add(-envelopeMaxSkew-time.Minute)),
+			wantCode: constants.ErrStaleEnvelope().Code,
+		},
+		{
+			name:     "Unknown key",
+			body:     unknownKey,
+			wantCode: constants.ErrUnknownEncryptionKey().Code,
+		},
+		{
+			name:     "Legacy format",
+			body:     []byte(`{"data":"AAAA"}`),
+			wantCode: constants.ErrLegacyEnvelopeRejected().Code,
+		},
+	}
+
+	defer func(legacy bool) { cfg.EncryptionLegacy = legacy }(cfg.EncryptionLegacy)
+	cfg.EncryptionLegacy = false
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := setupTestRouter()
+			router.POST("/test", func(c *gin.Context) {
+				t.Error("handler must not run")
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/test", bytes.NewBuffer(tt.body))
+			req.Header.Set("Content-Type", EncryptedContentType)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+
+			var resp constants.ErrorResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, tt.wantCode, resp.Code)
 		})
-
-		encBody := encryptedBody{Data: "invalid-base64"}
-		reqJSON, _ := json.Marshal(encBody)
-
-		w := httptest.NewRecorder()
-		req := httptest.NewRequest("POST", "/test", bytes.NewBuffer(reqJSON))
-		req.Header.Set("Content-Type", "application/json")
-		router.ServeHTTP(w, req)
-
-		assert.Equal(t, http.StatusBadRequest, w.Code)
-	})
+	}
 }
 
 func TestEncryptionResponseWriter(t *testing.T) {
@@ -150,6 +220,7 @@ func TestEncryptionResponseWriter(t *testing.T) {
 
 		w := httptest.NewRecorder()
 		req := httptest.NewRequest("GET", "/test", nil)
+		req.Header.Set("Accept", EncryptedContentType)
 		router.ServeHTTP(w, req)
 
 		assert.Equal(t, http.StatusOK, w.Code)
@@ -164,6 +235,7 @@ func TestEncryptionResponseWriter(t *testing.T) {
 
 		w := httptest.NewRecorder()
 		req := httptest.NewRequest("GET", "/test", nil)
+		req.Header.Set("Accept", EncryptedContentType)
 		router.ServeHTTP(w, req)
 
 		assert.Equal(t, http.StatusOK, w.Code)
@@ -173,42 +245,93 @@ func TestEncryptionResponseWriter(t *testing.T) {
 		assert.NoError(t, err)
 		assert.NotEmpty(t, encResp.Data)
 	})
-}
 
-func TestEncryptionWithInvalidKey(t *testing.T) {
-	// Store original encryption key and nonce
-	origKey := os.Getenv("ENCRYPTION_KEY")
-	origNonce := os.Getenv("ENCRYPTION_NONCE")
-	defer func() {
-		// Restore original environment variables after test
-		if origKey != "" {
-			os.Setenv("ENCRYPTION_KEY", origKey)
-		} else {
-			os.Unsetenv("ENCRYPTION_KEY")
-		}
-		if origNonce != "" {
-			os.Setenv("ENCRYPTION_NONCE", origNonce)
-		} else {
-			os.Unsetenv("ENCRYPTION_NONCE")
-		}
-	}()
-
-	// Set test environment variables with invalid key
-	os.Setenv("ENCRYPTION_KEY", "invalid-key")
-	os.Setenv("ENCRYPTION_NONCE", string(make([]byte, 12)))
-
-	// Reset config instance to force new config creation with our test values
-	config.GetConfig()
-
-	router := setupTestRouter()
-	router.POST("/test", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	t.Run("Encrypts chunked JSON response once", func(t *testing.T) {
+		router := setupTestRouter()
+		router.GET("/test", func(c *gin.Context) {
+			c.Header("Content-Type", "application/json")
+			c.Header("Content-Length", "27")
+			c.Status(http.StatusAccepted)
+			c.Writer.WriteHeaderNow()
+			c.Writer.WriteString(`{"items":[`)
+			c.Writer.Flush()
+			c.Writer.Write([]byte(`"a","b"`))
+			c.Writer.Flush()
+			c.Writer.WriteString(`]}`)
+		})
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.Header.Set("Accept", EncryptedContentType)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusAccepted, w.Code)
+		assert.Equal(t, EncryptedContentType, w.Header().Get("Content-Type"))
+		assert.Equal(t, strconv.Itoa(w.Body.Len()), w.Header().Get("Content-Length"))
+		assert.JSONEq(t, `{"items":["a","b"]}`, string(openTestResponse(t, "GET", "/test", w)))
+	})
+
+	t.Run("Streams non-JSON response", func(t *testing.T) {
+		router := setupTestRouter()
+		router.GET("/test", func(c *gin.Context) {
+			c.Header("Content-Type", "text/event-stream")
+			c.Writer.WriteString("data: first\n\n")
+			c.Writer.Flush()
+			assert.True(t, c.Writer.Written())
+			c.Writer.WriteString("data: second\n\n")
+		})
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.Header.Set("Accept", EncryptedContentType)
+		router.ServeHTTP(w, req)
+
+		assert.True(t, w.Flushed)
+		assert.Equal(t, "data: first\n\ndata: second\n\n", w.Body.String())
+	})
+
+	t.Run("Lets recovery respond to a panicking handler", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		router.Use(gin.CustomRecovery(func(c *gin.Context, _ any) {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, constants.ErrInternalServer())
+		}))
+		router.Use(EncryptionMiddleware(newTestEncryptionKeyRing(), newTestKeyExchange()))
+		router.GET("/test", func(c *gin.Context) {
+			c.Header("Content-Type", "application/json")
+			c.Writer.WriteString(`{"partial":`)
+			panic("handler failed")
+		})
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.Header.Set("Accept", EncryptedContentType)
+		router.ServeHTTP(w, req)
+
+		// The partial response of the handler is dropped, not sent with the error
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.JSONEq(t, `{"code":"INTERNAL_SERVER_ERROR","message":"Internal server error occurred"}`, w.Body.String())
 	})
 
-	w := httptest.NewRecorder()
-	req := httptest.NewRequest("POST", "/test", bytes.NewBufferString("{}"))
-	req.Header.Set("Content-Type", "application/json")
-	router.ServeHTTP(w, req)
+	t.Run("Sends JSON response without body as is", func(t *testing.T) {
+		router := setupTestRouter()
+		router.DELETE("/test", func(c *gin.Context) {
+			c.Header("Content-Type", "application/json")
+			c.Status(http.StatusNoContent)
+		})
 
-	assert.Equal(t, http.StatusInternalServerError, w.Code)
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("DELETE", "/test", nil)
+		req.Header.Set("Accept", EncryptedContentType)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Empty(t, w.Body.String())
+	})
+}
+
+func TestEncryptionWithInvalidKey(t *testing.T) {
+	// Invalid keys are rejected at startup instead of failing every request
+	_, err := LoadEncryptionKeyRing(&config.Config{EncryptionKey: []byte("invalid-key")})
+	assert.ErrorIs(t, err, constants.ErrInvalidEncryptionKey)
 }
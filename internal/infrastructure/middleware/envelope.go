@@ -0,0 +1,152 @@
+package middleware
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"time"
+
+	"web-server/internal/domain/constants"
+)
+
+// envelopeVersion1 is the first versioned envelope format. A sealed message is
+//
+//	version (1 byte) | timestamp (8 bytes, Unix seconds, big endian) |
+//	key ID length (1 byte) | key ID | nonce (12 bytes) | ciphertext and GCM tag
+//
+// The header up to the key ID is authenticated as associated data together
+// with the envelopeBinding, so none of it can be changed without the message
+// failing to open.
+const envelopeVersion1 byte = 1
+
+// envelopeMaxSkew is how far the timestamp of a request envelope may be from
+// the server clock. It limits how long a captured request can be replayed.
+const envelopeMaxSkew = 5 * time.Minute
+
+// Directions of an envelope, so that a response cannot be replayed as a
+// request to the same route.
+const (
+	envelopeRequest  = "request"
+	envelopeResponse = "response"
+)
+
+// envelopeBinding is the associated data an envelope is bound to besides its
+// header: the request it belongs to and whether it carries the request or the
+// response body.
+type envelopeBinding struct {
+	Direction string
+	Method    string
+	Path      string
+}
+
+// envelope is a parsed sealed message.
+type envelope struct {
+	Version    byte
+	Timestamp  time.Time
+	KeyID      string
+	Nonce      []byte
+	Ciphertext []byte
+
+	header []byte // Raw bytes from the version up to the key ID
+}
+
+// sealEnvelope encrypts the plaintext with a fresh random nonce and returns
+// the sealed envelope.
+func sealEnvelope(key []byte, keyID string, plaintext []byte, binding envelopeBinding, now time.Time) ([]byte, error) {
+	if len(keyID) > 255 {
+		return nil, constants.ErrInvalidEnvelope
+	}
+
+	aesgcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 0, 10+len(keyID))
+	header = append(header, envelopeVersion1)
+	header = binary.BigEndian.AppendUint64(header, uint64(now.Unix()))
+	header = append(header, byte(len(keyID)))
+	header = append(header, keyID...)
+
+	nonce := make([]byte, aesgcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	sealed := append(header, nonce...)
+	return aesgcm.Seal(sealed, nonce, plaintext, associatedData(header, binding)), nil
+}
+
+// openEnvelope decrypts a sealed envelope with the key its key ID names. The
+// timestamp of request envelopes must be within envelopeMaxSkew of now.
+func openEnvelope(data []byte, binding envelopeBinding, now time.Time, lookup func(keyID string) ([]byte, error)) ([]byte, error) {
+	env, err := parseEnvelope(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if binding.Direction == envelopeRequest {
+		if skew := now.Sub(env.Timestamp); skew > envelopeMaxSkew || skew < -envelopeMaxSkew {
+			return nil, constants.ErrEnvelopeExpired
+		}
+	}
+
+	key, err := lookup(env.KeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	aesgcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := aesgcm.Open(nil, env.Nonce, env.Ciphertext, associatedData(env.header, binding))
+	if err != nil {
+		return nil, constants.ErrInvalidEnvelope
+	}
+
+	return plaintext, nil
+}
+
+// parseEnvelope splits a sealed envelope into its parts without decrypting it.
+func parseEnvelope(data []byte) (*envelope, error) {
+	if len(data) < 10 || data[0] != envelopeVersion1 {
+		return nil, constants.ErrInvalidEnvelope
+	}
+
+	keyIDEnd := 10 + int(data[9])
+	nonceEnd := keyIDEnd + 12
+	if len(data) < nonceEnd {
+		return nil, constants.ErrInvalidEnvelope
+	}
+
+	return &envelope{
+		Version:    data[0],
+		Timestamp:  time.Unix(int64(binary.BigEndian.Uint64(data[1:9])), 0),
+		KeyID:      string(data[10:keyIDEnd]),
+		Nonce:      data[keyIDEnd:nonceEnd],
+		Ciphertext: data[nonceEnd:],
+		header:     data[:keyIDEnd],
+	}, nil
+}
+
+// associatedData binds an envelope to its header and request.
+func associatedData(header []byte, binding envelopeBinding) []byte {
+	aad := make([]byte, 0, len(header)+len(binding.Direction)+len(binding.Method)+len(binding.Path)+3)
+	aad = append(aad, header...)
+	for _, part := range []string{binding.Direction, binding.Method, binding.Path} {
+		aad = append(aad, 0)
+		aad = append(aad, part...)
+	}
+	return aad
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
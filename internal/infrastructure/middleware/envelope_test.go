@@ -0,0 +1,157 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"web-server/internal/domain/constants"
+	"web-server/internal/infrastructure/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testEnvelopeKey = bytes.Repeat([]byte{7}, 32)
+
+func testEnvelopeLookup(keyID string) ([]byte, error) {
+	if keyID != "k1" {
+		return nil, constants.ErrInvalidEnvelope
+	}
+	return testEnvelopeKey, nil
+}
+
+func TestSealEnvelope(t *testing.T) {
+	binding := envelopeBinding{Direction: envelopeRequest, Method: "POST", Path: "/api/test"}
+	now := time.Now()
+
+	first, err := sealEnvelope(testEnvelopeKey, "k1", []byte(`{"a":1}`), binding, now)
+	require.NoError(t, err)
+	second, err := sealEnvelope(testEnvelopeKey, "k1", []byte(`{"a":1}`), binding, now)
+	require.NoError(t, err)
+
+	// Every message gets its own nonce
+	firstEnv, err := parseEnvelope(first)
+	require.NoError(t, err)
+	secondEnv, err := parseEnvelope(second)
+	require.NoError(t, err)
+	assert.NotEqual(t, firstEnv.Nonce, secondEnv.Nonce)
+	assert.Equal(t, envelopeVersion1, firstEnv.Version)
+	assert.Equal(t, "k1", firstEnv.KeyID)
+	assert.Equal(t, now.Unix(), firstEnv.Timestamp.Unix())
+
+	plaintext, err := openEnvelope(first, binding, now, testEnvelopeLookup)
+	require.NoError(t, err)
+	assert.Equal(t, `{"a":1}`, string(plaintext))
+}
+
+func TestOpenEnvelope_Rejects(t *testing.T) {
+	binding := envelopeBinding{Direction: envelopeRequest, Method: "POST", Path: "/api/test"}
+	now := time.Now()
+
+	sealed, err := sealEnvelope(testEnvelopeKey, "k1", []byte("secret"), binding, now)
+	require.NoError(t, err)
+
+	t.Run("Other route", func(t *testing.T) {
+		other := binding
+		other.Path = "/api/other"
+		_, err := openEnvelope(sealed, other, now, testEnvelopeLookup)
+		assert.ErrorIs(t, err, constants.ErrInvalidEnvelope)
+	})
+
+	t.Run("Other direction", func(t *testing.T) {
+		other := binding
+		other.Direction = envelopeResponse
+		_, err := openEnvelope(sealed, other, now, testEnvelopeLookup)
+		assert.ErrorIs(t, err, constants.ErrInvalidEnvelope)
+	})
+
+	t.Run("Changed timestamp", func(t *testing.T) {
+		tampered := append([]byte{}, sealed...)
+		tampered[8]++
+		_, err := openEnvelope(tampered, binding, now, testEnvelopeLookup)
+		assert.ErrorIs(t, err, constants.ErrInvalidEnvelope)
+	})
+
+	t.Run("Old request", func(t *testing.T) {
+		_, err := openEnvelope(sealed, binding, now.Add(envelopeMaxSkew+time.Minute), testEnvelopeLookup)
+		assert.ErrorIs(t, err, constants.ErrEnvelopeExpired)
+	})
+
+	t.Run("Unknown version or truncated", func(t *testing.T) {
+		tampered := append([]byte{}, sealed...)
+		tampered[0] = 2
+		_, err := openEnvelope(tampered, binding, now, testEnvelopeLookup)
+		assert.ErrorIs(t, err, constants.ErrInvalidEnvelope)
+
+		_, err = openEnvelope(sealed[:15], binding, now, testEnvelopeLookup)
+		assert.ErrorIs(t, err, constants.ErrInvalidEnvelope)
+	})
+}
+
+func TestLegacyEnvelope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := config.GetConfig()
+	defer func(legacy bool) { cfg.EncryptionLegacy = legacy }(cfg.EncryptionLegacy)
+
+	router := gin.New()
+	router.Use(EncryptionMiddleware(newTestEncryptionKeyRing(), newTestKeyExchange()))
+	router.POST("/echo", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+
+	request := func(contentType, header string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/echo", nil)
+		req.Header.Set("Content-Type", contentType)
+		if header != "" {
+			req.Header.Set(EncryptionVersionHeader, header)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	decode := func(w *httptest.ResponseRecorder) (encryptedBody, []byte) {
+		var body encryptedBody
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		data, err := base64.StdEncoding.DecodeString(body.Data)
+		require.NoError(t, err)
+		return body, data
+	}
+
+	t.Run("Versioned for clients that opted in", func(t *testing.T) {
+		cfg.EncryptionLegacy = true
+		w := request(EncryptedContentType, "")
+		body, data := decode(w)
+		assert.Equal(t, 1, body.Version)
+		assert.Equal(t, "1", w.Header().Get(EncryptionVersionHeader))
+
+		plaintext, err := openEnvelope(data, envelopeBinding{Direction: envelopeResponse, Method: "POST", Path: "/echo"}, time.Now(),
+			func(string) ([]byte, error) { return cfg.EncryptionKey, nil })
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"status":"ok"}`, string(plaintext))
+	})
+
+	t.Run("Legacy while enabled and not opted out", func(t *testing.T) {
+		cfg.EncryptionLegacy = true
+		w := request("application/json", "")
+		body, _ := decode(w)
+		assert.Zero(t, body.Version)
+		assert.Equal(t, "true", w.Header().Get("Deprecation"))
+
+		w = request("application/json", "1")
+		body, _ = decode(w)
+		assert.Equal(t, 1, body.Version)
+	})
+
+	t.Run("Plain JSON once disabled", func(t *testing.T) {
+		cfg.EncryptionLegacy = false
+		w := request("application/json", "")
+		assert.JSONEq(t, `{"status":"ok"}`, w.Body.String())
+	})
+}
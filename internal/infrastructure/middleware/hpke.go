@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/sha256"
+	"encoding/binary"
+)
+
+// HPKE (RFC 9180) identifiers of the key exchange ciphersuite:
+// DHKEM(X25519, HKDF-SHA256), HKDF-SHA256 and AES-256-GCM.
+const (
+	hpkeKEMX25519     uint16 = 0x0020
+	hpkeKDFSHA256     uint16 = 0x0001
+	hpkeAEADAES256GCM uint16 = 0x0002
+)
+
+// hpkeExport derives a secret from the encapsulated key of a sender like the
+// Export function of an HPKE base mode recipient context (RFC 9180, sections
+// 4.1, 5.1 and 5.3). Clients derive the same secret with any HPKE library from
+// the server's public key, without sealing a message.
+func hpkeExport(privateKey *ecdh.PrivateKey, enc, info, exporterContext []byte, length int) ([]byte, error) {
+	senderKey, err := ecdh.X25519().NewPublicKey(enc)
+	if err != nil {
+		return nil, err
+	}
+
+	// Rejects the low order points that would make the secret predictable
+	dh, err := privateKey.ECDH(senderKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return hpkeExportDH(dh, enc, privateKey.PublicKey().Bytes(), info, exporterContext, length)
+}
+
+// hpkeExportDH runs the DHKEM and key schedule steps of hpkeExport on the
+// Diffie-Hellman result, which the sender computes from its ephemeral key.
+func hpkeExportDH(dh, enc, recipientKey, info, exporterContext []byte, length int) ([]byte, error) {
+	// DHKEM ExtractAndExpand
+	kemSuite := binary.BigEndian.AppendUint16([]byte("KEM"), hpkeKEMX25519)
+	kemContext := append(append([]byte{}, enc...), recipientKey...)
+	eaePRK, err := hpkeLabeledExtract(kemSuite, nil, "eae_prk", dh)
+	if err != nil {
+		return nil, err
+	}
+	sharedSecret, err := hpkeLabeledExpand(kemSuite, eaePRK, "shared_secret", kemContext, sha256.Size)
+	if err != nil {
+		return nil, err
+	}
+
+	// Key schedule of mode_base, without a PSK
+	suite := []byte("HPKE")
+	suite = binary.BigEndian.AppendUint16(suite, hpkeKEMX25519)
+	suite = binary.BigEndian.AppendUint16(suite, hpkeKDFSHA256)
+	suite = binary.BigEndian.AppendUint16(suite, hpkeAEADAES256GCM)
+
+	pskIDHash, err := hpkeLabeledExtract(suite, nil, "psk_id_hash", nil)
+	if err != nil {
+		return nil, err
+	}
+	infoHash, err := hpkeLabeledExtract(suite, nil, "info_hash", info)
+	if err != nil {
+		return nil, err
+	}
+	keyScheduleContext := append(append([]byte{0x00}, pskIDHash...), infoHash...)
+
+	secret, err := hpkeLabeledExtract(suite, sharedSecret, "secret", nil)
+	if err != nil {
+		return nil, err
+	}
+	exporterSecret, err := hpkeLabeledExpand(suite, secret, "exp", keyScheduleContext, sha256.Size)
+	if err != nil {
+		return nil, err
+	}
+
+	return hpkeLabeledExpand(suite, exporterSecret, "sec", exporterContext, length)
+}
+
+func hpkeLabeledExtract(suite, salt []byte, label string, ikm []byte) ([]byte, error) {
+	labeledIKM := append([]byte("HPKE-v1"), suite...)
+	labeledIKM = append(labeledIKM, label...)
+	labeledIKM = append(labeledIKM, ikm...)
+	return hkdf.Extract(sha256.New, labeledIKM, salt)
+}
+
+func hpkeLabeledExpand(suite, prk []byte, label string, info []byte, length int) ([]byte, error) {
+	labeledInfo := binary.BigEndian.AppendUint16(nil, uint16(length))
+	labeledInfo = append(labeledInfo, "HPKE-v1"...)
+	labeledInfo = append(labeledInfo, suite...)
+	labeledInfo = append(labeledInfo, label...)
+	labeledInfo = append(labeledInfo, info...)
+	return hkdf.Expand(sha256.New, prk, string(labeledInfo), length)
+}
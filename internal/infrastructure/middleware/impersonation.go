@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"net/http"
+
+	"web-server/internal/domain/constants"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ImpersonatorKey is the context key AuthMiddleware stores the admin under when
+// the request is made with an impersonation token. The impersonated user is
+// stored under "userID" as usual.
+const ImpersonatorKey = "impersonatorID"
+
+// DenyImpersonation rejects requests made with impersonation tokens. It guards
+// actions an admin acting as a user must not take on their behalf, such as
+// changing their password. It must run after AuthMiddleware.
+func DenyImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString(ImpersonatorKey) != "" {
+			c.JSON(http.StatusForbidden, constants.ErrImpersonationForbidden())
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
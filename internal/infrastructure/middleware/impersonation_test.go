@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"web-server/internal/domain/constants"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenManager_ImpersonationToken(t *testing.T) {
+	manager, _ := newTestTokenManager()
+
+	token, expiresIn, err := manager.GenerateImpersonationToken("user-1", "user", "admin-1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(15*60), expiresIn)
+
+	claims, err := manager.ValidateAccessToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", claims.UserID)
+	assert.Equal(t, "user", claims.Role)
+	require.NotNil(t, claims.Act)
+	assert.Equal(t, "admin-1", claims.Act.Subject)
+	assert.Empty(t, claims.SessionID)
+
+	// Logging the admin out everywhere ends their impersonations
+	require.NoError(t, manager.LogoutAll("admin-1"))
+	_, err = manager.ValidateAccessToken(token)
+	assert.ErrorIs(t, err, constants.ErrAccessTokenRevoked)
+}
+
+func TestAuthMiddleware_Impersonation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	manager, _ := newTestTokenManager()
+
+	router := gin.New()
+	router.Use(AuthMiddleware(manager, nil))
+	router.GET("/whoami", func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("userID")+" "+c.GetString(ImpersonatorKey))
+	})
+	router.POST("/password", DenyImpersonation(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	request := func(method, path, token string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	impersonation, _, err := manager.GenerateImpersonationToken("user-1", "user", "admin-1")
+	require.NoError(t, err)
+	own, err := manager.GenerateTokenPair("user-1", "user")
+	require.NoError(t, err)
+
+	t.Run("Exposes both identities", func(t *testing.T) {
+		w := request("GET", "/whoami", impersonation)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "user-1 admin-1", w.Body.String())
+
+		w = request("GET", "/whoami", own.AccessToken)
+		assert.Equal(t, "user-1 ", w.Body.String())
+	})
+
+	t.Run("Denies guarded actions", func(t *testing.T) {
+		w := request("POST", "/password", impersonation)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "IMPERSONATION_FORBIDDEN")
+
+		w = request("POST", "/password", own.AccessToken)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
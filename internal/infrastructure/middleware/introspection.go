@@ -0,0 +1,199 @@
+package middleware
+
+import (
+	"errors"
+	"slices"
+	"time"
+
+	"web-server/internal/domain/constants"
+	"web-server/internal/domain/entity"
+)
+
+// Token type hints of RFC 7009 and RFC 7662, also used as the token_type of
+// introspection responses.
+const (
+	TokenTypeHintAccess  = "access_token"
+	TokenTypeHintRefresh = "refresh_token"
+)
+
+// TokenIntrospection describes a token as in RFC 7662. Tokens that are
+// invalid, expired or revoked only report Active as false.
+type TokenIntrospection struct {
+	Active          bool        `json:"active"`
+	Subject         string      `json:"sub,omitempty"`
+	Role            string      `json:"role,omitempty"`
+	TokenType       string      `json:"token_type,omitempty"` // access_token or refresh_token
+	ExpiresAt       int64       `json:"exp,omitempty"`
+	IssuedAt        int64       `json:"iat,omitempty"`
+	ID              string      `json:"jti,omitempty"`
+	SessionID       string      `json:"sid,omitempty"`
+	ClientID        string      `json:"client_id,omitempty"`        // Set on client tokens
+	Audience        []string    `json:"aud,omitempty"`              // Clients the token is meant for, besides ClientID
+	Scope           string      `json:"scope,omitempty"`            // Set on client tokens
+	EmailUnverified bool        `json:"email_unverified,omitempty"` // See VerifiedEmailMiddleware
+	Act             *ActorClaim `json:"act,omitempty"`              // Set on impersonation tokens
+}
+
+// Introspect reports to an OAuth client whether a token it may see is active
+// and describes it, see visibleTo. Other tokens are reported as inactive, like
+// unknown ones. The hint only decides which token type is tried first. Errors
+// are only returned if the token state could not be looked up.
+func (m *TokenManager) Introspect(tokenString, hint string, client *entity.OAuthClient) (*TokenIntrospection, error) {
+	introspection, err := m.introspect(tokenString, hint)
+	if err != nil || !introspection.Active {
+		return introspection, err
+	}
+
+	if !introspection.visibleTo(client) {
+		return &TokenIntrospection{Active: false}, nil
+	}
+	return introspection, nil
+}
+
+// visibleTo reports whether the OAuth client may introspect and revoke the
+// token. Client tokens are only visible to the client they were issued to.
+// User tokens are visible to clients with the introspect scope and to the
+// clients named as their audience.
+func (t *TokenIntrospection) visibleTo(client *entity.OAuthClient) bool {
+	if client == nil {
+		return false
+	}
+	if t.ClientID != "" {
+		return t.ClientID == client.ID
+	}
+	return client.HasScope(entity.ScopeIntrospect) || slices.Contains(t.Audience, client.ID)
+}
+
+// introspect describes any token issued by this service, see Introspect.
+func (m *TokenManager) introspect(tokenString, hint string) (*TokenIntrospection, error) {
+	lookups := []func(string) (*TokenIntrospection, error){m.introspectAccessToken, m.introspectRefreshToken}
+	if hint == TokenTypeHintRefresh {
+		lookups[0], lookups[1] = lookups[1], lookups[0]
+	}
+
+	for _, lookup := range lookups {
+		introspection, err := lookup(tokenString)
+		if err != nil || introspection != nil {
+			return introspection, err
+		}
+	}
+
+	return &TokenIntrospection{Active: false}, nil
+}
+
+// introspectAccessToken returns nil if the token is not a valid access token.
+func (m *TokenManager) introspectAccessToken(tokenString string) (*TokenIntrospection, error) {
+	claims, err := m.ValidateAccessToken(tokenString)
+	if errors.Is(err, constants.ErrInvalidAccessToken) || errors.Is(err, constants.ErrInvalidTokenType) {
+		return nil, nil
+	}
+	if errors.Is(err, constants.ErrAccessTokenRevoked) {
+		return &TokenIntrospection{Active: false}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	subject := claims.UserID
+	if claims.ClientID != "" {
+		subject = claims.Subject
+	}
+
+	return &TokenIntrospection{
+		Active:          true,
+		Subject:         subject,
+		Role:            claims.Role,
+		TokenType:       TokenTypeHintAccess,
+		ExpiresAt:       claims.ExpiresAt.Unix(),
+		IssuedAt:        claims.IssuedAt.Unix(),
+		ID:              claims.ID,
+		SessionID:       claims.SessionID,
+		ClientID:        claims.ClientID,
+		Audience:        claims.Audience,
+		Scope:           claims.Scope,
+		EmailUnverified: claims.EmailUnverified,
+		Act:             claims.Act,
+	}, nil
+}
+
+// introspectRefreshToken returns nil if the token is not a valid refresh token.
+// Refresh tokens stop being active once they have been used.
+func (m *TokenManager) introspectRefreshToken(tokenString string) (*TokenIntrospection, error) {
+	claims, err := parseToken(tokenString, refreshKeyfunc)
+	if err != nil || claims.TokenType != "refresh" {
+		return nil, nil
+	}
+
+	stored, err := m.refreshTokens.GetByID(claims.ID)
+	if errors.Is(err, constants.ErrRefreshTokenNotFound) {
+		return &TokenIntrospection{Active: false}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if stored.IsUsed() || stored.IsRevoked() || stored.IsExpired(time.Now()) {
+		return &TokenIntrospection{Active: false}, nil
+	}
+
+	return &TokenIntrospection{
+		Active:          true,
+		Subject:         claims.UserID,
+		Role:            claims.Role,
+		TokenType:       TokenTypeHintRefresh,
+		ExpiresAt:       claims.ExpiresAt.Unix(),
+		IssuedAt:        claims.IssuedAt.Unix(),
+		ID:              claims.ID,
+		SessionID:       claims.FamilyID,
+		EmailUnverified: claims.EmailUnverified,
+	}, nil
+}
+
+// RevokeToken revokes a token for an OAuth client that may see it, as in
+// RFC 7009 and like Introspect. Other tokens are ignored, as are tokens that
+// are invalid, no longer active or were not issued by this service.
+func (m *TokenManager) RevokeToken(tokenString, hint string, client *entity.OAuthClient) error {
+	introspection, err := m.Introspect(tokenString, hint, client)
+	if err != nil || !introspection.Active {
+		return err
+	}
+
+	return m.revoke(tokenString, hint)
+}
+
+// revoke revokes any token issued by this service. Revoking a refresh token
+// ends its whole session, including the access tokens issued to it.
+func (m *TokenManager) revoke(tokenString, hint string) error {
+	revocations := []func(string) (bool, error){m.revokeAccessToken, m.revokeRefreshToken}
+	if hint == TokenTypeHintRefresh {
+		revocations[0], revocations[1] = revocations[1], revocations[0]
+	}
+
+	for _, revoke := range revocations {
+		if revoked, err := revoke(tokenString); err != nil || revoked {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// revokeAccessToken reports false if the token is not an access token.
+func (m *TokenManager) revokeAccessToken(tokenString string) (bool, error) {
+	claims, err := parseToken(tokenString, m.keys.Keyfunc)
+	if err != nil || claims.TokenType != "access" {
+		return false, nil
+	}
+
+	return true, m.revocations.RevokeToken(claims.ID, claims.ExpiresAt.Time)
+}
+
+// revokeRefreshToken reports false if the token is not a refresh token.
+func (m *TokenManager) revokeRefreshToken(tokenString string) (bool, error) {
+	claims, err := parseToken(tokenString, refreshKeyfunc)
+	if err != nil || claims.TokenType != "refresh" || claims.FamilyID == "" {
+		return false, nil
+	}
+
+	return true, m.revokeFamily(claims.FamilyID, time.Now())
+}
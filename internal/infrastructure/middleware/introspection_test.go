@@ -0,0 +1,169 @@
+package middleware
+
+import (
+	"testing"
+
+	"web-server/internal/domain/constants"
+	"web-server/internal/domain/entity"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenManager_IntrospectAnyToken(t *testing.T) {
+	manager, _ := newTestTokenManager()
+
+	tokens, err := manager.GenerateTokenPair("user-1", "admin")
+	require.NoError(t, err)
+
+	access, err := manager.introspect(tokens.AccessToken, "")
+	require.NoError(t, err)
+	assert.True(t, access.Active)
+	assert.Equal(t, "user-1", access.Subject)
+	assert.Equal(t, "admin", access.Role)
+	assert.Equal(t, TokenTypeHintAccess, access.TokenType)
+	assert.NotZero(t, access.ExpiresAt)
+	assert.NotZero(t, access.IssuedAt)
+
+	// The hint only changes the order the token types are tried in
+	refresh, err := manager.introspect(tokens.RefreshToken, TokenTypeHintAccess)
+	require.NoError(t, err)
+	assert.True(t, refresh.Active)
+	assert.Equal(t, TokenTypeHintRefresh, refresh.TokenType)
+	assert.Equal(t, access.SessionID, refresh.SessionID)
+
+	invalid, err := manager.introspect("garbage", "")
+	require.NoError(t, err)
+	assert.Equal(t, &TokenIntrospection{Active: false}, invalid)
+
+	// Used refresh tokens are no longer active
+	_, err = manager.RefreshToken(tokens.RefreshToken)
+	require.NoError(t, err)
+	refresh, err = manager.introspect(tokens.RefreshToken, TokenTypeHintRefresh)
+	require.NoError(t, err)
+	assert.False(t, refresh.Active)
+	assert.Empty(t, refresh.Subject)
+}
+
+func TestTokenManager_RevokeAnyToken(t *testing.T) {
+	manager, _ := newTestTokenManager()
+
+	t.Run("Access token", func(t *testing.T) {
+		tokens, err := manager.GenerateTokenPair("user-1", "user")
+		require.NoError(t, err)
+
+		require.NoError(t, manager.revoke(tokens.AccessToken, ""))
+
+		introspection, err := manager.introspect(tokens.AccessToken, "")
+		require.NoError(t, err)
+		assert.False(t, introspection.Active)
+
+		// The session and its refresh token are kept
+		_, err = manager.RefreshToken(tokens.RefreshToken)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Refresh token ends the session", func(t *testing.T) {
+		tokens, err := manager.GenerateTokenPair("user-1", "user")
+		require.NoError(t, err)
+
+		require.NoError(t, manager.revoke(tokens.RefreshToken, TokenTypeHintRefresh))
+
+		introspection, err := manager.introspect(tokens.AccessToken, "")
+		require.NoError(t, err)
+		assert.False(t, introspection.Active)
+
+		_, err = manager.RefreshToken(tokens.RefreshToken)
+		assert.Error(t, err)
+	})
+
+	t.Run("Invalid token", func(t *testing.T) {
+		assert.NoError(t, manager.revoke("garbage", ""))
+	})
+}
+
+func TestTokenManager_IntrospectForClient(t *testing.T) {
+	manager, _ := newTestTokenManager()
+	registerTestClients(t, manager, "c-1", "c-2")
+	client := &entity.OAuthClient{ID: "c-1"}
+	resourceServer := &entity.OAuthClient{ID: "c-2", Scopes: []string{entity.ScopeIntrospect}}
+
+	own, _, err := manager.GenerateClientToken("client:c-1", "c-1", []string{"users:read"})
+	require.NoError(t, err)
+	other, _, err := manager.GenerateClientToken("client:c-2", "c-2", []string{"users:read"})
+	require.NoError(t, err)
+	tokens, err := manager.GenerateTokenPair("user-1", "admin")
+	require.NoError(t, err)
+
+	introspection, err := manager.Introspect(own, "", client)
+	require.NoError(t, err)
+	assert.True(t, introspection.Active)
+	assert.Equal(t, "c-1", introspection.ClientID)
+
+	// Without the introspect scope, tokens of other clients and of users
+	// look like unknown ones
+	for _, token := range []string{other, tokens.AccessToken, tokens.RefreshToken} {
+		introspection, err := manager.Introspect(token, "", client)
+		require.NoError(t, err)
+		assert.Equal(t, &TokenIntrospection{Active: false}, introspection)
+	}
+
+	t.Run("Introspect scope reveals user tokens", func(t *testing.T) {
+		access, err := manager.Introspect(tokens.AccessToken, "", resourceServer)
+		require.NoError(t, err)
+		assert.True(t, access.Active)
+		assert.Equal(t, "user-1", access.Subject)
+		assert.Equal(t, "admin", access.Role)
+		assert.Equal(t, TokenTypeHintAccess, access.TokenType)
+		assert.NotZero(t, access.ExpiresAt)
+		assert.NotZero(t, access.IssuedAt)
+		assert.Empty(t, access.ClientID)
+
+		refresh, err := manager.Introspect(tokens.RefreshToken, TokenTypeHintRefresh, resourceServer)
+		require.NoError(t, err)
+		assert.True(t, refresh.Active)
+		assert.Equal(t, TokenTypeHintRefresh, refresh.TokenType)
+
+		// Client tokens stay private to their client
+		introspection, err := manager.Introspect(own, "", resourceServer)
+		require.NoError(t, err)
+		assert.Equal(t, &TokenIntrospection{Active: false}, introspection)
+	})
+}
+
+func TestTokenManager_RevokeTokenForClient(t *testing.T) {
+	manager, _ := newTestTokenManager()
+	registerTestClients(t, manager, "c-1", "c-2")
+	client := &entity.OAuthClient{ID: "c-1"}
+	resourceServer := &entity.OAuthClient{ID: "c-2", Scopes: []string{entity.ScopeIntrospect}}
+
+	own, _, err := manager.GenerateClientToken("client:c-1", "c-1", []string{"users:read"})
+	require.NoError(t, err)
+	other, _, err := manager.GenerateClientToken("client:c-2", "c-2", []string{"users:read"})
+	require.NoError(t, err)
+	tokens, err := manager.GenerateTokenPair("user-1", "user")
+	require.NoError(t, err)
+
+	// Revoking tokens the client may not see succeeds without revoking them
+	for _, token := range []string{other, tokens.AccessToken, tokens.RefreshToken} {
+		require.NoError(t, manager.RevokeToken(token, "", client))
+	}
+	require.NoError(t, manager.RevokeToken(own, "", resourceServer))
+	_, err = manager.ValidateAccessToken(other)
+	assert.NoError(t, err)
+	_, err = manager.ValidateAccessToken(own)
+	assert.NoError(t, err)
+	_, err = manager.ValidateAccessToken(tokens.AccessToken)
+	assert.NoError(t, err)
+
+	require.NoError(t, manager.RevokeToken(own, "", client))
+	_, err = manager.ValidateAccessToken(own)
+	assert.ErrorIs(t, err, constants.ErrAccessTokenRevoked)
+
+	// The introspect scope allows revoking user sessions
+	require.NoError(t, manager.RevokeToken(tokens.RefreshToken, TokenTypeHintRefresh, resourceServer))
+	_, err = manager.ValidateAccessToken(tokens.AccessToken)
+	assert.ErrorIs(t, err, constants.ErrAccessTokenRevoked)
+	_, err = manager.RefreshToken(tokens.RefreshToken)
+	assert.Error(t, err)
+}
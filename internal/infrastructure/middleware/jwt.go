@@ -1,59 +1,173 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 	"time"
 
 	"web-server/internal/domain/constants"
+	"web-server/internal/domain/entity"
+	"web-server/internal/domain/repository"
 	"web-server/internal/infrastructure/config"
 
 	"github.com/gin-gonic/gin"
 	jwt "github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 type JWTClaims struct {
 	UserID    string `json:"user_id"`
 	Role      string `json:"role"`
 	TokenType string `json:"token_type"`
+	FamilyID  string `json:"family_id,omitempty"` // Refresh token family, only set on refresh tokens
+	SessionID string `json:"sid,omitempty"`       // Session of the refresh token family, only set on access tokens
+	Email     string `json:"email,omitempty"`     // Address an email verification token was sent to
+
+	// EmailUnverified limits the token to the endpoints that remain available
+	// before the user has verified their email, see VerifiedEmailMiddleware.
+	EmailUnverified bool `json:"email_unverified,omitempty"`
+
+	// Act names the admin acting as the user on impersonation tokens.
+	Act *ActorClaim `json:"act,omitempty"`
+
+	// ClientID and Scope are set on the access tokens of OAuth clients, which
+	// have no user. The scope is a space separated list of permissions.
+	ClientID string `json:"client_id,omitempty"`
+	Scope    string `json:"scope,omitempty"`
+
 	*jwt.RegisteredClaims
 }
 
+// ActorClaim is the actor claim of RFC 8693: the party acting on behalf of the
+// subject of the token.
+type ActorClaim struct {
+	Subject string `json:"sub"`
+}
+
+// TokenOption adjusts a token pair issued for a new login. Options on the
+// claims are carried over to the pairs issued when the refresh token is used.
+type TokenOption func(login *tokenLogin)
+
+// tokenLogin is the token pair and session a login is about to be issued.
+type tokenLogin struct {
+	claims  JWTClaims
+	session entity.Session
+}
+
+// WithEmailUnverified issues tokens restricted to the endpoints that do not
+// require a verified email.
+func WithEmailUnverified() TokenOption {
+	return func(login *tokenLogin) {
+		login.claims.EmailUnverified = true
+	}
+}
+
+// WithClient records the client that logged in on the session.
+func WithClient(userAgent, ip string) TokenOption {
+	return func(login *tokenLogin) {
+		login.session.UserAgent = userAgent
+		login.session.IP = ip
+	}
+}
+
 type TokenPair struct {
-	AccessToken  string `json:"access_token"`
-	RefreshToken string `json:"refresh_token"`
-	ExpiresIn    int64  `json:"expires_in"` // Access token expiration in seconds
+	AccessToken  string `json:"access_token,omitempty"`  // Left out in the cookie session mode
+	RefreshToken string `json:"refresh_token,omitempty"` // Left out in the cookie session mode
+	ExpiresIn    int64  `json:"expires_in"`              // Access token expiration in seconds
+	CSRFToken    string `json:"csrf_token,omitempty"`    // Only set in the cookie session mode
+	SessionID    string `json:"-"`
+}
+
+// TokenManager issues token pairs, rotates refresh tokens and revokes access
+// tokens. Every refresh token is recorded in the repository so that it can be
+// used only once, and every refresh token family is tracked as a session.
+// Access tokens of OAuth clients are only valid while their client is, and
+// refresh tokens only while their user exists and is enabled.
+type TokenManager struct {
+	keys          *KeyRing
+	refreshTokens repository.RefreshTokenRepository
+	revocations   repository.TokenRevocationRepository
+	sessions      repository.SessionRepository
+	clients       repository.OAuthClientRepository
+	users         repository.UserRepository
 }
 
-func GenerateTokenPair(userID, role string) (*TokenPair, error) {
+func NewTokenManager(
+	keys *KeyRing,
+	refreshTokens repository.RefreshTokenRepository,
+	revocations repository.TokenRevocationRepository,
+	sessions repository.SessionRepository,
+	clients repository.OAuthClientRepository,
+	users repository.UserRepository,
+) *TokenManager {
+	return &TokenManager{
+		keys:          keys,
+		refreshTokens: refreshTokens,
+		revocations:   revocations,
+		sessions:      sessions,
+		clients:       clients,
+		users:         users,
+	}
+}
+
+// GenerateTokenPair issues a token pair whose refresh token starts a new family
+// and session.
+func (m *TokenManager) GenerateTokenPair(userID, role string, opts ...TokenOption) (*TokenPair, error) {
+	now := time.Now()
+	login := tokenLogin{
+		claims: JWTClaims{
+			UserID: userID,
+			Role:   role,
+		},
+		session: entity.Session{
+			ID:              uuid.New().String(),
+			UserID:          userID,
+			CreatedAt:       now,
+			LastRefreshedAt: now,
+			ExpiresAt:       now.Add(config.GetConfig().JWTRefreshExpiration),
+		},
+	}
+	for _, opt := range opts {
+		opt(&login)
+	}
+
+	if err := m.sessions.Create(&login.session); err != nil {
+		return nil, err
+	}
+
+	return m.generateTokenPair(login.claims, login.session.ID)
+}
+
+// generateTokenPair issues a token pair in the given family. The subject
+// claims are copied into both tokens.
+func (m *TokenManager) generateTokenPair(subject JWTClaims, familyID string) (*TokenPair, error) {
 	cfg := config.GetConfig()
+	now := time.Now()
 
 	// Generate access token
-	accessClaims := JWTClaims{
-		UserID:    userID,
-		Role:      role,
-		TokenType: "access",
-		RegisteredClaims: &jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(cfg.JWTExpiration)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-		},
+	accessClaims := subject
+	accessClaims.TokenType = "access"
+	accessClaims.SessionID = familyID
+	accessClaims.RegisteredClaims = &jwt.RegisteredClaims{
+		ID:        uuid.New().String(),
+		ExpiresAt: jwt.NewNumericDate(now.Add(cfg.JWTExpiration)),
+		IssuedAt:  jwt.NewNumericDate(now),
 	}
 
-	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims)
-	accessTokenString, err := accessToken.SignedString(cfg.JWTSecret)
+	accessTokenString, err := m.keys.Sign(accessClaims)
 	if err != nil {
 		return nil, err
 	}
 
 	// Generate refresh token
-	refreshClaims := JWTClaims{
-		UserID:    userID,
-		Role:      role,
-		TokenType: "refresh",
-		RegisteredClaims: &jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(cfg.JWTRefreshExpiration)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-		},
+	refreshClaims := subject
+	refreshClaims.TokenType = "refresh"
+	refreshClaims.FamilyID = familyID
+	refreshClaims.RegisteredClaims = &jwt.RegisteredClaims{
+		ID:        uuid.New().String(),
+		ExpiresAt: jwt.NewNumericDate(now.Add(cfg.JWTRefreshExpiration)),
+		IssuedAt:  jwt.NewNumericDate(now),
 	}
 
 	refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims)
@@ -62,26 +176,32 @@ func GenerateTokenPair(userID, role string) (*TokenPair, error) {
 		return nil, err
 	}
 
+	// Record the refresh token so it can be rotated exactly once
+	if err := m.refreshTokens.Create(&entity.RefreshToken{
+		ID:        refreshClaims.ID,
+		FamilyID:  familyID,
+		UserID:    subject.UserID,
+		ExpiresAt: refreshClaims.ExpiresAt.Time,
+		CreatedAt: now,
+	}); err != nil {
+		return nil, err
+	}
+
 	return &TokenPair{
 		AccessToken:  accessTokenString,
 		RefreshToken: refreshTokenString,
 		ExpiresIn:    int64(cfg.JWTExpiration.Seconds()),
+		SessionID:    familyID,
 	}, nil
 }
 
-func RefreshToken(refreshTokenString string) (*TokenPair, error) {
-	cfg := config.GetConfig()
-	claims := &JWTClaims{}
-
-	// Parse and validate refresh token
-	token, err := jwt.ParseWithClaims(refreshTokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, constants.ErrUnexpectedSigningMethod
-		}
-		return cfg.JWTRefreshSecret, nil
-	})
-
-	if err != nil || !token.Valid {
+// RefreshToken exchanges a refresh token for a new token pair in the same family
+// and marks the presented token as used. Presenting a used token again revokes
+// the whole family, since it means the token has been copied. The family is
+// also revoked once its user has been deleted or disabled.
+func (m *TokenManager) RefreshToken(refreshTokenString string) (*TokenPair, error) {
+	claims, err := parseToken(refreshTokenString, refreshKeyfunc)
+	if err != nil {
 		return nil, constants.ErrInvalidRefreshToken
 	}
 
@@ -89,81 +209,515 @@ func RefreshToken(refreshTokenString string) (*TokenPair, error) {
 		return nil, constants.ErrInvalidTokenType
 	}
 
+	stored, err := m.refreshTokens.GetByID(claims.ID)
+	if errors.Is(err, constants.ErrRefreshTokenNotFound) {
+		return nil, constants.ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if stored.IsRevoked() {
+		return nil, constants.ErrRefreshTokenRevoked
+	}
+
+	now := time.Now()
+	user, err := m.users.GetByID(stored.UserID)
+	if errors.Is(err, constants.ErrUserNotFound) || (err == nil && user.Disabled) {
+		if revokeErr := m.revokeFamily(stored.FamilyID, now); revokeErr != nil {
+			return nil, revokeErr
+		}
+		return nil, constants.ErrRefreshTokenRevoked
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.refreshTokens.MarkUsed(stored.ID, now); err != nil {
+		if errors.Is(err, constants.ErrRefreshTokenReused) {
+			if revokeErr := m.revokeFamily(stored.FamilyID, now); revokeErr != nil {
+				return nil, revokeErr
+			}
+		}
+		return nil, err
+	}
+
+	if err := m.sessions.Touch(stored.FamilyID, now, now.Add(config.GetConfig().JWTRefreshExpiration)); err != nil {
+		return nil, err
+	}
+
 	// Generate new token pair
-	return GenerateTokenPair(claims.UserID, claims.Role)
+	return m.generateTokenPair(JWTClaims{
+		UserID:          stored.UserID,
+		Role:            claims.Role,
+		EmailUnverified: claims.EmailUnverified,
+	}, stored.FamilyID)
+}
+
+// ValidateAccessToken verifies an access token and checks that it has been
+// revoked neither on its own nor by a logout of all the user's sessions, nor
+// for client tokens by revoking or deleting the client.
+func (m *TokenManager) ValidateAccessToken(tokenString string) (*JWTClaims, error) {
+	claims, err := parseToken(tokenString, m.keys.Keyfunc)
+	if err != nil {
+		return nil, constants.ErrInvalidAccessToken
+	}
+
+	if claims.TokenType != "access" {
+		return nil, constants.ErrInvalidTokenType
+	}
+
+	revoked, err := m.revocations.IsTokenRevoked(claims.ID)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, constants.ErrAccessTokenRevoked
+	}
+
+	if claims.IssuedAt == nil {
+		return nil, constants.ErrAccessTokenRevoked
+	}
+
+	// Client tokens have no user whose logout could end them, they end with
+	// their client instead
+	if claims.ClientID != "" {
+		client, err := m.clients.GetByID(claims.ClientID)
+		if errors.Is(err, constants.ErrOAuthClientNotFound) {
+			return nil, constants.ErrAccessTokenRevoked
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !client.IsActive() {
+			return nil, constants.ErrAccessTokenRevoked
+		}
+	} else {
+		validAfter, err := m.revocations.GetTokensValidAfter(claims.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if claims.IssuedAt.Before(validAfter) {
+			return nil, constants.ErrAccessTokenRevoked
+		}
+	}
+
+	// Impersonation tokens end when the admin is logged out everywhere
+	if claims.Act != nil {
+		actorValidAfter, err := m.revocations.GetTokensValidAfter(claims.Act.Subject)
+		if err != nil {
+			return nil, err
+		}
+		if claims.IssuedAt.Before(actorValidAfter) {
+			return nil, constants.ErrAccessTokenRevoked
+		}
+	}
+
+	// Access tokens issued before sessions were tracked and impersonation
+	// tokens carry no session
+	if claims.SessionID != "" {
+		session, err := m.sessions.GetByID(claims.SessionID)
+		if errors.Is(err, constants.ErrSessionNotFound) {
+			return nil, constants.ErrAccessTokenRevoked
+		}
+		if err != nil {
+			return nil, err
+		}
+		if session.IsRevoked() {
+			return nil, constants.ErrAccessTokenRevoked
+		}
+	}
+
+	return claims, nil
+}
+
+// Logout revokes the access token described by claims and the session it
+// belongs to. If a refresh token is given, its family is revoked as well.
+func (m *TokenManager) Logout(claims *JWTClaims, refreshTokenString string) error {
+	now := time.Now()
+
+	if err := m.revocations.RevokeToken(claims.ID, claims.ExpiresAt.Time); err != nil {
+		return err
+	}
+
+	if claims.SessionID != "" {
+		if err := m.revokeFamily(claims.SessionID, now); err != nil {
+			return err
+		}
+	}
+
+	if refreshTokenString == "" {
+		return nil
+	}
+
+	refreshClaims, err := parseToken(refreshTokenString, refreshKeyfunc)
+	if err != nil || refreshClaims.TokenType != "refresh" || refreshClaims.UserID != claims.UserID {
+		return constants.ErrInvalidRefreshToken
+	}
+
+	return m.revokeFamily(refreshClaims.FamilyID, now)
+}
+
+// LogoutAll revokes every access and refresh token issued to the user so far.
+func (m *TokenManager) LogoutAll(userID string) error {
+	now := time.Now()
+
+	if err := m.revocations.RevokeAllForUser(userID, now); err != nil {
+		return err
+	}
+
+	if err := m.sessions.RevokeByUser(userID, now); err != nil {
+		return err
+	}
+
+	return m.refreshTokens.RevokeByUser(userID, now)
+}
+
+// ListSessions returns the sessions of the user that have neither been logged
+// out nor expired, most recent first.
+func (m *TokenManager) ListSessions(userID string) ([]*entity.Session, error) {
+	sessions, err := m.sessions.ListByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	active := make([]*entity.Session, 0, len(sessions))
+	for _, session := range sessions {
+		if session.IsActive(now) {
+			active = append(active, session)
+		}
+	}
+
+	return active, nil
+}
+
+// RevokeSession logs out a single session of the user: its refresh tokens and
+// the access tokens issued to it stop working. Sessions of other users and
+// sessions that are no longer active are reported as not found.
+func (m *TokenManager) RevokeSession(userID, sessionID string) error {
+	session, err := m.sessions.GetByID(sessionID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if session.UserID != userID || !session.IsActive(now) {
+		return constants.ErrSessionNotFound
+	}
+
+	return m.revokeFamily(session.ID, now)
+}
+
+// revokeFamily revokes a refresh token family together with its session.
+func (m *TokenManager) revokeFamily(familyID string, now time.Time) error {
+	if err := m.sessions.Revoke(familyID, now); err != nil {
+		return err
+	}
+
+	return m.refreshTokens.RevokeFamily(familyID, now)
+}
+
+// GenerateImpersonationToken issues a short-lived access token that lets the
+// impersonator act as the user, with the user's role. It carries the
+// impersonator in the act claim and comes without refresh token or session,
+// so it cannot be extended. It returns the token and its lifetime in seconds.
+func (m *TokenManager) GenerateImpersonationToken(userID, role, impersonatorID string) (string, int64, error) {
+	cfg := config.GetConfig()
+	now := time.Now()
+
+	claims := JWTClaims{
+		UserID:    userID,
+		Role:      role,
+		TokenType: "access",
+		Act:       &ActorClaim{Subject: impersonatorID},
+		RegisteredClaims: &jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			ExpiresAt: jwt.NewNumericDate(now.Add(cfg.ImpersonationExpiration)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+
+	token, err := m.keys.Sign(claims)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return token, int64(cfg.ImpersonationExpiration.Seconds()), nil
+}
+
+// GenerateClientToken issues an access token to an OAuth client with the
+// client credentials grant. The token has the client as subject, no user and no
+// role, and only allows what its scopes allow. It comes without refresh token
+// or session. It returns the token and its lifetime in seconds.
+func (m *TokenManager) GenerateClientToken(subject, clientID string, scopes []string) (string, int64, error) {
+	cfg := config.GetConfig()
+	now := time.Now()
+
+	claims := JWTClaims{
+		TokenType: "access",
+		ClientID:  clientID,
+		Scope:     strings.Join(scopes, " "),
+		RegisteredClaims: &jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			Subject:   subject,
+			ExpiresAt: jwt.NewNumericDate(now.Add(cfg.JWTExpiration)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+
+	token, err := m.keys.Sign(claims)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return token, int64(cfg.JWTExpiration.Seconds()), nil
+}
+
+// GenerateMFAToken issues the short-lived challenge token that a client
+// exchanges, together with a second factor, for a token pair.
+func (m *TokenManager) GenerateMFAToken(userID string) (string, error) {
+	cfg := config.GetConfig()
+	now := time.Now()
+
+	claims := JWTClaims{
+		UserID:    userID,
+		TokenType: "mfa",
+		RegisteredClaims: &jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			ExpiresAt: jwt.NewNumericDate(now.Add(cfg.MFATokenExpiration)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(cfg.JWTSecret)
+}
+
+// ValidateMFAToken verifies a challenge token that has not been used up and
+// returns the user it was issued to.
+func (m *TokenManager) ValidateMFAToken(tokenString string) (string, error) {
+	claims, err := m.parseMFAToken(tokenString)
+	if err != nil {
+		return "", err
+	}
+
+	return claims.UserID, nil
+}
+
+// RevokeMFAToken uses up a challenge token, once it completed a login or its
+// user was locked out for too many wrong codes. Of concurrent calls for the
+// same token only one succeeds, the others get constants.ErrInvalidMFAToken.
+func (m *TokenManager) RevokeMFAToken(tokenString string) error {
+	claims, err := m.parseMFAToken(tokenString)
+	if err != nil {
+		return err
+	}
+
+	revoked, err := m.revocations.RevokeTokenOnce(claims.ID, claims.ExpiresAt.Time)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return constants.ErrInvalidMFAToken
+	}
+
+	return nil
+}
+
+func (m *TokenManager) parseMFAToken(tokenString string) (*JWTClaims, error) {
+	claims, err := parseToken(tokenString, secretKeyfunc)
+	if err != nil || claims.TokenType != "mfa" {
+		return nil, constants.ErrInvalidMFAToken
+	}
+
+	revoked, err := m.revocations.IsTokenRevoked(claims.ID)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, constants.ErrInvalidMFAToken
+	}
+
+	return claims, nil
 }
 
-func AuthMiddleware() gin.HandlerFunc {
+// GenerateEmailVerificationToken issues the token of an email verification
+// link. It is only valid for the address it was sent to.
+func (m *TokenManager) GenerateEmailVerificationToken(userID, email string) (string, error) {
+	cfg := config.GetConfig()
+	now := time.Now()
+
+	claims := JWTClaims{
+		UserID:    userID,
+		Email:     email,
+		TokenType: "email_verification",
+		RegisteredClaims: &jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			ExpiresAt: jwt.NewNumericDate(now.Add(cfg.EmailVerificationExpiration)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(cfg.JWTSecret)
+}
+
+// ValidateEmailVerificationToken verifies an email verification token and
+// returns the user and address it was issued for.
+func (m *TokenManager) ValidateEmailVerificationToken(tokenString string) (string, string, error) {
+	claims, err := parseToken(tokenString, secretKeyfunc)
+	if err != nil || claims.TokenType != "email_verification" {
+		return "", "", constants.ErrInvalidVerificationToken
+	}
+
+	return claims.UserID, claims.Email, nil
+}
+
+// GenerateLoginLinkToken issues the token of a passwordless login link. The
+// token ID is the stored link, which makes the token single-use.
+func (m *TokenManager) GenerateLoginLinkToken(userID, linkID string, expiresAt time.Time) (string, error) {
+	cfg := config.GetConfig()
+
+	claims := JWTClaims{
+		UserID:    userID,
+		TokenType: "magic_link",
+		RegisteredClaims: &jwt.RegisteredClaims{
+			ID:        linkID,
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(cfg.JWTSecret)
+}
+
+// ValidateLoginLinkToken verifies a login link token and returns the user and
+// the stored link it was issued for.
+func (m *TokenManager) ValidateLoginLinkToken(tokenString string) (string, string, error) {
+	claims, err := parseToken(tokenString, secretKeyfunc)
+	if err != nil || claims.TokenType != "magic_link" || claims.RegisteredClaims == nil || claims.ID == "" {
+		return "", "", constants.ErrInvalidLoginLink
+	}
+
+	return claims.UserID, claims.ID, nil
+}
+
+// refreshKeyfunc verifies refresh tokens, which never leave this service and
+// stay signed with the shared refresh secret.
+func refreshKeyfunc(token *jwt.Token) (interface{}, error) {
+	return hmacKey(token, config.GetConfig().JWTRefreshSecret)
+}
+
+// secretKeyfunc verifies the MFA challenge, email verification, login link and
+// OIDC state tokens, which are signed with the shared JWT secret.
+func secretKeyfunc(token *jwt.Token) (interface{}, error) {
+	return hmacKey(token, config.GetConfig().JWTSecret)
+}
+
+func hmacKey(token *jwt.Token, secret []byte) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		return nil, constants.ErrUnexpectedSigningMethod
+	}
+	return secret, nil
+}
+
+// parseToken verifies a token with the given key function and returns its claims.
+func parseToken(tokenString string, keyFunc jwt.Keyfunc) (*JWTClaims, error) {
+	claims := &JWTClaims{RegisteredClaims: &jwt.RegisteredClaims{}}
+
+	// ParseWithClaims returns an error for any token that is not valid
+	_, err := jwt.ParseWithClaims(tokenString, claims, keyFunc)
+	if err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// AuthMiddleware authenticates requests with a bearer access token, the access
+// token cookie of the cookie session mode or, when apiKeys is set, with an API
+// key in the X-API-Key header. A bearer token takes precedence over the cookie.
+func AuthMiddleware(tokens *TokenManager, apiKeys APIKeyAuthenticator) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, constants.ErrAuthHeaderRequired())
-			c.Abort()
+		if key := c.GetHeader(APIKeyHeader); key != "" && apiKeys != nil {
+			authenticateAPIKey(c, apiKeys, key)
 			return
 		}
 
-		bearerToken := strings.Split(authHeader, " ")
-		if len(bearerToken) != 2 || bearerToken[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, constants.ErrInvalidAuthFormat())
+		authHeader := c.GetHeader("Authorization")
+		cookieToken, _ := c.Cookie(AccessTokenCookie)
+		if authHeader == "" && cookieToken == "" {
+			c.JSON(http.StatusUnauthorized, constants.ErrAuthHeaderRequired())
 			c.Abort()
 			return
 		}
 
-		tokenString := bearerToken[1]
-		claims := &JWTClaims{}
-
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, constants.ErrUnexpectedSigningMethod
+		tokenString := cookieToken
+		if authHeader != "" {
+			bearerToken := strings.Split(authHeader, " ")
+			if len(bearerToken) != 2 || bearerToken[0] != "Bearer" {
+				c.JSON(http.StatusUnauthorized, constants.ErrInvalidAuthFormat())
+				c.Abort()
+				return
 			}
-			return config.GetConfig().JWTSecret, nil
-		})
+			tokenString = bearerToken[1]
+		}
 
-		if err != nil {
+		claims, err := tokens.ValidateAccessToken(tokenString)
+		switch {
+		case errors.Is(err, constants.ErrAccessTokenRevoked):
+			c.JSON(http.StatusUnauthorized, constants.ErrTokenRevoked())
+			c.Abort()
+			return
+		case errors.Is(err, constants.ErrInvalidAccessToken), errors.Is(err, constants.ErrInvalidTokenType):
 			c.JSON(http.StatusUnauthorized, constants.ErrInvalidToken())
 			c.Abort()
 			return
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, constants.ErrInternalServer())
+			c.Abort()
+			return
 		}
 
-		if !token.Valid || claims.TokenType != "access" {
-			c.JSON(http.StatusUnauthorized, constants.ErrInvalidToken())
-			c.Abort()
+		// Client tokens have no user or role, their subject stands in for the
+		// user in logs and audit entries
+		if claims.ClientID != "" {
+			c.Set("userID", claims.Subject)
+			c.Set(ClientIDKey, claims.ClientID)
+			c.Set(ScopesKey, strings.Fields(claims.Scope))
+			c.Set("claims", claims)
+			c.Next()
 			return
 		}
 
 		// Add claims to context
 		c.Set("userID", claims.UserID)
 		c.Set("role", claims.Role)
+		c.Set("claims", claims)
+		if claims.Act != nil {
+			c.Set(ImpersonatorKey, claims.Act.Subject)
+		}
 		c.Next()
 	}
 }
 
-func RoleMiddleware(allowedRoles ...string) gin.HandlerFunc {
+// VerifiedEmailMiddleware rejects tokens issued to users who have not verified
+// their email yet. It must run after AuthMiddleware.
+func VerifiedEmailMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		role, exists := c.Get("role")
-		if !exists {
-			c.JSON(http.StatusUnauthorized, constants.ErrRoleNotFound())
-			c.Abort()
-			return
-		}
-
-		roleStr, ok := role.(string)
+		claims, ok := c.Get("claims")
 		if !ok {
-			c.JSON(http.StatusInternalServerError, constants.ErrInternalServer())
+			// API keys can only be created by verified users
+			if _, ok := c.Get("apiKey"); ok {
+				c.Next()
+				return
+			}
+			c.JSON(http.StatusUnauthorized, constants.ErrInvalidToken())
 			c.Abort()
 			return
 		}
 
-		allowed := false
-		for _, r := range allowedRoles {
-			if r == roleStr {
-				allowed = true
-				break
-			}
-		}
-
-		if !allowed {
-			c.JSON(http.StatusForbidden, constants.ErrInsufficientPermissions())
+		if jwtClaims, ok := claims.(*JWTClaims); ok && jwtClaims.EmailUnverified {
+			c.JSON(http.StatusForbidden, constants.ErrEmailVerificationRequired())
 			c.Abort()
 			return
 		}
@@ -0,0 +1,466 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"web-server/internal/domain/constants"
+	"web-server/internal/domain/entity"
+	"web-server/internal/infrastructure/config"
+	"web-server/internal/infrastructure/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestTokenManager returns a token manager whose users "user-1" and
+// "user-2" exist.
+func newTestTokenManager() (*TokenManager, *repository.InMemoryRefreshTokenRepository) {
+	refreshTokens := repository.NewInMemoryRefreshTokenRepository()
+	users := repository.NewInMemoryUserRepository()
+	for _, id := range []string{"user-1", "user-2"} {
+		_ = users.Create(&entity.User{ID: id, Username: id, Email: id + "@example.com", Role: "user"})
+	}
+	manager := NewTokenManager(
+		NewHMACKeyRing(config.GetConfig().JWTSecret),
+		refreshTokens,
+		repository.NewInMemoryTokenRevocationRepository(),
+		repository.NewInMemorySessionRepository(),
+		repository.NewInMemoryOAuthClientRepository(),
+		users,
+	)
+	return manager, refreshTokens
+}
+
+func TestTokenManager_GenerateTokenPair(t *testing.T) {
+	manager, repo := newTestTokenManager()
+
+	tokens, err := manager.GenerateTokenPair("user-1", "user")
+	require.NoError(t, err)
+	assert.NotEmpty(t, tokens.AccessToken)
+	assert.NotEmpty(t, tokens.RefreshToken)
+
+	claims, err := parseToken(tokens.RefreshToken, refreshKeyfunc)
+	require.NoError(t, err)
+	assert.NotEmpty(t, claims.ID)
+	assert.NotEmpty(t, claims.FamilyID)
+
+	stored, err := repo.GetByID(claims.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", stored.UserID)
+	assert.Equal(t, claims.FamilyID, stored.FamilyID)
+	assert.False(t, stored.IsUsed())
+}
+
+func TestTokenManager_RefreshToken(t *testing.T) {
+	t.Run("Rotates refresh token within the family", func(t *testing.T) {
+		manager, repo := newTestTokenManager()
+
+		first, err := manager.GenerateTokenPair("user-1", "user")
+		require.NoError(t, err)
+
+		second, err := manager.RefreshToken(first.RefreshToken)
+		require.NoError(t, err)
+		assert.NotEqual(t, first.RefreshToken, second.RefreshToken)
+
+		firstClaims, err := parseToken(first.RefreshToken, refreshKeyfunc)
+		require.NoError(t, err)
+		secondClaims, err := parseToken(second.RefreshToken, refreshKeyfunc)
+		require.NoError(t, err)
+		assert.Equal(t, firstClaims.FamilyID, secondClaims.FamilyID)
+
+		used, err := repo.GetByID(firstClaims.ID)
+		require.NoError(t, err)
+		assert.True(t, used.IsUsed())
+	})
+
+	t.Run("Reuse revokes the whole family", func(t *testing.T) {
+		manager, _ := newTestTokenManager()
+
+		first, err := manager.GenerateTokenPair("user-1", "user")
+		require.NoError(t, err)
+		second, err := manager.RefreshToken(first.RefreshToken)
+		require.NoError(t, err)
+
+		_, err = manager.RefreshToken(first.RefreshToken)
+		assert.ErrorIs(t, err, constants.ErrRefreshTokenReused)
+
+		// The legitimate successor is revoked along with the family
+		_, err = manager.RefreshToken(second.RefreshToken)
+		assert.ErrorIs(t, err, constants.ErrRefreshTokenRevoked)
+	})
+
+	t.Run("Other families are not affected by reuse", func(t *testing.T) {
+		manager, _ := newTestTokenManager()
+
+		stolen, err := manager.GenerateTokenPair("user-1", "user")
+		require.NoError(t, err)
+		other, err := manager.GenerateTokenPair("user-1", "user")
+		require.NoError(t, err)
+
+		_, err = manager.RefreshToken(stolen.RefreshToken)
+		require.NoError(t, err)
+		_, err = manager.RefreshToken(stolen.RefreshToken)
+		assert.ErrorIs(t, err, constants.ErrRefreshTokenReused)
+
+		_, err = manager.RefreshToken(other.RefreshToken)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Rejects unknown refresh token", func(t *testing.T) {
+		manager, _ := newTestTokenManager()
+		tokens, err := manager.GenerateTokenPair("user-1", "user")
+		require.NoError(t, err)
+
+		// A different manager has no record of the token
+		other, _ := newTestTokenManager()
+		_, err = other.RefreshToken(tokens.RefreshToken)
+		assert.ErrorIs(t, err, constants.ErrInvalidRefreshToken)
+	})
+
+	t.Run("Rejects access token", func(t *testing.T) {
+		manager, _ := newTestTokenManager()
+		tokens, err := manager.GenerateTokenPair("user-1", "user")
+		require.NoError(t, err)
+
+		_, err = manager.RefreshToken(tokens.AccessToken)
+		assert.ErrorIs(t, err, constants.ErrInvalidRefreshToken)
+	})
+
+	t.Run("Rejects tokens of deleted users", func(t *testing.T) {
+		manager, _ := newTestTokenManager()
+		tokens, err := manager.GenerateTokenPair("user-1", "user")
+		require.NoError(t, err)
+
+		require.NoError(t, manager.users.Delete("user-1"))
+
+		_, err = manager.RefreshToken(tokens.RefreshToken)
+		assert.ErrorIs(t, err, constants.ErrRefreshTokenRevoked)
+
+		// The session ends with the refresh token
+		_, err = manager.ValidateAccessToken(tokens.AccessToken)
+		assert.ErrorIs(t, err, constants.ErrAccessTokenRevoked)
+	})
+
+	t.Run("Rejects tokens of disabled users", func(t *testing.T) {
+		manager, _ := newTestTokenManager()
+		tokens, err := manager.GenerateTokenPair("user-1", "user")
+		require.NoError(t, err)
+
+		user, err := manager.users.GetByID("user-1")
+		require.NoError(t, err)
+		disabled := *user
+		disabled.Disabled = true
+		require.NoError(t, manager.users.Update(&disabled))
+
+		_, err = manager.RefreshToken(tokens.RefreshToken)
+		assert.ErrorIs(t, err, constants.ErrRefreshTokenRevoked)
+
+		// Enabling the account again does not bring the family back
+		require.NoError(t, manager.users.Update(user))
+		_, err = manager.RefreshToken(tokens.RefreshToken)
+		assert.ErrorIs(t, err, constants.ErrRefreshTokenRevoked)
+	})
+}
+
+func TestTokenManager_Logout(t *testing.T) {
+	t.Run("Revokes access token and refresh family", func(t *testing.T) {
+		manager, _ := newTestTokenManager()
+		tokens, err := manager.GenerateTokenPair("user-1", "user")
+		require.NoError(t, err)
+
+		claims, err := manager.ValidateAccessToken(tokens.AccessToken)
+		require.NoError(t, err)
+
+		require.NoError(t, manager.Logout(claims, tokens.RefreshToken))
+
+		_, err = manager.ValidateAccessToken(tokens.AccessToken)
+		assert.ErrorIs(t, err, constants.ErrAccessTokenRevoked)
+
+		_, err = manager.RefreshToken(tokens.RefreshToken)
+		assert.ErrorIs(t, err, constants.ErrRefreshTokenRevoked)
+	})
+
+	t.Run("Rejects refresh token of another user", func(t *testing.T) {
+		manager, _ := newTestTokenManager()
+		mine, err := manager.GenerateTokenPair("user-1", "user")
+		require.NoError(t, err)
+		theirs, err := manager.GenerateTokenPair("user-2", "user")
+		require.NoError(t, err)
+
+		claims, err := manager.ValidateAccessToken(mine.AccessToken)
+		require.NoError(t, err)
+
+		err = manager.Logout(claims, theirs.RefreshToken)
+		assert.ErrorIs(t, err, constants.ErrInvalidRefreshToken)
+
+		_, err = manager.RefreshToken(theirs.RefreshToken)
+		assert.NoError(t, err)
+	})
+}
+
+func TestTokenManager_LogoutAll(t *testing.T) {
+	manager, _ := newTestTokenManager()
+	first, err := manager.GenerateTokenPair("user-1", "user")
+	require.NoError(t, err)
+	other, err := manager.GenerateTokenPair("user-2", "user")
+	require.NoError(t, err)
+
+	// Tokens carry second precision, so make sure the cutoff is strictly later
+	time.Sleep(time.Second)
+	require.NoError(t, manager.LogoutAll("user-1"))
+
+	_, err = manager.ValidateAccessToken(first.AccessToken)
+	assert.ErrorIs(t, err, constants.ErrAccessTokenRevoked)
+	_, err = manager.RefreshToken(first.RefreshToken)
+	assert.ErrorIs(t, err, constants.ErrRefreshTokenRevoked)
+
+	_, err = manager.ValidateAccessToken(other.AccessToken)
+	assert.NoError(t, err)
+}
+
+func TestTokenManager_Sessions(t *testing.T) {
+	t.Run("Login starts a session that refreshes update", func(t *testing.T) {
+		manager, _ := newTestTokenManager()
+		tokens, err := manager.GenerateTokenPair("user-1", "user", WithClient("curl/8.0", "192.0.2.1"))
+		require.NoError(t, err)
+
+		sessions, err := manager.ListSessions("user-1")
+		require.NoError(t, err)
+		require.Len(t, sessions, 1)
+		assert.Equal(t, "curl/8.0", sessions[0].UserAgent)
+		assert.Equal(t, "192.0.2.1", sessions[0].IP)
+
+		refreshClaims, err := parseToken(tokens.RefreshToken, refreshKeyfunc)
+		require.NoError(t, err)
+		assert.Equal(t, refreshClaims.FamilyID, sessions[0].ID)
+
+		time.Sleep(10 * time.Millisecond)
+		_, err = manager.RefreshToken(tokens.RefreshToken)
+		require.NoError(t, err)
+
+		refreshed, err := manager.ListSessions("user-1")
+		require.NoError(t, err)
+		require.Len(t, refreshed, 1)
+		assert.True(t, refreshed[0].LastRefreshedAt.After(sessions[0].LastRefreshedAt))
+	})
+
+	t.Run("Revoking a session revokes only its tokens", func(t *testing.T) {
+		manager, _ := newTestTokenManager()
+		laptop, err := manager.GenerateTokenPair("user-1", "user")
+		require.NoError(t, err)
+		phone, err := manager.GenerateTokenPair("user-1", "user")
+		require.NoError(t, err)
+
+		laptopClaims, err := manager.ValidateAccessToken(laptop.AccessToken)
+		require.NoError(t, err)
+
+		// Sessions of other users cannot be revoked
+		assert.ErrorIs(t, manager.RevokeSession("user-2", laptopClaims.SessionID), constants.ErrSessionNotFound)
+
+		require.NoError(t, manager.RevokeSession("user-1", laptopClaims.SessionID))
+
+		_, err = manager.ValidateAccessToken(laptop.AccessToken)
+		assert.ErrorIs(t, err, constants.ErrAccessTokenRevoked)
+		_, err = manager.RefreshToken(laptop.RefreshToken)
+		assert.ErrorIs(t, err, constants.ErrRefreshTokenRevoked)
+
+		_, err = manager.ValidateAccessToken(phone.AccessToken)
+		assert.NoError(t, err)
+
+		sessions, err := manager.ListSessions("user-1")
+		require.NoError(t, err)
+		assert.Len(t, sessions, 1)
+
+		// A revoked session is gone
+		assert.ErrorIs(t, manager.RevokeSession("user-1", laptopClaims.SessionID), constants.ErrSessionNotFound)
+	})
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	manager, _ := newTestTokenManager()
+
+	router := gin.New()
+	router.Use(AuthMiddleware(manager, nil))
+	router.GET("/test", func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("userID"))
+	})
+
+	request := func(authHeader string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/test", nil)
+		if authHeader != "" {
+			req.Header.Set("Authorization", authHeader)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	tokens, err := manager.GenerateTokenPair("user-1", "user")
+	require.NoError(t, err)
+
+	t.Run("Accepts valid access token", func(t *testing.T) {
+		w := request("Bearer " + tokens.AccessToken)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "user-1", w.Body.String())
+	})
+
+	t.Run("Rejects missing header", func(t *testing.T) {
+		w := request("")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("Rejects refresh token", func(t *testing.T) {
+		w := request("Bearer " + tokens.RefreshToken)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("Rejects revoked token", func(t *testing.T) {
+		claims, err := manager.ValidateAccessToken(tokens.AccessToken)
+		require.NoError(t, err)
+		require.NoError(t, manager.Logout(claims, ""))
+
+		w := request("Bearer " + tokens.AccessToken)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), "TOKEN_REVOKED")
+	})
+}
+
+func TestTokenManager_MFAToken(t *testing.T) {
+	manager, _ := newTestTokenManager()
+
+	mfaToken, err := manager.GenerateMFAToken("user-1")
+	require.NoError(t, err)
+
+	userID, err := manager.ValidateMFAToken(mfaToken)
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", userID)
+
+	// A challenge token is not an access token and vice versa
+	_, err = manager.ValidateAccessToken(mfaToken)
+	assert.Error(t, err)
+
+	tokens, err := manager.GenerateTokenPair("user-1", "user")
+	require.NoError(t, err)
+	_, err = manager.ValidateMFAToken(tokens.AccessToken)
+	assert.ErrorIs(t, err, constants.ErrInvalidMFAToken)
+
+	// A challenge token is used up once revoked, other challenges stay valid
+	other, err := manager.GenerateMFAToken("user-1")
+	require.NoError(t, err)
+	require.NoError(t, manager.RevokeMFAToken(mfaToken))
+	_, err = manager.ValidateMFAToken(mfaToken)
+	assert.ErrorIs(t, err, constants.ErrInvalidMFAToken)
+	assert.ErrorIs(t, manager.RevokeMFAToken(mfaToken), constants.ErrInvalidMFAToken)
+	_, err = manager.ValidateMFAToken(other)
+	assert.NoError(t, err)
+
+	t.Run("Concurrent redemptions use up the token once", func(t *testing.T) {
+		mfaToken, err := manager.GenerateMFAToken("user-1")
+		require.NoError(t, err)
+
+		var (
+			wg       sync.WaitGroup
+			redeemed atomic.Int32
+		)
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := manager.RevokeMFAToken(mfaToken); err == nil {
+					redeemed.Add(1)
+				} else {
+					assert.ErrorIs(t, err, constants.ErrInvalidMFAToken)
+				}
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, int32(1), redeemed.Load())
+	})
+}
+
+func TestTokenManager_EmailVerificationToken(t *testing.T) {
+	manager, _ := newTestTokenManager()
+
+	token, err := manager.GenerateEmailVerificationToken("user-1", "user@example.com")
+	require.NoError(t, err)
+
+	userID, email, err := manager.ValidateEmailVerificationToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", userID)
+	assert.Equal(t, "user@example.com", email)
+
+	// Other tokens signed with the same secret are not verification tokens
+	mfaToken, err := manager.GenerateMFAToken("user-1")
+	require.NoError(t, err)
+	_, _, err = manager.ValidateEmailVerificationToken(mfaToken)
+	assert.ErrorIs(t, err, constants.ErrInvalidVerificationToken)
+}
+
+func TestTokenManager_LoginLinkToken(t *testing.T) {
+	manager, _ := newTestTokenManager()
+
+	token, err := manager.GenerateLoginLinkToken("user-1", "link-1", time.Now().Add(time.Minute))
+	require.NoError(t, err)
+
+	userID, linkID, err := manager.ValidateLoginLinkToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", userID)
+	assert.Equal(t, "link-1", linkID)
+
+	expired, err := manager.GenerateLoginLinkToken("user-1", "link-2", time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+	_, _, err = manager.ValidateLoginLinkToken(expired)
+	assert.ErrorIs(t, err, constants.ErrInvalidLoginLink)
+
+	verification, err := manager.GenerateEmailVerificationToken("user-1", "user@example.com")
+	require.NoError(t, err)
+	_, _, err = manager.ValidateLoginLinkToken(verification)
+	assert.ErrorIs(t, err, constants.ErrInvalidLoginLink)
+}
+
+func TestVerifiedEmailMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	manager, _ := newTestTokenManager()
+
+	router := gin.New()
+	router.Use(AuthMiddleware(manager, nil), VerifiedEmailMiddleware())
+	router.GET("/test", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	request := func(accessToken string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Accepts unrestricted token", func(t *testing.T) {
+		tokens, err := manager.GenerateTokenPair("user-1", "user")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, request(tokens.AccessToken).Code)
+	})
+
+	t.Run("Rejects restricted token", func(t *testing.T) {
+		tokens, err := manager.GenerateTokenPair("user-1", "user", WithEmailUnverified())
+		require.NoError(t, err)
+
+		w := request(tokens.AccessToken)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "EMAIL_NOT_VERIFIED")
+
+		// The restriction is kept when the refresh token is used
+		refreshed, err := manager.RefreshToken(tokens.RefreshToken)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, request(refreshed.AccessToken).Code)
+	})
+}
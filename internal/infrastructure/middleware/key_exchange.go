@@ -0,0 +1,205 @@
+package middleware
+
+import (
+	"container/list"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"web-server/internal/domain/constants"
+	"web-server/internal/domain/entity"
+	"web-server/internal/infrastructure/config"
+)
+
+// EncryptionSessionHeader carries the ID of the session key a client agreed on
+// with KeyExchange. Requests with it are opened and answered with that key
+// instead of the shared keys of the EncryptionKeyRing.
+const EncryptionSessionHeader = "X-Encryption-Session"
+
+// The HPKE info and exporter context clients derive their session key with.
+const (
+	keyExchangeInfo            = "web-server encryption session"
+	keyExchangeExporterContext = "session key"
+)
+
+// KeyExchange lets every client agree on its own payload encryption key with
+// the server, so that a leaked client key exposes only that client's traffic.
+// The client runs HPKE (RFC 9180) in base mode against the server's X25519
+// public key, exports the session key from the sender context and sends the
+// encapsulated key to the handshake, which derives the same key and keeps it
+// for a limited time.
+type KeyExchange struct {
+	privateKey *ecdh.PrivateKey
+	sessions   *encryptionSessionStore
+}
+
+// KeyExchangeSuite describes how clients derive their session key.
+type KeyExchangeSuite struct {
+	KEM             uint16 `json:"kem_id" example:"32"` // DHKEM(X25519, HKDF-SHA256)
+	KDF             uint16 `json:"kdf_id" example:"1"`  // HKDF-SHA256
+	AEAD            uint16 `json:"aead_id" example:"2"` // AES-256-GCM
+	PublicKey       string `json:"public_key"`          // Base64 encoded X25519 public key
+	Info            string `json:"info" example:"web-server encryption session"`
+	ExporterContext string `json:"exporter_context" example:"session key"`
+	KeyLength       int    `json:"key_length" example:"32"`
+}
+
+// EncryptionSession is a session key agreed on with KeyExchange.
+type EncryptionSession struct {
+	ID        string
+	ExpiresAt time.Time
+	key       []byte
+}
+
+func NewKeyExchange(privateKey *ecdh.PrivateKey, ttl time.Duration, maxSessions int) *KeyExchange {
+	return &KeyExchange{
+		privateKey: privateKey,
+		sessions:   newEncryptionSessionStore(ttl, maxSessions),
+	}
+}
+
+// LoadKeyExchange reads the handshake key from the configured file, or
+// generates one if none is configured. Session keys are kept in memory, so a
+// generated key loses nothing that a restart would not.
+func LoadKeyExchange(cfg *config.Config) (*KeyExchange, error) {
+	var privateKey *ecdh.PrivateKey
+
+	if path := cfg.EncryptionSession.ExchangeKey; path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading key exchange key: %w", err)
+		}
+
+		if privateKey, err = ParseExchangeKey(data); err != nil {
+			return nil, fmt.Errorf("parsing key exchange key: %w", err)
+		}
+	} else {
+		var err error
+		if privateKey, err = ecdh.X25519().GenerateKey(rand.Reader); err != nil {
+			return nil, err
+		}
+	}
+
+	return NewKeyExchange(privateKey, cfg.EncryptionSession.TTL, cfg.EncryptionSession.MaxSessions), nil
+}
+
+// ParseExchangeKey parses a PEM encoded PKCS #8 X25519 private key, as
+// generated by openssl genpkey -algorithm X25519.
+func ParseExchangeKey(data []byte) (*ecdh.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PRIVATE KEY" {
+		return nil, constants.ErrInvalidEncryptionKey
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	privateKey, ok := key.(*ecdh.PrivateKey)
+	if !ok || privateKey.Curve() != ecdh.X25519() {
+		return nil, constants.ErrInvalidEncryptionKey
+	}
+	return privateKey, nil
+}
+
+// Suite returns the server's public key and the parameters clients derive
+// their session key with.
+func (k *KeyExchange) Suite() KeyExchangeSuite {
+	return KeyExchangeSuite{
+		KEM:             hpkeKEMX25519,
+		KDF:             hpkeKDFSHA256,
+		AEAD:            hpkeAEADAES256GCM,
+		PublicKey:       base64.StdEncoding.EncodeToString(k.privateKey.PublicKey().Bytes()),
+		Info:            keyExchangeInfo,
+		ExporterContext: keyExchangeExporterContext,
+		KeyLength:       encryptionKeySize,
+	}
+}
+
+// Handshake derives the session key of the client that sent the encapsulated
+// key and starts a session with it.
+func (k *KeyExchange) Handshake(enc []byte) (*EncryptionSession, error) {
+	key, err := hpkeExport(k.privateKey, enc, []byte(keyExchangeInfo), []byte(keyExchangeExporterContext), encryptionKeySize)
+	if err != nil {
+		return nil, constants.ErrInvalidEncapsulatedKey
+	}
+
+	return k.sessions.add(key, time.Now())
+}
+
+// SessionKey returns the key of a session that has not expired.
+func (k *KeyExchange) SessionKey(id string) ([]byte, error) {
+	return k.sessions.get(id, time.Now())
+}
+
+// encryptionSessionStore keeps a bounded number of session keys until they
+// expire. All sessions live equally long, so the oldest session is always the
+// next to expire and the first to be dropped when the store is full.
+type encryptionSessionStore struct {
+	sessions map[string]*list.Element
+	order    *list.List // Oldest first
+	ttl      time.Duration
+	max      int
+	mutex    sync.Mutex
+}
+
+func newEncryptionSessionStore(ttl time.Duration, max int) *encryptionSessionStore {
+	return &encryptionSessionStore{
+		sessions: make(map[string]*list.Element),
+		order:    list.New(),
+		ttl:      ttl,
+		max:      max,
+	}
+}
+
+func (s *encryptionSessionStore) add(key []byte, now time.Time) (*EncryptionSession, error) {
+	id, _, err := entity.GenerateOneTimeToken()
+	if err != nil {
+		return nil, err
+	}
+
+	session := &EncryptionSession{ID: id, ExpiresAt: now.Add(s.ttl), key: key}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for front := s.order.Front(); front != nil; front = s.order.Front() {
+		if s.order.Len() < s.max && now.Before(front.Value.(*EncryptionSession).ExpiresAt) {
+			break
+		}
+		s.remove(front)
+	}
+
+	s.sessions[id] = s.order.PushBack(session)
+	return session, nil
+}
+
+func (s *encryptionSessionStore) get(id string, now time.Time) ([]byte, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	element, exists := s.sessions[id]
+	if !exists {
+		return nil, constants.ErrEncryptionSessionNotFound
+	}
+
+	session := element.Value.(*EncryptionSession)
+	if !now.Before(session.ExpiresAt) {
+		s.remove(element)
+		return nil, constants.ErrEncryptionSessionNotFound
+	}
+
+	return session.key, nil
+}
+
+func (s *encryptionSessionStore) remove(element *list.Element) {
+	delete(s.sessions, element.Value.(*EncryptionSession).ID)
+	s.order.Remove(element)
+}
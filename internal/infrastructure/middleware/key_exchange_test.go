@@ -0,0 +1,210 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"web-server/internal/domain/constants"
+	"web-server/internal/infrastructure/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestKeyExchange() *KeyExchange {
+	privateKey, _ := ecdh.X25519().GenerateKey(rand.Reader)
+	return NewKeyExchange(privateKey, time.Hour, 100)
+}
+
+// testHandshake derives a session key the way clients do, as an HPKE sender
+// exporting a secret, and completes the handshake with the encapsulated key.
+func testHandshake(t *testing.T, exchange *KeyExchange) (string, []byte) {
+	suite := exchange.Suite()
+	publicKey, err := base64.StdEncoding.DecodeString(suite.PublicKey)
+	require.NoError(t, err)
+	recipientKey, err := ecdh.X25519().NewPublicKey(publicKey)
+	require.NoError(t, err)
+
+	ephemeralKey, err := ecdh.X25519().GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	dh, err := ephemeralKey.ECDH(recipientKey)
+	require.NoError(t, err)
+
+	enc := ephemeralKey.PublicKey().Bytes()
+	key, err := hpkeExportDH(dh, enc, publicKey, []byte(suite.Info), []byte(suite.ExporterContext), suite.KeyLength)
+	require.NoError(t, err)
+
+	session, err := exchange.Handshake(enc)
+	require.NoError(t, err)
+	return session.ID, key
+}
+
+func TestHPKEExport(t *testing.T) {
+	// Computed with the recipient context of an independent RFC 9180
+	// implementation for the recipient key and enc of the RFC's X25519 test
+	// vectors
+	privateKeyBytes, _ := hex.DecodeString("497b4502664cfea5d5af0b39934dac72242a74f8480451e1aee7d6a53320333d")
+	enc, _ := hex.DecodeString("6c93e09869df3402d7bf231bf540fadd35cd56be14f97178f0954db94b7fc256")
+	privateKey, err := ecdh.X25519().NewPrivateKey(privateKeyBytes)
+	require.NoError(t, err)
+
+	secret, err := hpkeExport(privateKey, enc, []byte(keyExchangeInfo), []byte(keyExchangeExporterContext), 32)
+	require.NoError(t, err)
+	assert.Equal(t, "df0ac5bfd193064ecc773b74cdfc5ea96fabada4301dfe5b86c4e15a504203a1", hex.EncodeToString(secret))
+
+	// The all-zero point would make the secret predictable
+	_, err = hpkeExport(privateKey, make([]byte, 32), nil, nil, 32)
+	assert.Error(t, err)
+}
+
+func TestParseExchangeKey(t *testing.T) {
+	privateKey, err := ecdh.X25519().GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	require.NoError(t, err)
+
+	parsed, err := ParseExchangeKey(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	require.NoError(t, err)
+	assert.True(t, privateKey.Equal(parsed))
+
+	// Keys for other algorithms are rejected
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err = x509.MarshalPKCS8PrivateKey(edKey)
+	require.NoError(t, err)
+	_, err = ParseExchangeKey(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	assert.ErrorIs(t, err, constants.ErrInvalidEncryptionKey)
+}
+
+func TestEncryptionSessionStore(t *testing.T) {
+	store := newEncryptionSessionStore(time.Minute, 2)
+	now := time.Now()
+
+	first, err := store.add([]byte("first"), now)
+	require.NoError(t, err)
+	second, err := store.add([]byte("second"), now.Add(time.Second))
+	require.NoError(t, err)
+
+	key, err := store.get(first.ID, now)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("first"), key)
+
+	t.Run("Drops the oldest session when full", func(t *testing.T) {
+		third, err := store.add([]byte("third"), now.Add(2*time.Second))
+		require.NoError(t, err)
+
+		_, err = store.get(first.ID, now)
+		assert.ErrorIs(t, err, constants.ErrEncryptionSessionNotFound)
+		_, err = store.get(second.ID, now)
+		assert.NoError(t, err)
+		_, err = store.get(third.ID, now)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Expires sessions", func(t *testing.T) {
+		_, err := store.get(second.ID, second.ExpiresAt)
+		assert.ErrorIs(t, err, constants.ErrEncryptionSessionNotFound)
+		assert.Equal(t, 1, store.order.Len())
+	})
+}
+
+func TestKeyExchange_Middleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := config.GetConfig()
+	exchange := newTestKeyExchange()
+
+	router := gin.New()
+	router.Use(EncryptionMiddleware(newTestEncryptionKeyRing(), exchange))
+	router.POST("/echo", func(c *gin.Context) {
+		var data map[string]string
+		if err := c.ShouldBindJSON(&data); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, data)
+	})
+
+	sessionID, sessionKey := testHandshake(t, exchange)
+
+	request := func(sessionID string, body []byte) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/echo", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", EncryptedContentType)
+		if sessionID != "" {
+			req.Header.Set(EncryptionSessionHeader, sessionID)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	seal := func(keyID string, key []byte) []byte {
+		sealed, err := sealEnvelope(key, keyID, []byte(`{"message":"test"}`), envelopeBinding{Direction: envelopeRequest, Method: "POST", Path: "/echo"}, time.Now())
+		require.NoError(t, err)
+		body, err := json.Marshal(encryptedBody{Version: 1, Data: base64.StdEncoding.EncodeToString(sealed)})
+		require.NoError(t, err)
+		return body
+	}
+
+	errorCode := func(w *httptest.ResponseRecorder) string {
+		var resp constants.ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp.Code
+	}
+
+	t.Run("Uses the session key", func(t *testing.T) {
+		w := request(sessionID, seal(sessionID, sessionKey))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var encResp encryptedBody
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &encResp))
+		data, err := base64.StdEncoding.DecodeString(encResp.Data)
+		require.NoError(t, err)
+
+		env, err := parseEnvelope(data)
+		require.NoError(t, err)
+		assert.Equal(t, sessionID, env.KeyID)
+
+		plaintext, err := openEnvelope(data, envelopeBinding{Direction: envelopeResponse, Method: "POST", Path: "/echo"}, time.Now(),
+			func(string) ([]byte, error) { return sessionKey, nil })
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"message":"test"}`, string(plaintext))
+	})
+
+	t.Run("Rejects shared keys within a session", func(t *testing.T) {
+		keyID, key := newTestEncryptionKeyRing().Primary()
+		w := request(sessionID, seal(keyID, key))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, constants.ErrUnknownEncryptionKey().Code, errorCode(w))
+	})
+
+	t.Run("Rejects unknown sessions", func(t *testing.T) {
+		w := request("unknown", seal("unknown", sessionKey))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, constants.ErrUnknownEncryptionSession().Code, errorCode(w))
+	})
+
+	t.Run("Requires a session if configured", func(t *testing.T) {
+		defer func(required bool) { cfg.EncryptionSession.Required = required }(cfg.EncryptionSession.Required)
+		cfg.EncryptionSession.Required = true
+
+		keyID, key := newTestEncryptionKeyRing().Primary()
+		w := request("", seal(keyID, key))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, constants.ErrEncryptionSessionRequired().Code, errorCode(w))
+
+		w = request(sessionID, seal(sessionID, sessionKey))
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
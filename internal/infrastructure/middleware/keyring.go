@@ -0,0 +1,341 @@
+package middleware
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"web-server/internal/domain/constants"
+	"web-server/internal/infrastructure/config"
+
+	jwt "github.com/golang-jwt/jwt/v5"
+)
+
+// defaultKeyID identifies the shared HMAC secret used when no asymmetric keys
+// are configured.
+const defaultKeyID = "default"
+
+// SigningKey is a key that signs or verifies access tokens. Keys loaded from a
+// public key file have no private key and can only verify. Keys with a
+// VerifyUntil stop verifying tokens after it.
+type SigningKey struct {
+	ID          string
+	Method      jwt.SigningMethod
+	PrivateKey  interface{}
+	PublicKey   interface{}
+	VerifyUntil time.Time
+}
+
+// KeyRing holds the keys access tokens are signed with. The primary key signs
+// new tokens and every other key still verifies the tokens it signed, so that
+// keys can be rotated without invalidating tokens that are already out.
+type KeyRing struct {
+	keys    map[string]*SigningKey
+	primary string
+	mutex   sync.RWMutex
+}
+
+func NewKeyRing() *KeyRing {
+	return &KeyRing{
+		keys: make(map[string]*SigningKey),
+	}
+}
+
+// NewHMACKeyRing returns a key ring with a single HS256 key.
+func NewHMACKeyRing(secret []byte) *KeyRing {
+	ring := NewKeyRing()
+	ring.keys[defaultKeyID] = newHMACSigningKey(secret)
+	ring.primary = defaultKeyID
+	return ring
+}
+
+func newHMACSigningKey(secret []byte) *SigningKey {
+	return &SigningKey{
+		ID:         defaultKeyID,
+		Method:     jwt.SigningMethodHS256,
+		PrivateKey: secret,
+		PublicKey:  secret,
+	}
+}
+
+// LoadKeyRing builds the key ring from the configured key files. The first key
+// becomes the primary key. Without configured keys it falls back to HS256 with
+// the shared JWT secret. With them and Config.JWTVerifyLegacySecret set, the
+// shared secret keeps verifying the tokens it signed for one access token
+// lifetime, so that moving to asymmetric keys does not log everyone out but
+// the secret cannot mint valid tokens for longer than the move takes.
+func LoadKeyRing(cfg *config.Config) (*KeyRing, error) {
+	if len(cfg.JWTSigningKeys) == 0 {
+		return NewHMACKeyRing(cfg.JWTSecret), nil
+	}
+
+	ring := NewKeyRing()
+	for _, file := range cfg.JWTSigningKeys {
+		data, err := os.ReadFile(file.Path)
+		if err != nil {
+			return nil, fmt.Errorf("reading signing key %q: %w", file.ID, err)
+		}
+
+		key, err := ParseSigningKey(file.ID, data)
+		if err != nil {
+			return nil, fmt.Errorf("parsing signing key %q: %w", file.ID, err)
+		}
+
+		if err := ring.Add(key); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.JWTVerifyLegacySecret && len(cfg.JWTSecret) > 0 {
+		legacy := newHMACSigningKey(cfg.JWTSecret)
+		legacy.VerifyUntil = time.Now().Add(cfg.JWTExpiration)
+		if err := ring.Add(legacy); err != nil {
+			return nil, fmt.Errorf("adding legacy signing key %q: %w", defaultKeyID, err)
+		}
+	}
+
+	if err := ring.SetPrimary(cfg.JWTSigningKeys[0].ID); err != nil {
+		return nil, err
+	}
+
+	return ring, nil
+}
+
+// ParseSigningKey parses a PEM encoded RSA, ECDSA or Ed25519 key. The signing
+// method is derived from the key type.
+func ParseSigningKey(id string, data []byte) (*SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, constants.ErrInvalidSigningKey
+	}
+
+	var (
+		privateKey interface{}
+		publicKey  interface{}
+		err        error
+	)
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		privateKey, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		privateKey, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		privateKey, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		publicKey, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, constants.ErrInvalidSigningKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if privateKey != nil {
+		signer, ok := privateKey.(crypto.Signer)
+		if !ok {
+			return nil, constants.ErrInvalidSigningKey
+		}
+		publicKey = signer.Public()
+	}
+
+	method, err := signingMethodFor(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SigningKey{
+		ID:         id,
+		Method:     method,
+		PrivateKey: privateKey,
+		PublicKey:  publicKey,
+	}, nil
+}
+
+func signingMethodFor(publicKey interface{}) (jwt.SigningMethod, error) {
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		return jwt.SigningMethodRS256, nil
+	case *ecdsa.PublicKey:
+		switch key.Curve {
+		case elliptic.P256():
+			return jwt.SigningMethodES256, nil
+		case elliptic.P384():
+			return jwt.SigningMethodES384, nil
+		case elliptic.P521():
+			return jwt.SigningMethodES512, nil
+		}
+	case ed25519.PublicKey:
+		return jwt.SigningMethodEdDSA, nil
+	}
+	return nil, constants.ErrInvalidSigningKey
+}
+
+// Add adds a key to the ring. Adding a key does not make it the primary key.
+func (r *KeyRing) Add(key *SigningKey) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.keys[key.ID]; exists {
+		return constants.ErrSigningKeyExists
+	}
+
+	r.keys[key.ID] = key
+	return nil
+}
+
+// SetPrimary makes the key with the given ID sign all new tokens.
+func (r *KeyRing) SetPrimary(id string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	key, exists := r.keys[id]
+	if !exists {
+		return constants.ErrSigningKeyNotFound
+	}
+	if key.PrivateKey == nil {
+		return constants.ErrInvalidSigningKey
+	}
+
+	r.primary = id
+	return nil
+}
+
+// Remove drops a key that no longer needs to verify tokens. The primary key
+// cannot be removed.
+func (r *KeyRing) Remove(id string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.keys[id]; !exists {
+		return constants.ErrSigningKeyNotFound
+	}
+	if id == r.primary {
+		return constants.ErrPrimaryKeyRemoval
+	}
+
+	delete(r.keys, id)
+	return nil
+}
+
+// Sign signs the claims with the primary key and sets the kid header.
+func (r *KeyRing) Sign(claims jwt.Claims) (string, error) {
+	r.mutex.RLock()
+	key := r.keys[r.primary]
+	r.mutex.RUnlock()
+
+	if key == nil {
+		return "", constants.ErrSigningKeyNotFound
+	}
+
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.PrivateKey)
+}
+
+// Keyfunc looks up the verification key by the kid header. Tokens without a kid
+// were signed with the shared HS256 secret before key IDs existed, so they are
+// only valid while that key is in the ring and has not run out.
+func (r *KeyRing) Keyfunc(token *jwt.Token) (interface{}, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	id, _ := token.Header["kid"].(string)
+	if id == "" {
+		id = defaultKeyID
+	}
+
+	key, exists := r.keys[id]
+	if !exists || (!key.VerifyUntil.IsZero() && time.Now().After(key.VerifyUntil)) {
+		return nil, constants.ErrSigningKeyNotFound
+	}
+
+	// Never let the token pick the algorithm, or a public key could be used as an HMAC secret
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, constants.ErrUnexpectedSigningMethod
+	}
+
+	return key.PublicKey, nil
+}
+
+// JSONWebKey is the public part of a signing key as defined in RFC 7517.
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JSONWebKeySet is a set of public keys as served at /.well-known/jwks.json.
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// JWKS returns the public keys of the ring. HMAC keys are never published.
+func (r *KeyRing) JWKS() JSONWebKeySet {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	set := JSONWebKeySet{Keys: []JSONWebKey{}}
+	for _, key := range r.keys {
+		if jwk, ok := toJSONWebKey(key); ok {
+			set.Keys = append(set.Keys, jwk)
+		}
+	}
+
+	// Keep the output stable across requests
+	sort.Slice(set.Keys, func(i, j int) bool {
+		return set.Keys[i].Kid < set.Keys[j].Kid
+	})
+
+	return set
+}
+
+func toJSONWebKey(key *SigningKey) (JSONWebKey, bool) {
+	jwk := JSONWebKey{
+		Kid: key.ID,
+		Use: "sig",
+		Alg: key.Method.Alg(),
+	}
+
+	switch publicKey := key.PublicKey.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = encodeSegment(publicKey.N.Bytes())
+		jwk.E = encodeSegment(big.NewInt(int64(publicKey.E)).Bytes())
+	case *ecdsa.PublicKey:
+		size := (publicKey.Curve.Params().BitSize + 7) / 8
+		jwk.Kty = "EC"
+		jwk.Crv = publicKey.Curve.Params().Name
+		jwk.X = encodeSegment(publicKey.X.FillBytes(make([]byte, size)))
+		jwk.Y = encodeSegment(publicKey.Y.FillBytes(make([]byte, size)))
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = encodeSegment(publicKey)
+	default:
+		return JSONWebKey{}, false
+	}
+
+	return jwk, true
+}
+
+func encodeSegment(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
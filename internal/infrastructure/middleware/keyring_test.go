@@ -0,0 +1,287 @@
+package middleware
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"web-server/internal/domain/constants"
+	"web-server/internal/infrastructure/config"
+
+	jwt "github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encodePrivateKey(t *testing.T, key interface{}) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+}
+
+func newTestClaims() JWTClaims {
+	return JWTClaims{
+		UserID:    "user-1",
+		Role:      "user",
+		TokenType: "access",
+		RegisteredClaims: &jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+}
+
+func TestParseSigningKey(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantAlg string
+	}{
+		{
+			name:    "RSA key",
+			data:    encodePrivateKey(t, rsaKey),
+			wantAlg: "RS256",
+		},
+		{
+			name:    "PKCS1 RSA key",
+			data:    pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)}),
+			wantAlg: "RS256",
+		},
+		{
+			name:    "ECDSA P-256 key",
+			data:    encodePrivateKey(t, ecKey),
+			wantAlg: "ES256",
+		},
+		{
+			name:    "Ed25519 key",
+			data:    encodePrivateKey(t, edKey),
+			wantAlg: "EdDSA",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := ParseSigningKey("kid", tt.data)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantAlg, key.Method.Alg())
+			assert.NotNil(t, key.PrivateKey)
+			assert.NotNil(t, key.PublicKey)
+		})
+	}
+
+	t.Run("Public key can only verify", func(t *testing.T) {
+		der, err := x509.MarshalPKIXPublicKey(edKey.Public())
+		require.NoError(t, err)
+
+		key, err := ParseSigningKey("kid", pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+		require.NoError(t, err)
+		assert.Nil(t, key.PrivateKey)
+
+		ring := NewKeyRing()
+		require.NoError(t, ring.Add(key))
+		assert.ErrorIs(t, ring.SetPrimary("kid"), constants.ErrInvalidSigningKey)
+	})
+
+	t.Run("Rejects invalid PEM", func(t *testing.T) {
+		_, err := ParseSigningKey("kid", []byte("not a key"))
+		assert.ErrorIs(t, err, constants.ErrInvalidSigningKey)
+	})
+}
+
+func TestKeyRing_Rotation(t *testing.T) {
+	_, oldKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	newKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	ring := NewKeyRing()
+	old, err := ParseSigningKey("old", encodePrivateKey(t, oldKey))
+	require.NoError(t, err)
+	require.NoError(t, ring.Add(old))
+	require.NoError(t, ring.SetPrimary("old"))
+
+	oldToken, err := ring.Sign(newTestClaims())
+	require.NoError(t, err)
+
+	// Rotate to the new key
+	current, err := ParseSigningKey("new", encodePrivateKey(t, newKey))
+	require.NoError(t, err)
+	require.NoError(t, ring.Add(current))
+	require.NoError(t, ring.SetPrimary("new"))
+
+	newToken, err := ring.Sign(newTestClaims())
+	require.NoError(t, err)
+
+	parsed, err := jwt.Parse(newToken, ring.Keyfunc)
+	require.NoError(t, err)
+	assert.Equal(t, "new", parsed.Header["kid"])
+	assert.Equal(t, "ES256", parsed.Method.Alg())
+
+	// Tokens signed before the rotation stay valid
+	_, err = parseToken(oldToken, ring.Keyfunc)
+	assert.NoError(t, err)
+
+	// Until the old key is removed
+	assert.ErrorIs(t, ring.Remove("new"), constants.ErrPrimaryKeyRemoval)
+	require.NoError(t, ring.Remove("old"))
+	_, err = parseToken(oldToken, ring.Keyfunc)
+	assert.Error(t, err)
+}
+
+func TestLoadKeyRing_MixedRotation(t *testing.T) {
+	secret := []byte("test-jwt-secret")
+
+	// Tokens issued with the shared secret, before and after key IDs existed
+	unversioned, err := jwt.NewWithClaims(jwt.SigningMethodHS256, newTestClaims()).SignedString(secret)
+	require.NoError(t, err)
+	versioned, err := NewHMACKeyRing(secret).Sign(newTestClaims())
+	require.NoError(t, err)
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "ed25519.pem")
+	require.NoError(t, os.WriteFile(path, encodePrivateKey(t, edKey), 0o600))
+
+	cfg := &config.Config{
+		JWTSecret:             secret,
+		JWTSigningKeys:        []config.KeyFile{{ID: "2025-06", Path: path}},
+		JWTVerifyLegacySecret: true,
+		JWTExpiration:         15 * time.Minute,
+	}
+
+	t.Run("Legacy tokens stay valid after moving to asymmetric keys", func(t *testing.T) {
+		ring, err := LoadKeyRing(cfg)
+		require.NoError(t, err)
+
+		signed, err := ring.Sign(newTestClaims())
+		require.NoError(t, err)
+		parsed, err := jwt.Parse(signed, ring.Keyfunc)
+		require.NoError(t, err)
+		assert.Equal(t, "2025-06", parsed.Header["kid"])
+		assert.Equal(t, "EdDSA", parsed.Method.Alg())
+
+		_, err = parseToken(unversioned, ring.Keyfunc)
+		assert.NoError(t, err)
+		_, err = parseToken(versioned, ring.Keyfunc)
+		assert.NoError(t, err)
+
+		// The shared secret only verifies and is never published
+		assert.Len(t, ring.JWKS().Keys, 1)
+	})
+
+	t.Run("Legacy tokens are rejected once the secret is dropped", func(t *testing.T) {
+		withoutLegacy := *cfg
+		withoutLegacy.JWTVerifyLegacySecret = false
+		ring, err := LoadKeyRing(&withoutLegacy)
+		require.NoError(t, err)
+
+		_, err = parseToken(unversioned, ring.Keyfunc)
+		assert.ErrorIs(t, err, constants.ErrSigningKeyNotFound)
+		_, err = parseToken(versioned, ring.Keyfunc)
+		assert.ErrorIs(t, err, constants.ErrSigningKeyNotFound)
+	})
+
+	t.Run("Legacy tokens are rejected one access token lifetime after loading", func(t *testing.T) {
+		ring, err := LoadKeyRing(cfg)
+		require.NoError(t, err)
+
+		legacy := ring.keys[defaultKeyID]
+		assert.WithinDuration(t, time.Now().Add(cfg.JWTExpiration), legacy.VerifyUntil, time.Minute)
+
+		legacy.VerifyUntil = time.Now().Add(-time.Second)
+		_, err = parseToken(unversioned, ring.Keyfunc)
+		assert.ErrorIs(t, err, constants.ErrSigningKeyNotFound)
+		_, err = parseToken(versioned, ring.Keyfunc)
+		assert.ErrorIs(t, err, constants.ErrSigningKeyNotFound)
+	})
+
+	t.Run("Tokens without a kid never verify against an asymmetric primary key", func(t *testing.T) {
+		ring, err := LoadKeyRing(cfg)
+		require.NoError(t, err)
+
+		unversioned, err := jwt.NewWithClaims(jwt.SigningMethodEdDSA, newTestClaims()).SignedString(edKey)
+		require.NoError(t, err)
+
+		_, err = parseToken(unversioned, ring.Keyfunc)
+		assert.ErrorIs(t, err, constants.ErrUnexpectedSigningMethod)
+	})
+}
+
+func TestKeyRing_RejectsAlgorithmConfusion(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	ring := NewKeyRing()
+	key, err := ParseSigningKey("rsa", encodePrivateKey(t, rsaKey))
+	require.NoError(t, err)
+	require.NoError(t, ring.Add(key))
+	require.NoError(t, ring.SetPrimary("rsa"))
+
+	// An attacker signs with HS256 using the public key as the secret
+	publicDER, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	require.NoError(t, err)
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, newTestClaims())
+	forged.Header["kid"] = "rsa"
+	forgedString, err := forged.SignedString(publicDER)
+	require.NoError(t, err)
+
+	_, err = parseToken(forgedString, ring.Keyfunc)
+	assert.ErrorIs(t, err, constants.ErrUnexpectedSigningMethod)
+}
+
+func TestKeyRing_JWKS(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	rsaPath := filepath.Join(dir, "rsa.pem")
+	ecPath := filepath.Join(dir, "ec.pem")
+	require.NoError(t, os.WriteFile(rsaPath, encodePrivateKey(t, rsaKey), 0o600))
+	require.NoError(t, os.WriteFile(ecPath, encodePrivateKey(t, ecKey), 0o600))
+
+	ring, err := LoadKeyRing(&config.Config{
+		JWTSigningKeys: []config.KeyFile{
+			{ID: "b-rsa", Path: rsaPath},
+			{ID: "a-ec", Path: ecPath},
+		},
+	})
+	require.NoError(t, err)
+
+	set := ring.JWKS()
+	require.Len(t, set.Keys, 2)
+	assert.Equal(t, "a-ec", set.Keys[0].Kid)
+	assert.Equal(t, "EC", set.Keys[0].Kty)
+	assert.Equal(t, "P-256", set.Keys[0].Crv)
+	assert.Equal(t, "b-rsa", set.Keys[1].Kid)
+	assert.Equal(t, "RSA", set.Keys[1].Kty)
+	assert.Equal(t, "AQAB", set.Keys[1].E)
+
+	// The first configured key signs
+	token, err := ring.Sign(newTestClaims())
+	require.NoError(t, err)
+	parsed, err := jwt.Parse(token, ring.Keyfunc)
+	require.NoError(t, err)
+	assert.Equal(t, "b-rsa", parsed.Header["kid"])
+
+	t.Run("HMAC keys are not published", func(t *testing.T) {
+		assert.Empty(t, NewHMACKeyRing([]byte("secret")).JWKS().Keys)
+	})
+}
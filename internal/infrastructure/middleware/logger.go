@@ -71,6 +71,16 @@ func LoggerMiddleware(log *logrus.Logger) gin.HandlerFunc {
 			entry = entry.WithField("user_id", userID)
 		}
 
+		// Add the admin acting as the user with an impersonation token
+		if impersonatorID := c.GetString(ImpersonatorKey); impersonatorID != "" {
+			entry = entry.WithField("impersonator_id", impersonatorID)
+		}
+
+		// Add errors recorded by handlers that did not fail the request
+		if len(c.Errors) > 0 {
+			entry = entry.WithField("errors", c.Errors.String())
+		}
+
 		// Log based on status code
 		statusCode := c.Writer.Status()
 		switch {
@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"web-server/internal/domain/constants"
+	"web-server/internal/domain/service"
+	"web-server/internal/infrastructure/config"
+
+	"github.com/gin-gonic/gin"
+	jwt "github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// OIDCStateCookie keeps the signed state of an external login between the
+	// redirect to the provider and the callback.
+	OIDCStateCookie = "oidc_state"
+
+	oidcStateCookiePath = "/api/public/users/login/oidc"
+
+	// oidcStateExpiration is how long users have to log in at the provider
+	oidcStateExpiration = 10 * time.Minute
+)
+
+// oidcStateClaims are the claims of the token in OIDCStateCookie.
+type oidcStateClaims struct {
+	TokenType    string `json:"token_type"`
+	State        string `json:"state"`
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"code_verifier"`
+	jwt.RegisteredClaims
+}
+
+// GenerateOIDCStateToken seals the state of an external login into a token
+// signed with the shared JWT secret.
+func (m *TokenManager) GenerateOIDCStateToken(state service.OIDCLoginState) (string, error) {
+	now := time.Now()
+
+	claims := oidcStateClaims{
+		TokenType:    "oidc_state",
+		State:        state.State,
+		Nonce:        state.Nonce,
+		CodeVerifier: state.CodeVerifier,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(oidcStateExpiration)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(config.GetConfig().JWTSecret)
+}
+
+// ValidateOIDCStateToken verifies a token from GenerateOIDCStateToken and
+// returns the login state sealed into it.
+func (m *TokenManager) ValidateOIDCStateToken(tokenString string) (*service.OIDCLoginState, error) {
+	claims := &oidcStateClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, secretKeyfunc)
+	if err != nil || claims.TokenType != "oidc_state" || claims.State == "" || claims.CodeVerifier == "" {
+		return nil, constants.ErrInvalidOIDCState
+	}
+
+	return &service.OIDCLoginState{
+		State:        claims.State,
+		Nonce:        claims.Nonce,
+		CodeVerifier: claims.CodeVerifier,
+	}, nil
+}
+
+// SetOIDCStateCookie stores the state token of an external login in the
+// browser. The cookie is always SameSite lax, since the provider sends the
+// browser back with a cross-site redirect.
+func SetOIDCStateCookie(c *gin.Context, token string) {
+	setOIDCStateCookie(c, token, int(oidcStateExpiration.Seconds()))
+}
+
+// ClearOIDCStateCookie removes the state of an external login once it is used.
+func ClearOIDCStateCookie(c *gin.Context) {
+	setOIDCStateCookie(c, "", -1)
+}
+
+func setOIDCStateCookie(c *gin.Context, value string, maxAge int) {
+	cfg := config.GetConfig().Cookie
+
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     OIDCStateCookie,
+		Value:    value,
+		Path:     oidcStateCookiePath,
+		Domain:   cfg.Domain,
+		MaxAge:   maxAge,
+		Secure:   cfg.Secure,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"web-server/internal/domain/constants"
+	"web-server/internal/domain/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenManager_OIDCStateToken(t *testing.T) {
+	manager, _ := newTestTokenManager()
+	state := service.OIDCLoginState{State: "state", Nonce: "nonce", CodeVerifier: "verifier"}
+
+	token, err := manager.GenerateOIDCStateToken(state)
+	require.NoError(t, err)
+
+	validated, err := manager.ValidateOIDCStateToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, state, *validated)
+
+	_, err = manager.ValidateOIDCStateToken(token + "x")
+	assert.ErrorIs(t, err, constants.ErrInvalidOIDCState)
+
+	// Other tokens signed with the same secret are no login state
+	mfaToken, err := manager.GenerateMFAToken("user-1")
+	require.NoError(t, err)
+	_, err = manager.ValidateOIDCStateToken(mfaToken)
+	assert.ErrorIs(t, err, constants.ErrInvalidOIDCState)
+}
+
+func TestSetOIDCStateCookie(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	SetOIDCStateCookie(c, "token")
+
+	cookie := w.Result().Cookies()[0]
+	assert.Equal(t, OIDCStateCookie, cookie.Name)
+	assert.Equal(t, "token", cookie.Value)
+	assert.True(t, cookie.HttpOnly)
+	// The provider redirects back cross-site, which strict cookies would not survive
+	assert.Equal(t, http.SameSiteLaxMode, cookie.SameSite)
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"net/http"
+
+	"web-server/internal/domain/constants"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PermissionChecker decides whether a role is granted a permission.
+type PermissionChecker interface {
+	HasPermission(role, permission string) (bool, error)
+}
+
+// RequirePermission rejects requests whose role lacks any of the given
+// permissions. Client tokens have no role and need the permissions as scopes
+// instead, see RequireScope. It must run after AuthMiddleware.
+func RequirePermission(checker PermissionChecker, permissions ...string) gin.HandlerFunc {
+	requireScope := RequireScope(permissions...)
+
+	return func(c *gin.Context) {
+		if c.GetString(ClientIDKey) != "" {
+			requireScope(c)
+			return
+		}
+
+		role, exists := c.Get("role")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, constants.ErrRoleNotFound())
+			c.Abort()
+			return
+		}
+
+		roleStr, ok := role.(string)
+		if !ok {
+			c.JSON(http.StatusInternalServerError, constants.ErrInternalServer())
+			c.Abort()
+			return
+		}
+
+		for _, permission := range permissions {
+			allowed, err := checker.HasPermission(roleStr, permission)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, constants.ErrInternalServer())
+				c.Abort()
+				return
+			}
+			if !allowed {
+				c.JSON(http.StatusForbidden, constants.ErrInsufficientPermissions())
+				c.Abort()
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
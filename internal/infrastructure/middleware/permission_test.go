@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakePermissionChecker map[string][]string
+
+func (f fakePermissionChecker) HasPermission(role, permission string) (bool, error) {
+	for _, p := range f[role] {
+		if p == permission {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func TestRequirePermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	checker := fakePermissionChecker{
+		"support": {"users:read"},
+		"auditor": {"users:read", "roles:read"},
+	}
+
+	request := func(role string, permissions ...string) *httptest.ResponseRecorder {
+		router := gin.New()
+		router.Use(func(c *gin.Context) {
+			if role != "" {
+				c.Set("role", role)
+			}
+		})
+		router.GET("/test", RequirePermission(checker, permissions...), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/test", nil))
+		return w
+	}
+
+	t.Run("Allows granted permission", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, request("support", "users:read").Code)
+	})
+
+	t.Run("Requires every permission", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, request("auditor", "users:read", "roles:read").Code)
+
+		w := request("support", "users:read", "roles:read")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "INSUFFICIENT_PERMISSIONS")
+	})
+
+	t.Run("Rejects missing role", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, request("", "users:read").Code)
+	})
+}
@@ -25,21 +25,21 @@ func TestRateLimiter_TryConsume(t *testing.T) {
 	t.Run("Consume within capacity", func(t *testing.T) {
 		limiter := NewRateLimiter(10.0, 2.0)
 		assert.True(t, limiter.tryConsume(5.0))
-		assert.Equal(t, 5.0, limiter.tokens)
+		assert.InDelta(t, 5.0, limiter.tokens, 0.1)
 	})
 
 	t.Run("Consume more than available", func(t *testing.T) {
 		limiter := NewRateLimiter(10.0, 2.0)
 		limiter.tokens = 3.0
 		assert.False(t, limiter.tryConsume(5.0))
-		assert.Equal(t, 3.0, limiter.tokens)
+		assert.InDelta(t, 3.0, limiter.tokens, 0.1)
 	})
 
 	t.Run("Consume exact amount", func(t *testing.T) {
 		limiter := NewRateLimiter(10.0, 2.0)
 		limiter.tokens = 5.0
 		assert.True(t, limiter.tryConsume(5.0))
-		assert.Equal(t, 0.0, limiter.tokens)
+		assert.InDelta(t, 0.0, limiter.tokens, 0.1)
 	})
 }
 
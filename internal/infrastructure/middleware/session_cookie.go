@@ -0,0 +1,163 @@
+package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strings"
+
+	"web-server/internal/domain/constants"
+	"web-server/internal/infrastructure/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Browser clients can keep their tokens in cookies instead of script-accessible
+// storage. They ask for it by sending SessionModeHeader with SessionModeCookie
+// when logging in; the access and refresh tokens are then set as HttpOnly
+// cookies and left out of the response body.
+const (
+	SessionModeHeader = "X-Session-Mode"
+	SessionModeCookie = "cookie"
+
+	AccessTokenCookie  = "access_token"
+	RefreshTokenCookie = "refresh_token"
+
+	// CSRFCookie holds the CSRF token of the session where scripts can read
+	// it. Requests authenticated with the session cookies echo it back in
+	// CSRFHeader.
+	CSRFCookie = "csrf_token"
+	CSRFHeader = "X-CSRF-Token"
+
+	// The refresh token is only needed to rotate the tokens
+	refreshCookiePath = "/api/public/users/refresh"
+)
+
+// WantsSessionCookies reports whether the client asked for the cookie session mode.
+func WantsSessionCookies(c *gin.Context) bool {
+	return strings.EqualFold(c.GetHeader(SessionModeHeader), SessionModeCookie)
+}
+
+// HasSessionCookies reports whether the request carries a session cookie.
+func HasSessionCookies(c *gin.Context) bool {
+	for _, name := range []string{AccessTokenCookie, RefreshTokenCookie} {
+		if value, err := c.Cookie(name); err == nil && value != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// SetSessionCookies sets the token pair and the CSRF token of its session as
+// cookies. The CSRF token is returned for the response body.
+func (m *TokenManager) SetSessionCookies(c *gin.Context, pair *TokenPair) string {
+	cfg := config.GetConfig()
+	csrfToken := m.CSRFToken(pair.SessionID)
+
+	setCookie(c, AccessTokenCookie, pair.AccessToken, "/", int(pair.ExpiresIn), true)
+	setCookie(c, RefreshTokenCookie, pair.RefreshToken, refreshCookiePath, int(cfg.JWTRefreshExpiration.Seconds()), true)
+	setCookie(c, CSRFCookie, csrfToken, "/", int(cfg.JWTRefreshExpiration.Seconds()), false)
+
+	return csrfToken
+}
+
+// ClearSessionCookies removes the session cookies from the browser.
+func ClearSessionCookies(c *gin.Context) {
+	setCookie(c, AccessTokenCookie, "", "/", -1, true)
+	setCookie(c, RefreshTokenCookie, "", refreshCookiePath, -1, true)
+	setCookie(c, CSRFCookie, "", "/", -1, false)
+}
+
+func setCookie(c *gin.Context, name, value, path string, maxAge int, httpOnly bool) {
+	cfg := config.GetConfig().Cookie
+
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   cfg.Domain,
+		MaxAge:   maxAge,
+		Secure:   cfg.Secure,
+		HttpOnly: httpOnly,
+		SameSite: sameSiteMode(cfg.SameSite),
+	})
+}
+
+// sameSiteMode maps the configured SameSite attribute, defaulting to strict.
+func sameSiteMode(value string) http.SameSite {
+	switch strings.ToLower(value) {
+	case "lax":
+		return http.SameSiteLaxMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteStrictMode
+	}
+}
+
+// CSRFToken returns the CSRF token of a session. It is derived from the session
+// ID, so it stays the same when the tokens are refreshed and cannot be reused
+// with another session.
+func (m *TokenManager) CSRFToken(sessionID string) string {
+	mac := hmac.New(sha256.New, config.GetConfig().JWTSecret)
+	mac.Write([]byte("csrf:" + sessionID))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// cookieSession returns the session of the session cookies of the request, or
+// false if it has none with a valid signature. The access token is checked
+// first, since the browser drops it earlier than the refresh token.
+func (m *TokenManager) cookieSession(c *gin.Context) (string, bool) {
+	if value, err := c.Cookie(AccessTokenCookie); err == nil && value != "" {
+		claims, err := parseToken(value, m.keys.Keyfunc)
+		if err == nil && claims.TokenType == "access" && claims.SessionID != "" {
+			return claims.SessionID, true
+		}
+	}
+
+	if value, err := c.Cookie(RefreshTokenCookie); err == nil && value != "" {
+		claims, err := parseToken(value, refreshKeyfunc)
+		if err == nil && claims.TokenType == "refresh" && claims.FamilyID != "" {
+			return claims.FamilyID, true
+		}
+	}
+
+	return "", false
+}
+
+// CSRFMiddleware rejects state-changing requests that are authenticated with
+// session cookies but lack the CSRF token of the session in CSRFHeader. Other
+// sites can make a browser send its cookies, but cannot read the token.
+// Requests with an Authorization or API key header are not affected, since
+// browsers never add those on their own.
+func CSRFMiddleware(tokens *TokenManager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+			c.Next()
+			return
+		}
+
+		if c.GetHeader("Authorization") != "" || c.GetHeader(APIKeyHeader) != "" {
+			c.Next()
+			return
+		}
+
+		// Requests without a usable session cookie carry nothing a forged
+		// request could abuse, authentication rejects them if needed
+		sessionID, ok := tokens.cookieSession(c)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		if !hmac.Equal([]byte(c.GetHeader(CSRFHeader)), []byte(tokens.CSRFToken(sessionID))) {
+			c.JSON(http.StatusForbidden, constants.ErrCSRFTokenInvalid())
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetSessionCookies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	manager, _ := newTestTokenManager()
+
+	tokens, err := manager.GenerateTokenPair("user-1", "user")
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	csrfToken := manager.SetSessionCookies(c, tokens)
+	assert.Equal(t, manager.CSRFToken(tokens.SessionID), csrfToken)
+
+	cookies := map[string]*http.Cookie{}
+	for _, cookie := range w.Result().Cookies() {
+		cookies[cookie.Name] = cookie
+	}
+
+	require.Contains(t, cookies, AccessTokenCookie)
+	assert.Equal(t, tokens.AccessToken, cookies[AccessTokenCookie].Value)
+	assert.True(t, cookies[AccessTokenCookie].HttpOnly)
+	assert.True(t, cookies[AccessTokenCookie].Secure)
+	assert.Equal(t, http.SameSiteStrictMode, cookies[AccessTokenCookie].SameSite)
+
+	require.Contains(t, cookies, RefreshTokenCookie)
+	assert.True(t, cookies[RefreshTokenCookie].HttpOnly)
+	assert.Equal(t, refreshCookiePath, cookies[RefreshTokenCookie].Path)
+
+	// Scripts read the CSRF token from its cookie
+	require.Contains(t, cookies, CSRFCookie)
+	assert.Equal(t, csrfToken, cookies[CSRFCookie].Value)
+	assert.False(t, cookies[CSRFCookie].HttpOnly)
+}
+
+func TestCSRFMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	manager, _ := newTestTokenManager()
+
+	router := gin.New()
+	router.Use(CSRFMiddleware(manager), AuthMiddleware(manager, nil))
+	router.Any("/test", func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("userID"))
+	})
+
+	tokens, err := manager.GenerateTokenPair("user-1", "user")
+	require.NoError(t, err)
+	other, err := manager.GenerateTokenPair("user-2", "user")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		method     string
+		cookie     string
+		bearer     string
+		csrfToken  string
+		wantStatus int
+	}{
+		{
+			name:       "Cookie without CSRF token on safe method",
+			method:     http.MethodGet,
+			cookie:     tokens.AccessToken,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Cookie with CSRF token",
+			method:     http.MethodPost,
+			cookie:     tokens.AccessToken,
+			csrfToken:  manager.CSRFToken(tokens.SessionID),
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Cookie without CSRF token",
+			method:     http.MethodPost,
+			cookie:     tokens.AccessToken,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "Cookie with CSRF token of another session",
+			method:     http.MethodDelete,
+			cookie:     tokens.AccessToken,
+			csrfToken:  manager.CSRFToken(other.SessionID),
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "Bearer token without CSRF token",
+			method:     http.MethodPost,
+			cookie:     tokens.AccessToken,
+			bearer:     other.AccessToken,
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.method, "/test", nil)
+			req.AddCookie(&http.Cookie{Name: AccessTokenCookie, Value: tt.cookie})
+			if tt.bearer != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.bearer)
+			}
+			if tt.csrfToken != "" {
+				req.Header.Set(CSRFHeader, tt.csrfToken)
+			}
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if w.Code == http.StatusForbidden {
+				assert.Contains(t, w.Body.String(), "CSRF_TOKEN_INVALID")
+			}
+		})
+	}
+
+	t.Run("Bearer token takes precedence over cookie", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		req.AddCookie(&http.Cookie{Name: AccessTokenCookie, Value: tokens.AccessToken})
+		req.Header.Set("Authorization", "Bearer "+other.AccessToken)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, "user-2", w.Body.String())
+	})
+}
@@ -0,0 +1,298 @@
+package oidc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"web-server/internal/domain/constants"
+	"web-server/internal/domain/service"
+	"web-server/internal/infrastructure/config"
+
+	jwt "github.com/golang-jwt/jwt/v5"
+)
+
+// discoveryPath is where providers publish their metadata, relative to the issuer.
+const discoveryPath = "/.well-known/openid-configuration"
+
+// metadata is the part of the provider metadata the login needs.
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// tokenResponse is the response of the token endpoint.
+type tokenResponse struct {
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// idTokenClaims are the claims of an ID token the login uses.
+type idTokenClaims struct {
+	Nonce             string `json:"nonce"`
+	AuthorizedParty   string `json:"azp"`
+	Email             string `json:"email"`
+	EmailVerified     bool   `json:"email_verified"`
+	PreferredUsername string `json:"preferred_username"`
+	jwt.RegisteredClaims
+}
+
+// Provider is an OpenID Connect relying party for a single provider. The
+// metadata and keys of the provider are fetched on first use and cached; the
+// keys are fetched again when an ID token is signed with an unknown key.
+type Provider struct {
+	cfg    config.OIDCConfig
+	client *http.Client
+
+	mutex    sync.Mutex
+	metadata *metadata
+	keys     map[string]*publicKey
+}
+
+// New returns a provider for the configuration, which needs at least an
+// issuer, a client ID and a redirect URL. Nothing is fetched yet, so the
+// server starts even while the provider is unreachable.
+func New(cfg config.OIDCConfig) (*Provider, error) {
+	if cfg.Issuer == "" || cfg.ClientID == "" || cfg.RedirectURL == "" {
+		return nil, errors.New("OIDC_ISSUER, OIDC_CLIENT_ID and OIDC_REDIRECT_URL are required for OIDC login")
+	}
+
+	return &Provider{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// AuthCodeURL returns the URL of the provider's login page for an
+// authorization code request with PKCE.
+func (p *Provider) AuthCodeURL(state, nonce, codeChallenge string) (string, error) {
+	meta, err := p.discover()
+	if err != nil {
+		return "", err
+	}
+
+	endpoint, err := url.Parse(meta.AuthorizationEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("OIDC authorization endpoint: %w", err)
+	}
+
+	query := endpoint.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", p.cfg.ClientID)
+	query.Set("redirect_uri", p.cfg.RedirectURL)
+	query.Set("scope", strings.Join(p.scopes(), " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", codeChallenge)
+	query.Set("code_challenge_method", "S256")
+	endpoint.RawQuery = query.Encode()
+
+	return endpoint.String(), nil
+}
+
+// Exchange redeems an authorization code at the token endpoint and verifies
+// the ID token in the response.
+func (p *Provider) Exchange(code, codeVerifier, nonce string) (*service.ExternalIdentity, error) {
+	meta, err := p.discover()
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.cfg.RedirectURL},
+		"code_verifier": {codeVerifier},
+	}
+	// Public clients have no secret and only name themselves
+	if p.cfg.ClientSecret == "" {
+		form.Set("client_id", p.cfg.ClientID)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("OIDC token request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var token tokenResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&token); err != nil {
+		return nil, fmt.Errorf("OIDC token response: %w", err)
+	}
+
+	// Invalid, expired or reused codes and wrong code verifiers end up here
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %s %s", constants.ErrOIDCCodeExchange, token.Error, token.ErrorDescription)
+	}
+	if token.IDToken == "" {
+		return nil, fmt.Errorf("%w: no ID token in the response", constants.ErrOIDCCodeExchange)
+	}
+
+	return p.verifyIDToken(token.IDToken, nonce)
+}
+
+// verifyIDToken checks the signature, issuer, audience, expiry and nonce of an
+// ID token and returns the identity it asserts.
+func (p *Provider) verifyIDToken(idToken, nonce string) (*service.ExternalIdentity, error) {
+	claims := &idTokenClaims{}
+	_, err := jwt.ParseWithClaims(idToken, claims, p.keyfunc,
+		jwt.WithValidMethods(supportedAlgorithms),
+		jwt.WithIssuer(p.cfg.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", constants.ErrInvalidIDToken, err)
+	}
+
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: no subject", constants.ErrInvalidIDToken)
+	}
+	// The nonce ties the token to the login started by this browser
+	if claims.Nonce != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", constants.ErrInvalidIDToken)
+	}
+	if len(claims.Audience) > 1 && claims.AuthorizedParty != p.cfg.ClientID {
+		return nil, fmt.Errorf("%w: issued to another party", constants.ErrInvalidIDToken)
+	}
+
+	return &service.ExternalIdentity{
+		Issuer:            claims.Issuer,
+		Subject:           claims.Subject,
+		Email:             claims.Email,
+		EmailVerified:     claims.EmailVerified,
+		PreferredUsername: claims.PreferredUsername,
+	}, nil
+}
+
+// keyfunc looks up the provider key an ID token is signed with by its kid
+// header, fetching the keys again if the provider has rotated them.
+func (p *Provider) keyfunc(token *jwt.Token) (interface{}, error) {
+	id, _ := token.Header["kid"].(string)
+
+	key, err := p.lookupKey(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Never let the token pick the algorithm, or a key could be used with
+	// another algorithm than it is meant for
+	if !key.allows(token.Method) {
+		return nil, constants.ErrUnexpectedSigningMethod
+	}
+
+	return key.key, nil
+}
+
+func (p *Provider) lookupKey(id string) (*publicKey, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if key, ok := findKey(p.keys, id); ok {
+		return key, nil
+	}
+
+	meta, err := p.discoverLocked()
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := p.fetchKeys(meta.JWKSURI)
+	if err != nil {
+		return nil, err
+	}
+	p.keys = keys
+
+	if key, ok := findKey(p.keys, id); ok {
+		return key, nil
+	}
+	return nil, constants.ErrSigningKeyNotFound
+}
+
+// findKey returns the key with the ID. Tokens without a kid can only be
+// checked if the provider has a single key.
+func findKey(keys map[string]*publicKey, id string) (*publicKey, bool) {
+	if id == "" && len(keys) == 1 {
+		for _, key := range keys {
+			return key, true
+		}
+	}
+
+	key, ok := keys[id]
+	return key, ok
+}
+
+func (p *Provider) discover() (*metadata, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.discoverLocked()
+}
+
+// discoverLocked fetches the provider metadata unless it is cached. The
+// caller must hold the mutex.
+func (p *Provider) discoverLocked() (*metadata, error) {
+	if p.metadata != nil {
+		return p.metadata, nil
+	}
+
+	var meta metadata
+	if err := p.getJSON(strings.TrimSuffix(p.cfg.Issuer, "/")+discoveryPath, &meta); err != nil {
+		return nil, fmt.Errorf("OIDC discovery: %w", err)
+	}
+
+	// A provider may only speak for its own issuer
+	if meta.Issuer != p.cfg.Issuer {
+		return nil, fmt.Errorf("OIDC discovery: issuer %q does not match %q", meta.Issuer, p.cfg.Issuer)
+	}
+	if meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" || meta.JWKSURI == "" {
+		return nil, errors.New("OIDC discovery: incomplete provider metadata")
+	}
+
+	p.metadata = &meta
+	return p.metadata, nil
+}
+
+func (p *Provider) getJSON(endpoint string, v interface{}) error {
+	resp, err := p.client.Get(endpoint)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s from %s", resp.Status, endpoint)
+	}
+
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v)
+}
+
+// scopes returns the configured scopes, which always include openid.
+func (p *Provider) scopes() []string {
+	for _, scope := range p.cfg.Scopes {
+		if scope == "openid" {
+			return p.cfg.Scopes
+		}
+	}
+	return append([]string{"openid"}, p.cfg.Scopes...)
+}
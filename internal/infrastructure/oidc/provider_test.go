@@ -0,0 +1,161 @@
+package oidc
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"web-server/internal/domain/constants"
+	"web-server/internal/infrastructure/middleware"
+	"web-server/internal/infrastructure/oidc/oidctest"
+
+	jwt "github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testRedirectURL = "https://app.example.com/oidc/callback"
+
+func newTestProvider(t *testing.T) (*Provider, *oidctest.Provider) {
+	t.Helper()
+	fake, err := oidctest.NewProvider("web-server", "client-secret")
+	require.NoError(t, err)
+	t.Cleanup(fake.Close)
+
+	fake.SignIn(oidctest.User{
+		Subject:           "ext-1",
+		Email:             "user@example.com",
+		EmailVerified:     true,
+		PreferredUsername: "ext-user",
+	})
+
+	provider, err := New(fake.Config(testRedirectURL))
+	require.NoError(t, err)
+	return provider, fake
+}
+
+// login runs the flow up to the callback and returns the code.
+func login(t *testing.T, provider *Provider, fake *oidctest.Provider, verifier, nonce string) string {
+	t.Helper()
+	sum := sha256.Sum256([]byte(verifier))
+
+	authURL, err := provider.AuthCodeURL("state-1", nonce, base64.RawURLEncoding.EncodeToString(sum[:]))
+	require.NoError(t, err)
+
+	callback, err := fake.Authorize(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, "state-1", callback.Query().Get("state"))
+	return callback.Query().Get("code")
+}
+
+func TestProvider_Exchange(t *testing.T) {
+	provider, fake := newTestProvider(t)
+
+	code := login(t, provider, fake, "verifier-1", "nonce-1")
+	identity, err := provider.Exchange(code, "verifier-1", "nonce-1")
+	require.NoError(t, err)
+	assert.Equal(t, fake.Issuer(), identity.Issuer)
+	assert.Equal(t, "ext-1", identity.Subject)
+	assert.Equal(t, "user@example.com", identity.Email)
+	assert.True(t, identity.EmailVerified)
+	assert.Equal(t, "ext-user", identity.PreferredUsername)
+
+	// Codes work once
+	_, err = provider.Exchange(code, "verifier-1", "nonce-1")
+	assert.ErrorIs(t, err, constants.ErrOIDCCodeExchange)
+}
+
+func TestProvider_Exchange_RejectsWrongVerifier(t *testing.T) {
+	provider, fake := newTestProvider(t)
+
+	code := login(t, provider, fake, "verifier-1", "nonce-1")
+	_, err := provider.Exchange(code, "intercepted", "nonce-1")
+	assert.ErrorIs(t, err, constants.ErrOIDCCodeExchange)
+}
+
+func TestProvider_Exchange_RejectsInvalidIDTokens(t *testing.T) {
+	tests := []struct {
+		name   string
+		nonce  string
+		mutate func(claims jwt.MapClaims)
+	}{
+		{name: "Other nonce", nonce: "nonce-2"},
+		{name: "Other issuer", nonce: "nonce-1", mutate: func(claims jwt.MapClaims) { claims["iss"] = "https://evil.example.com" }},
+		{name: "Other audience", nonce: "nonce-1", mutate: func(claims jwt.MapClaims) { claims["aud"] = "other-client" }},
+		{name: "Expired", nonce: "nonce-1", mutate: func(claims jwt.MapClaims) { claims["exp"] = time.Now().Add(-time.Minute).Unix() }},
+		{name: "No subject", nonce: "nonce-1", mutate: func(claims jwt.MapClaims) { delete(claims, "sub") }},
+		{
+			name:  "Other authorized party",
+			nonce: "nonce-1",
+			mutate: func(claims jwt.MapClaims) {
+				claims["aud"] = []string{"web-server", "other-client"}
+				claims["azp"] = "other-client"
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, fake := newTestProvider(t)
+			fake.Mutate = tt.mutate
+
+			code := login(t, provider, fake, "verifier-1", "nonce-1")
+			_, err := provider.Exchange(code, "verifier-1", tt.nonce)
+			assert.ErrorIs(t, err, constants.ErrInvalidIDToken)
+		})
+	}
+}
+
+func TestNew_RequiresConfiguration(t *testing.T) {
+	_, fake := newTestProvider(t)
+
+	cfg := fake.Config(testRedirectURL)
+	cfg.ClientID = ""
+	_, err := New(cfg)
+	assert.Error(t, err)
+
+	// The provider must speak for the configured issuer
+	cfg = fake.Config(testRedirectURL)
+	cfg.Issuer += "/"
+	provider, err := New(cfg)
+	require.NoError(t, err)
+	_, err = provider.AuthCodeURL("state", "nonce", "challenge")
+	assert.Error(t, err)
+}
+
+func TestParseJSONWebKey(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	ring := middleware.NewKeyRing()
+	require.NoError(t, ring.Add(&middleware.SigningKey{ID: "ec", Method: jwt.SigningMethodES384, PrivateKey: ecKey, PublicKey: &ecKey.PublicKey}))
+	require.NoError(t, ring.Add(&middleware.SigningKey{ID: "ed", Method: jwt.SigningMethodEdDSA, PrivateKey: edPrivate, PublicKey: edPublic}))
+
+	for _, published := range ring.JWKS().Keys {
+		key, err := parseJSONWebKey(jsonWebKey{
+			Kty: published.Kty, Kid: published.Kid, Alg: published.Alg,
+			N: published.N, E: published.E, Crv: published.Crv, X: published.X, Y: published.Y,
+		})
+		require.NoError(t, err, published.Kid)
+
+		switch published.Kid {
+		case "ec":
+			assert.True(t, ecKey.PublicKey.Equal(key))
+			assert.True(t, (&publicKey{key: key}).allows(jwt.SigningMethodES384))
+			assert.False(t, (&publicKey{key: key}).allows(jwt.SigningMethodES256))
+		case "ed":
+			assert.True(t, edPublic.Equal(key))
+			assert.False(t, (&publicKey{key: key}).allows(jwt.SigningMethodRS256))
+		}
+	}
+
+	_, err = parseJSONWebKey(jsonWebKey{Kty: "oct", Kid: "secret"})
+	assert.Error(t, err)
+}
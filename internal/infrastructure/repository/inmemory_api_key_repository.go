@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"web-server/internal/domain/constants"
+	"web-server/internal/domain/entity"
+)
+
+type InMemoryAPIKeyRepository struct {
+	keys  map[string]*entity.APIKey
+	mutex sync.RWMutex
+}
+
+func NewInMemoryAPIKeyRepository() *InMemoryAPIKeyRepository {
+	return &InMemoryAPIKeyRepository{
+		keys: make(map[string]*entity.APIKey),
+	}
+}
+
+func (r *InMemoryAPIKeyRepository) Create(key *entity.APIKey) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	stored := *key
+	r.keys[key.ID] = &stored
+	return nil
+}
+
+func (r *InMemoryAPIKeyRepository) GetByID(id string) (*entity.APIKey, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	key, exists := r.keys[id]
+	if !exists {
+		return nil, constants.ErrAPIKeyNotFound
+	}
+
+	found := *key
+	return &found, nil
+}
+
+func (r *InMemoryAPIKeyRepository) GetByHash(keyHash string) (*entity.APIKey, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, key := range r.keys {
+		if key.KeyHash == keyHash {
+			found := *key
+			return &found, nil
+		}
+	}
+
+	return nil, constants.ErrAPIKeyNotFound
+}
+
+func (r *InMemoryAPIKeyRepository) ListByUser(userID string) ([]*entity.APIKey, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	keys := make([]*entity.APIKey, 0)
+	for _, key := range r.keys {
+		if key.UserID == userID {
+			found := *key
+			keys = append(keys, &found)
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+	})
+
+	return keys, nil
+}
+
+func (r *InMemoryAPIKeyRepository) Revoke(id string, revokedAt time.Time) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	key, exists := r.keys[id]
+	if !exists {
+		return constants.ErrAPIKeyNotFound
+	}
+
+	if key.RevokedAt == nil {
+		key.RevokedAt = &revokedAt
+	}
+	return nil
+}
+
+func (r *InMemoryAPIKeyRepository) TouchLastUsed(id string, usedAt time.Time) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	key, exists := r.keys[id]
+	if !exists {
+		return constants.ErrAPIKeyNotFound
+	}
+
+	key.LastUsedAt = &usedAt
+	return nil
+}
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"sync"
+
+	"web-server/internal/domain/entity"
+)
+
+type InMemoryAuditRepository struct {
+	entries []*entity.AuditEntry
+	mutex   sync.RWMutex
+}
+
+func NewInMemoryAuditRepository() *InMemoryAuditRepository {
+	return &InMemoryAuditRepository{}
+}
+
+func (r *InMemoryAuditRepository) Create(entry *entity.AuditEntry) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	stored := *entry
+	r.entries = append(r.entries, &stored)
+	return nil
+}
+
+func (r *InMemoryAuditRepository) ListByUser(userID string) ([]*entity.AuditEntry, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	entries := make([]*entity.AuditEntry, 0)
+	for _, entry := range r.entries {
+		if entry.UserID == userID {
+			found := *entry
+			entries = append(entries, &found)
+		}
+	}
+
+	return entries, nil
+}
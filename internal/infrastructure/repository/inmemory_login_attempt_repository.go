@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"sync"
+	"time"
+
+	"web-server/internal/domain/constants"
+	"web-server/internal/domain/entity"
+)
+
+type InMemoryLoginAttemptRepository struct {
+	attempts map[string]*entity.LoginAttempt
+	mutex    sync.RWMutex
+}
+
+func NewInMemoryLoginAttemptRepository() *InMemoryLoginAttemptRepository {
+	return &InMemoryLoginAttemptRepository{
+		attempts: make(map[string]*entity.LoginAttempt),
+	}
+}
+
+func (r *InMemoryLoginAttemptRepository) Get(key string) (*entity.LoginAttempt, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	attempt, exists := r.attempts[key]
+	if !exists {
+		return nil, constants.ErrLoginAttemptNotFound
+	}
+
+	found := *attempt
+	return &found, nil
+}
+
+func (r *InMemoryLoginAttemptRepository) SwapFailures(key string, old, failures int, at time.Time) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	attempt, exists := r.attempts[key]
+	if !exists {
+		attempt = &entity.LoginAttempt{Key: key}
+	}
+	if attempt.Failures != old {
+		return constants.ErrLoginAttemptChanged
+	}
+
+	attempt.Failures = failures
+	attempt.LastFailureAt = at
+	r.attempts[key] = attempt
+	return nil
+}
+
+func (r *InMemoryLoginAttemptRepository) ForgetFailure(key string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if attempt, exists := r.attempts[key]; exists && attempt.Failures > 0 {
+		attempt.Failures--
+	}
+	return nil
+}
+
+func (r *InMemoryLoginAttemptRepository) Reset(key string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	delete(r.attempts, key)
+	return nil
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"sync"
+	"time"
+
+	"web-server/internal/domain/constants"
+	"web-server/internal/domain/entity"
+)
+
+type InMemoryLoginLinkRepository struct {
+	links map[string]*entity.LoginLink
+	mutex sync.RWMutex
+}
+
+func NewInMemoryLoginLinkRepository() *InMemoryLoginLinkRepository {
+	return &InMemoryLoginLinkRepository{
+		links: make(map[string]*entity.LoginLink),
+	}
+}
+
+func (r *InMemoryLoginLinkRepository) Create(link *entity.LoginLink) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	stored := *link
+	r.links[link.ID] = &stored
+	return nil
+}
+
+func (r *InMemoryLoginLinkRepository) GetByID(id string) (*entity.LoginLink, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	link, exists := r.links[id]
+	if !exists {
+		return nil, constants.ErrLoginLinkNotFound
+	}
+
+	found := *link
+	return &found, nil
+}
+
+func (r *InMemoryLoginLinkRepository) MarkUsed(id string, usedAt time.Time) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	link, exists := r.links[id]
+	if !exists {
+		return constants.ErrLoginLinkNotFound
+	}
+
+	if link.UsedAt != nil {
+		return constants.ErrInvalidLoginLink
+	}
+
+	link.UsedAt = &usedAt
+	return nil
+}
+
+func (r *InMemoryLoginLinkRepository) DeleteByUser(userID string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for id, link := range r.links {
+		if link.UserID == userID {
+			delete(r.links, id)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"sort"
+	"sync"
+)
+
+type InMemoryMFAPolicyRepository struct {
+	roles map[string]struct{}
+	mutex sync.RWMutex
+}
+
+func NewInMemoryMFAPolicyRepository() *InMemoryMFAPolicyRepository {
+	return &InMemoryMFAPolicyRepository{
+		roles: make(map[string]struct{}),
+	}
+}
+
+func (r *InMemoryMFAPolicyRepository) ListRequiredRoles() ([]string, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	roles := make([]string, 0, len(r.roles))
+	for role := range r.roles {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+
+	return roles, nil
+}
+
+func (r *InMemoryMFAPolicyRepository) SetRoleRequired(role string, required bool) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if required {
+		r.roles[role] = struct{}{}
+	} else {
+		delete(r.roles, role)
+	}
+
+	return nil
+}
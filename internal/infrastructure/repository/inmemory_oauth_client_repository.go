@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"web-server/internal/domain/constants"
+	"web-server/internal/domain/entity"
+)
+
+type InMemoryOAuthClientRepository struct {
+	clients map[string]*entity.OAuthClient
+	mutex   sync.RWMutex
+}
+
+func NewInMemoryOAuthClientRepository() *InMemoryOAuthClientRepository {
+	return &InMemoryOAuthClientRepository{
+		clients: make(map[string]*entity.OAuthClient),
+	}
+}
+
+func (r *InMemoryOAuthClientRepository) Create(client *entity.OAuthClient) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	stored := *client
+	r.clients[client.ID] = &stored
+	return nil
+}
+
+func (r *InMemoryOAuthClientRepository) GetByID(id string) (*entity.OAuthClient, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	client, exists := r.clients[id]
+	if !exists {
+		return nil, constants.ErrOAuthClientNotFound
+	}
+
+	found := *client
+	return &found, nil
+}
+
+func (r *InMemoryOAuthClientRepository) List() ([]*entity.OAuthClient, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	clients := make([]*entity.OAuthClient, 0, len(r.clients))
+	for _, client := range r.clients {
+		found := *client
+		clients = append(clients, &found)
+	}
+
+	sort.Slice(clients, func(i, j int) bool {
+		return clients[i].CreatedAt.Before(clients[j].CreatedAt)
+	})
+
+	return clients, nil
+}
+
+func (r *InMemoryOAuthClientRepository) Revoke(id string, revokedAt time.Time) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	client, exists := r.clients[id]
+	if !exists {
+		return constants.ErrOAuthClientNotFound
+	}
+
+	if client.RevokedAt == nil {
+		client.RevokedAt = &revokedAt
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"sync"
+	"time"
+
+	"web-server/internal/domain/constants"
+	"web-server/internal/domain/entity"
+)
+
+type InMemoryPasswordResetTokenRepository struct {
+	tokens map[string]*entity.PasswordResetToken
+	mutex  sync.RWMutex
+}
+
+func NewInMemoryPasswordResetTokenRepository() *InMemoryPasswordResetTokenRepository {
+	return &InMemoryPasswordResetTokenRepository{
+		tokens: make(map[string]*entity.PasswordResetToken),
+	}
+}
+
+func (r *InMemoryPasswordResetTokenRepository) Create(token *entity.PasswordResetToken) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	stored := *token
+	r.tokens[token.ID] = &stored
+	return nil
+}
+
+func (r *InMemoryPasswordResetTokenRepository) GetByHash(tokenHash string) (*entity.PasswordResetToken, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, token := range r.tokens {
+		if token.TokenHash == tokenHash {
+			found := *token
+			return &found, nil
+		}
+	}
+
+	return nil, constants.ErrPasswordResetTokenNotFound
+}
+
+func (r *InMemoryPasswordResetTokenRepository) MarkUsed(id string, usedAt time.Time) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	token, exists := r.tokens[id]
+	if !exists {
+		return constants.ErrPasswordResetTokenNotFound
+	}
+
+	if token.UsedAt != nil {
+		return constants.ErrInvalidResetToken
+	}
+
+	token.UsedAt = &usedAt
+	return nil
+}
+
+func (r *InMemoryPasswordResetTokenRepository) DeleteByUser(userID string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for id, token := range r.tokens {
+		if token.UserID == userID {
+			delete(r.tokens, id)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"sync"
+	"time"
+
+	"web-server/internal/domain/constants"
+	"web-server/internal/domain/entity"
+)
+
+type InMemoryRefreshTokenRepository struct {
+	tokens map[string]*entity.RefreshToken
+	mutex  sync.RWMutex
+}
+
+func NewInMemoryRefreshTokenRepository() *InMemoryRefreshTokenRepository {
+	return &InMemoryRefreshTokenRepository{
+		tokens: make(map[string]*entity.RefreshToken),
+	}
+}
+
+func (r *InMemoryRefreshTokenRepository) Create(token *entity.RefreshToken) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	stored := *token
+	r.tokens[token.ID] = &stored
+	return nil
+}
+
+func (r *InMemoryRefreshTokenRepository) GetByID(id string) (*entity.RefreshToken, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	token, exists := r.tokens[id]
+	if !exists {
+		return nil, constants.ErrRefreshTokenNotFound
+	}
+
+	found := *token
+	return &found, nil
+}
+
+func (r *InMemoryRefreshTokenRepository) MarkUsed(id string, usedAt time.Time) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	token, exists := r.tokens[id]
+	if !exists {
+		return constants.ErrRefreshTokenNotFound
+	}
+
+	if token.UsedAt != nil {
+		return constants.ErrRefreshTokenReused
+	}
+
+	token.UsedAt = &usedAt
+	return nil
+}
+
+func (r *InMemoryRefreshTokenRepository) RevokeFamily(familyID string, revokedAt time.Time) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, token := range r.tokens {
+		if token.FamilyID == familyID && token.RevokedAt == nil {
+			token.RevokedAt = &revokedAt
+		}
+	}
+
+	return nil
+}
+
+func (r *InMemoryRefreshTokenRepository) RevokeByUser(userID string, revokedAt time.Time) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, token := range r.tokens {
+		if token.UserID == userID && token.RevokedAt == nil {
+			token.RevokedAt = &revokedAt
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package repository
+
+import "sync"
+
+type InMemoryRolePermissionRepository struct {
+	roles map[string][]string
+	mutex sync.RWMutex
+}
+
+func NewInMemoryRolePermissionRepository() *InMemoryRolePermissionRepository {
+	return &InMemoryRolePermissionRepository{
+		roles: make(map[string][]string),
+	}
+}
+
+func (r *InMemoryRolePermissionRepository) ListRoles() (map[string][]string, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	roles := make(map[string][]string, len(r.roles))
+	for role, permissions := range r.roles {
+		roles[role] = append([]string{}, permissions...)
+	}
+
+	return roles, nil
+}
+
+func (r *InMemoryRolePermissionRepository) SetPermissions(role string, permissions []string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.roles[role] = append([]string{}, permissions...)
+	return nil
+}
+
+func (r *InMemoryRolePermissionRepository) DeleteRole(role string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	delete(r.roles, role)
+	return nil
+}
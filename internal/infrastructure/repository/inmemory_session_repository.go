@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"web-server/internal/domain/constants"
+	"web-server/internal/domain/entity"
+)
+
+type InMemorySessionRepository struct {
+	sessions map[string]*entity.Session
+	mutex    sync.RWMutex
+}
+
+func NewInMemorySessionRepository() *InMemorySessionRepository {
+	return &InMemorySessionRepository{
+		sessions: make(map[string]*entity.Session),
+	}
+}
+
+func (r *InMemorySessionRepository) Create(session *entity.Session) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	stored := *session
+	r.sessions[session.ID] = &stored
+	return nil
+}
+
+func (r *InMemorySessionRepository) GetByID(id string) (*entity.Session, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	session, exists := r.sessions[id]
+	if !exists {
+		return nil, constants.ErrSessionNotFound
+	}
+
+	found := *session
+	return &found, nil
+}
+
+func (r *InMemorySessionRepository) ListByUser(userID string) ([]*entity.Session, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	sessions := make([]*entity.Session, 0)
+	for _, session := range r.sessions {
+		if session.UserID == userID {
+			found := *session
+			sessions = append(sessions, &found)
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].CreatedAt.After(sessions[j].CreatedAt)
+	})
+
+	return sessions, nil
+}
+
+func (r *InMemorySessionRepository) Touch(id string, refreshedAt, expiresAt time.Time) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if session, exists := r.sessions[id]; exists {
+		session.LastRefreshedAt = refreshedAt
+		session.ExpiresAt = expiresAt
+	}
+
+	return nil
+}
+
+func (r *InMemorySessionRepository) Revoke(id string, revokedAt time.Time) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if session, exists := r.sessions[id]; exists && session.RevokedAt == nil {
+		session.RevokedAt = &revokedAt
+	}
+
+	return nil
+}
+
+func (r *InMemorySessionRepository) RevokeByUser(userID string, revokedAt time.Time) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, session := range r.sessions {
+		if session.UserID == userID && session.RevokedAt == nil {
+			session.RevokedAt = &revokedAt
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"sync"
+	"time"
+)
+
+type InMemoryTokenRevocationRepository struct {
+	revokedTokens map[string]time.Time // jti -> token expiry
+	cutoffs       map[string]time.Time // user ID -> tokens valid after
+	mutex         sync.RWMutex
+}
+
+func NewInMemoryTokenRevocationRepository() *InMemoryTokenRevocationRepository {
+	return &InMemoryTokenRevocationRepository{
+		revokedTokens: make(map[string]time.Time),
+		cutoffs:       make(map[string]time.Time),
+	}
+}
+
+func (r *InMemoryTokenRevocationRepository) RevokeToken(jti string, expiresAt time.Time) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	// Expired tokens are rejected anyway, so there is no need to remember them
+	now := time.Now()
+	for id, exp := range r.revokedTokens {
+		if exp.Before(now) {
+			delete(r.revokedTokens, id)
+		}
+	}
+
+	r.revokedTokens[jti] = expiresAt
+	return nil
+}
+
+func (r *InMemoryTokenRevocationRepository) RevokeTokenOnce(jti string, expiresAt time.Time) (bool, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, revoked := r.revokedTokens[jti]; revoked {
+		return false, nil
+	}
+
+	r.revokedTokens[jti] = expiresAt
+	return true, nil
+}
+
+func (r *InMemoryTokenRevocationRepository) IsTokenRevoked(jti string) (bool, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	_, revoked := r.revokedTokens[jti]
+	return revoked, nil
+}
+
+func (r *InMemoryTokenRevocationRepository) RevokeAllForUser(userID string, validAfter time.Time) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.cutoffs[userID] = validAfter
+	return nil
+}
+
+func (r *InMemoryTokenRevocationRepository) GetTokensValidAfter(userID string) (time.Time, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.cutoffs[userID], nil
+}
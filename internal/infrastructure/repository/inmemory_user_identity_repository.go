@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"sync"
+
+	"web-server/internal/domain/constants"
+	"web-server/internal/domain/entity"
+)
+
+type InMemoryUserIdentityRepository struct {
+	identities map[string]*entity.UserIdentity
+	mutex      sync.RWMutex
+}
+
+func NewInMemoryUserIdentityRepository() *InMemoryUserIdentityRepository {
+	return &InMemoryUserIdentityRepository{
+		identities: make(map[string]*entity.UserIdentity),
+	}
+}
+
+func (r *InMemoryUserIdentityRepository) Create(identity *entity.UserIdentity) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	stored := *identity
+	r.identities[identity.ID] = &stored
+	return nil
+}
+
+func (r *InMemoryUserIdentityRepository) GetBySubject(issuer, subject string) (*entity.UserIdentity, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, identity := range r.identities {
+		if identity.Issuer == issuer && identity.Subject == subject {
+			found := *identity
+			return &found, nil
+		}
+	}
+
+	return nil, constants.ErrUserIdentityNotFound
+}
+
+func (r *InMemoryUserIdentityRepository) Delete(id string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.identities[id]; !exists {
+		return constants.ErrUserIdentityNotFound
+	}
+
+	delete(r.identities, id)
+	return nil
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"slices"
 	"sync"
 
 	"web-server/internal/domain/constants"
@@ -26,7 +27,8 @@ func (r *InMemoryUserRepository) Create(user *entity.User) error {
 		return constants.ErrUserAlreadyExists
 	}
 
-	r.users[user.ID] = user
+	stored := *user
+	r.users[user.ID] = &stored
 	return nil
 }
 
@@ -39,21 +41,47 @@ func (r *InMemoryUserRepository) GetByID(id string) (*entity.User, error) {
 		return nil, constants.ErrUserNotFound
 	}
 
-	return user, nil
+	found := *user
+	return &found, nil
 }
 
 func (r *InMemoryUserRepository) Update(user *entity.User) error {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	if _, exists := r.users[user.ID]; !exists {
+	existing, exists := r.users[user.ID]
+	if !exists {
 		return constants.ErrUserNotFound
 	}
 
-	r.users[user.ID] = user
+	updated := *user
+	updated.MFA = existing.MFA
+	r.users[user.ID] = &updated
 	return nil
 }
 
+func (r *InMemoryUserRepository) SwapMFA(userID string, old, mfa entity.MFASettings) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	user, exists := r.users[userID]
+	if !exists || !sameMFA(user.MFA, old) {
+		return constants.ErrMFAChanged
+	}
+
+	updated := *user
+	updated.MFA = mfa
+	r.users[userID] = &updated
+	return nil
+}
+
+func sameMFA(a, b entity.MFASettings) bool {
+	return a.Enabled == b.Enabled &&
+		a.Secret == b.Secret &&
+		a.LastUsedStep == b.LastUsedStep &&
+		slices.Equal(a.RecoveryCodes, b.RecoveryCodes)
+}
+
 func (r *InMemoryUserRepository) Delete(id string) error {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
@@ -72,7 +100,8 @@ func (r *InMemoryUserRepository) List() ([]*entity.User, error) {
 
 	users := make([]*entity.User, 0, len(r.users))
 	for _, user := range r.users {
-		users = append(users, user)
+		found := *user
+		users = append(users, &found)
 	}
 
 	return users, nil
@@ -84,7 +113,8 @@ func (r *InMemoryUserRepository) GetByEmail(email string) (*entity.User, error)
 
 	for _, user := range r.users {
 		if user.Email == email {
-			return user, nil
+			found := *user
+			return &found, nil
 		}
 	}
 
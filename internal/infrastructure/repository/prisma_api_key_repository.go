@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"web-server/internal/domain/constants"
+	"web-server/internal/domain/entity"
+	"web-server/prisma/db"
+)
+
+type PrismaAPIKeyRepository struct {
+	client *db.PrismaClient
+	ctx    context.Context
+}
+
+func NewPrismaAPIKeyRepository(client *db.PrismaClient) *PrismaAPIKeyRepository {
+	return &PrismaAPIKeyRepository{
+		client: client,
+		ctx:    context.Background(),
+	}
+}
+
+func (r *PrismaAPIKeyRepository) Create(key *entity.APIKey) error {
+	params := []db.APIKeySetParam{
+		db.APIKey.ID.Set(key.ID),
+		db.APIKey.Scopes.Set(key.Scopes),
+	}
+	if key.ExpiresAt != nil {
+		params = append(params, db.APIKey.ExpiresAt.Set(*key.ExpiresAt))
+	}
+
+	_, err := r.client.APIKey.CreateOne(
+		db.APIKey.UserID.Set(key.UserID),
+		db.APIKey.Name.Set(key.Name),
+		db.APIKey.Prefix.Set(key.Prefix),
+		db.APIKey.KeyHash.Set(key.KeyHash),
+		params...,
+	).Exec(r.ctx)
+
+	return err
+}
+
+func (r *PrismaAPIKeyRepository) GetByID(id string) (*entity.APIKey, error) {
+	key, err := r.client.APIKey.FindUnique(
+		db.APIKey.ID.Equals(id),
+	).Exec(r.ctx)
+
+	return toAPIKeyResult(key, err)
+}
+
+func (r *PrismaAPIKeyRepository) GetByHash(keyHash string) (*entity.APIKey, error) {
+	key, err := r.client.APIKey.FindUnique(
+		db.APIKey.KeyHash.Equals(keyHash),
+	).Exec(r.ctx)
+
+	return toAPIKeyResult(key, err)
+}
+
+func (r *PrismaAPIKeyRepository) ListByUser(userID string) ([]*entity.APIKey, error) {
+	prismaKeys, err := r.client.APIKey.FindMany(
+		db.APIKey.UserID.Equals(userID),
+	).OrderBy(
+		db.APIKey.CreatedAt.Order(db.SortOrderAsc),
+	).Exec(r.ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]*entity.APIKey, len(prismaKeys))
+	for i := range prismaKeys {
+		keys[i] = toAPIKeyEntity(&prismaKeys[i])
+	}
+
+	return keys, nil
+}
+
+func (r *PrismaAPIKeyRepository) Revoke(id string, revokedAt time.Time) error {
+	_, err := r.client.APIKey.FindMany(
+		db.APIKey.ID.Equals(id),
+		db.APIKey.RevokedAt.IsNull(),
+	).Update(
+		db.APIKey.RevokedAt.Set(revokedAt),
+	).Exec(r.ctx)
+
+	return err
+}
+
+func (r *PrismaAPIKeyRepository) TouchLastUsed(id string, usedAt time.Time) error {
+	_, err := r.client.APIKey.FindUnique(
+		db.APIKey.ID.Equals(id),
+	).Update(
+		db.APIKey.LastUsedAt.Set(usedAt),
+	).Exec(r.ctx)
+
+	if errors.Is(err, db.ErrNotFound) {
+		return constants.ErrAPIKeyNotFound
+	}
+	return err
+}
+
+func toAPIKeyResult(key *db.APIKeyModel, err error) (*entity.APIKey, error) {
+	if errors.Is(err, db.ErrNotFound) {
+		return nil, constants.ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return toAPIKeyEntity(key), nil
+}
+
+func toAPIKeyEntity(key *db.APIKeyModel) *entity.APIKey {
+	result := &entity.APIKey{
+		ID:        key.ID,
+		UserID:    key.UserID,
+		Name:      key.Name,
+		Prefix:    key.Prefix,
+		KeyHash:   key.KeyHash,
+		Scopes:    key.Scopes,
+		CreatedAt: key.CreatedAt,
+	}
+
+	if expiresAt, ok := key.ExpiresAt(); ok {
+		result.ExpiresAt = &expiresAt
+	}
+	if lastUsedAt, ok := key.LastUsedAt(); ok {
+		result.LastUsedAt = &lastUsedAt
+	}
+	if revokedAt, ok := key.RevokedAt(); ok {
+		result.RevokedAt = &revokedAt
+	}
+
+	return result
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+
+	"web-server/internal/domain/entity"
+	"web-server/prisma/db"
+)
+
+type PrismaAuditRepository struct {
+	client *db.PrismaClient
+	ctx    context.Context
+}
+
+func NewPrismaAuditRepository(client *db.PrismaClient) *PrismaAuditRepository {
+	return &PrismaAuditRepository{
+		client: client,
+		ctx:    context.Background(),
+	}
+}
+
+func (r *PrismaAuditRepository) Create(entry *entity.AuditEntry) error {
+	_, err := r.client.AuditEntry.CreateOne(
+		db.AuditEntry.ActorID.Set(entry.ActorID),
+		db.AuditEntry.UserID.Set(entry.UserID),
+		db.AuditEntry.Action.Set(entry.Action),
+		db.AuditEntry.ID.Set(entry.ID),
+		db.AuditEntry.OldValue.Set(entry.OldValue),
+		db.AuditEntry.NewValue.Set(entry.NewValue),
+		db.AuditEntry.CreatedAt.Set(entry.CreatedAt),
+	).Exec(r.ctx)
+
+	return err
+}
+
+func (r *PrismaAuditRepository) ListByUser(userID string) ([]*entity.AuditEntry, error) {
+	stored, err := r.client.AuditEntry.FindMany(
+		db.AuditEntry.UserID.Equals(userID),
+	).OrderBy(
+		db.AuditEntry.CreatedAt.Order(db.SortOrderAsc),
+	).Exec(r.ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]*entity.AuditEntry, len(stored))
+	for i, entry := range stored {
+		entries[i] = &entity.AuditEntry{
+			ID:        entry.ID,
+			ActorID:   entry.ActorID,
+			UserID:    entry.UserID,
+			Action:    entry.Action,
+			OldValue:  entry.OldValue,
+			NewValue:  entry.NewValue,
+			CreatedAt: entry.CreatedAt,
+		}
+	}
+
+	return entries, nil
+}
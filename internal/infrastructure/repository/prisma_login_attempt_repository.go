@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"web-server/internal/domain/constants"
+	"web-server/internal/domain/entity"
+	"web-server/prisma/db"
+)
+
+type PrismaLoginAttemptRepository struct {
+	client *db.PrismaClient
+	ctx    context.Context
+}
+
+func NewPrismaLoginAttemptRepository(client *db.PrismaClient) *PrismaLoginAttemptRepository {
+	return &PrismaLoginAttemptRepository{
+		client: client,
+		ctx:    context.Background(),
+	}
+}
+
+func (r *PrismaLoginAttemptRepository) Get(key string) (*entity.LoginAttempt, error) {
+	attempt, err := r.client.LoginAttempt.FindUnique(
+		db.LoginAttempt.Key.Equals(key),
+	).Exec(r.ctx)
+
+	if errors.Is(err, db.ErrNotFound) {
+		return nil, constants.ErrLoginAttemptNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return toLoginAttemptEntity(attempt), nil
+}
+
+func (r *PrismaLoginAttemptRepository) SwapFailures(key string, old, failures int, at time.Time) error {
+	// Filtering on the failures makes the update a compare-and-set
+	result, err := r.client.LoginAttempt.FindMany(
+		db.LoginAttempt.Key.Equals(key),
+		db.LoginAttempt.Failures.Equals(old),
+	).Update(
+		db.LoginAttempt.Failures.Set(failures),
+		db.LoginAttempt.LastFailureAt.Set(at),
+	).Exec(r.ctx)
+	if err != nil {
+		return err
+	}
+	if result.Count > 0 {
+		return nil
+	}
+	if old != 0 {
+		return constants.ErrLoginAttemptChanged
+	}
+
+	// A key without failures may have no row yet. Of concurrent attempts
+	// creating it, the primary key lets only one succeed.
+	_, err = r.client.LoginAttempt.CreateOne(
+		db.LoginAttempt.Key.Set(key),
+		db.LoginAttempt.LastFailureAt.Set(at),
+		db.LoginAttempt.Failures.Set(failures),
+	).Exec(r.ctx)
+	if _, ok := db.IsErrUniqueConstraint(err); ok {
+		return constants.ErrLoginAttemptChanged
+	}
+
+	return err
+}
+
+func (r *PrismaLoginAttemptRepository) ForgetFailure(key string) error {
+	_, err := r.client.LoginAttempt.FindMany(
+		db.LoginAttempt.Key.Equals(key),
+		db.LoginAttempt.Failures.Gt(0),
+	).Update(
+		db.LoginAttempt.Failures.Decrement(1),
+	).Exec(r.ctx)
+
+	return err
+}
+
+func (r *PrismaLoginAttemptRepository) Reset(key string) error {
+	_, err := r.client.LoginAttempt.FindMany(
+		db.LoginAttempt.Key.Equals(key),
+	).Delete().Exec(r.ctx)
+
+	return err
+}
+
+func toLoginAttemptEntity(attempt *db.LoginAttemptModel) *entity.LoginAttempt {
+	return &entity.LoginAttempt{
+		Key:           attempt.Key,
+		Failures:      attempt.Failures,
+		LastFailureAt: attempt.LastFailureAt,
+	}
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"web-server/internal/domain/constants"
+	"web-server/internal/domain/entity"
+	"web-server/prisma/db"
+)
+
+type PrismaLoginLinkRepository struct {
+	client *db.PrismaClient
+	ctx    context.Context
+}
+
+func NewPrismaLoginLinkRepository(client *db.PrismaClient) *PrismaLoginLinkRepository {
+	return &PrismaLoginLinkRepository{
+		client: client,
+		ctx:    context.Background(),
+	}
+}
+
+func (r *PrismaLoginLinkRepository) Create(link *entity.LoginLink) error {
+	_, err := r.client.LoginLink.CreateOne(
+		db.LoginLink.ID.Set(link.ID),
+		db.LoginLink.UserID.Set(link.UserID),
+		db.LoginLink.ExpiresAt.Set(link.ExpiresAt),
+	).Exec(r.ctx)
+
+	return err
+}
+
+func (r *PrismaLoginLinkRepository) GetByID(id string) (*entity.LoginLink, error) {
+	link, err := r.client.LoginLink.FindUnique(
+		db.LoginLink.ID.Equals(id),
+	).Exec(r.ctx)
+
+	if errors.Is(err, db.ErrNotFound) {
+		return nil, constants.ErrLoginLinkNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := &entity.LoginLink{
+		ID:        link.ID,
+		UserID:    link.UserID,
+		ExpiresAt: link.ExpiresAt,
+		CreatedAt: link.CreatedAt,
+	}
+	if usedAt, ok := link.UsedAt(); ok {
+		result.UsedAt = &usedAt
+	}
+
+	return result, nil
+}
+
+func (r *PrismaLoginLinkRepository) MarkUsed(id string, usedAt time.Time) error {
+	// Filtering on used_at makes the update a compare-and-set, so a link
+	// cannot be redeemed twice by concurrent requests.
+	result, err := r.client.LoginLink.FindMany(
+		db.LoginLink.ID.Equals(id),
+		db.LoginLink.UsedAt.IsNull(),
+	).Update(
+		db.LoginLink.UsedAt.Set(usedAt),
+	).Exec(r.ctx)
+	if err != nil {
+		return err
+	}
+
+	if result.Count == 0 {
+		return constants.ErrInvalidLoginLink
+	}
+
+	return nil
+}
+
+func (r *PrismaLoginLinkRepository) DeleteByUser(userID string) error {
+	_, err := r.client.LoginLink.FindMany(
+		db.LoginLink.UserID.Equals(userID),
+	).Delete().Exec(r.ctx)
+
+	return err
+}
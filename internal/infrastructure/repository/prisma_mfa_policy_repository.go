@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+
+	"web-server/prisma/db"
+)
+
+type PrismaMFAPolicyRepository struct {
+	client *db.PrismaClient
+	ctx    context.Context
+}
+
+func NewPrismaMFAPolicyRepository(client *db.PrismaClient) *PrismaMFAPolicyRepository {
+	return &PrismaMFAPolicyRepository{
+		client: client,
+		ctx:    context.Background(),
+	}
+}
+
+func (r *PrismaMFAPolicyRepository) ListRequiredRoles() ([]string, error) {
+	policies, err := r.client.MfaRolePolicy.FindMany().OrderBy(
+		db.MfaRolePolicy.Role.Order(db.SortOrderAsc),
+	).Exec(r.ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	roles := make([]string, len(policies))
+	for i := range policies {
+		roles[i] = policies[i].Role
+	}
+
+	return roles, nil
+}
+
+func (r *PrismaMFAPolicyRepository) SetRoleRequired(role string, required bool) error {
+	if !required {
+		_, err := r.client.MfaRolePolicy.FindMany(
+			db.MfaRolePolicy.Role.Equals(role),
+		).Delete().Exec(r.ctx)
+		return err
+	}
+
+	_, err := r.client.MfaRolePolicy.UpsertOne(
+		db.MfaRolePolicy.Role.Equals(role),
+	).Create(
+		db.MfaRolePolicy.Role.Set(role),
+	).Update(
+		db.MfaRolePolicy.Role.Set(role),
+	).Exec(r.ctx)
+
+	return err
+}
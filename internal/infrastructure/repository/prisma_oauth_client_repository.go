@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"web-server/internal/domain/constants"
+	"web-server/internal/domain/entity"
+	"web-server/prisma/db"
+)
+
+type PrismaOAuthClientRepository struct {
+	client *db.PrismaClient
+	ctx    context.Context
+}
+
+func NewPrismaOAuthClientRepository(client *db.PrismaClient) *PrismaOAuthClientRepository {
+	return &PrismaOAuthClientRepository{
+		client: client,
+		ctx:    context.Background(),
+	}
+}
+
+func (r *PrismaOAuthClientRepository) Create(client *entity.OAuthClient) error {
+	_, err := r.client.OAuthClient.CreateOne(
+		db.OAuthClient.ID.Set(client.ID),
+		db.OAuthClient.Name.Set(client.Name),
+		db.OAuthClient.SecretHash.Set(client.SecretHash),
+		db.OAuthClient.Scopes.Set(client.Scopes),
+	).Exec(r.ctx)
+
+	return err
+}
+
+func (r *PrismaOAuthClientRepository) GetByID(id string) (*entity.OAuthClient, error) {
+	client, err := r.client.OAuthClient.FindUnique(
+		db.OAuthClient.ID.Equals(id),
+	).Exec(r.ctx)
+
+	if errors.Is(err, db.ErrNotFound) {
+		return nil, constants.ErrOAuthClientNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return toOAuthClientEntity(client), nil
+}
+
+func (r *PrismaOAuthClientRepository) List() ([]*entity.OAuthClient, error) {
+	prismaClients, err := r.client.OAuthClient.FindMany().OrderBy(
+		db.OAuthClient.CreatedAt.Order(db.SortOrderAsc),
+	).Exec(r.ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	clients := make([]*entity.OAuthClient, len(prismaClients))
+	for i := range prismaClients {
+		clients[i] = toOAuthClientEntity(&prismaClients[i])
+	}
+
+	return clients, nil
+}
+
+func (r *PrismaOAuthClientRepository) Revoke(id string, revokedAt time.Time) error {
+	_, err := r.client.OAuthClient.FindMany(
+		db.OAuthClient.ID.Equals(id),
+		db.OAuthClient.RevokedAt.IsNull(),
+	).Update(
+		db.OAuthClient.RevokedAt.Set(revokedAt),
+	).Exec(r.ctx)
+
+	return err
+}
+
+func toOAuthClientEntity(client *db.OAuthClientModel) *entity.OAuthClient {
+	result := &entity.OAuthClient{
+		ID:         client.ID,
+		Name:       client.Name,
+		SecretHash: client.SecretHash,
+		Scopes:     client.Scopes,
+		CreatedAt:  client.CreatedAt,
+	}
+
+	if revokedAt, ok := client.RevokedAt(); ok {
+		result.RevokedAt = &revokedAt
+	}
+
+	return result
+}
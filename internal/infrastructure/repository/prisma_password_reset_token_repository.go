@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"web-server/internal/domain/constants"
+	"web-server/internal/domain/entity"
+	"web-server/prisma/db"
+)
+
+type PrismaPasswordResetTokenRepository struct {
+	client *db.PrismaClient
+	ctx    context.Context
+}
+
+func NewPrismaPasswordResetTokenRepository(client *db.PrismaClient) *PrismaPasswordResetTokenRepository {
+	return &PrismaPasswordResetTokenRepository{
+		client: client,
+		ctx:    context.Background(),
+	}
+}
+
+func (r *PrismaPasswordResetTokenRepository) Create(token *entity.PasswordResetToken) error {
+	_, err := r.client.PasswordResetToken.CreateOne(
+		db.PasswordResetToken.ID.Set(token.ID),
+		db.PasswordResetToken.UserID.Set(token.UserID),
+		db.PasswordResetToken.TokenHash.Set(token.TokenHash),
+		db.PasswordResetToken.ExpiresAt.Set(token.ExpiresAt),
+	).Exec(r.ctx)
+
+	return err
+}
+
+func (r *PrismaPasswordResetTokenRepository) GetByHash(tokenHash string) (*entity.PasswordResetToken, error) {
+	token, err := r.client.PasswordResetToken.FindUnique(
+		db.PasswordResetToken.TokenHash.Equals(tokenHash),
+	).Exec(r.ctx)
+
+	if errors.Is(err, db.ErrNotFound) {
+		return nil, constants.ErrPasswordResetTokenNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := &entity.PasswordResetToken{
+		ID:        token.ID,
+		UserID:    token.UserID,
+		TokenHash: token.TokenHash,
+		ExpiresAt: token.ExpiresAt,
+		CreatedAt: token.CreatedAt,
+	}
+	if usedAt, ok := token.UsedAt(); ok {
+		result.UsedAt = &usedAt
+	}
+
+	return result, nil
+}
+
+func (r *PrismaPasswordResetTokenRepository) MarkUsed(id string, usedAt time.Time) error {
+	// Filtering on used_at makes the update a compare-and-set, so a token
+	// cannot be redeemed twice by concurrent requests.
+	result, err := r.client.PasswordResetToken.FindMany(
+		db.PasswordResetToken.ID.Equals(id),
+		db.PasswordResetToken.UsedAt.IsNull(),
+	).Update(
+		db.PasswordResetToken.UsedAt.Set(usedAt),
+	).Exec(r.ctx)
+	if err != nil {
+		return err
+	}
+
+	if result.Count == 0 {
+		return constants.ErrInvalidResetToken
+	}
+
+	return nil
+}
+
+func (r *PrismaPasswordResetTokenRepository) DeleteByUser(userID string) error {
+	_, err := r.client.PasswordResetToken.FindMany(
+		db.PasswordResetToken.UserID.Equals(userID),
+	).Delete().Exec(r.ctx)
+
+	return err
+}
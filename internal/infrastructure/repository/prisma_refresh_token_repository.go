@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"web-server/internal/domain/constants"
+	"web-server/internal/domain/entity"
+	"web-server/prisma/db"
+)
+
+type PrismaRefreshTokenRepository struct {
+	client *db.PrismaClient
+	ctx    context.Context
+}
+
+func NewPrismaRefreshTokenRepository(client *db.PrismaClient) *PrismaRefreshTokenRepository {
+	return &PrismaRefreshTokenRepository{
+		client: client,
+		ctx:    context.Background(),
+	}
+}
+
+func (r *PrismaRefreshTokenRepository) Create(token *entity.RefreshToken) error {
+	_, err := r.client.RefreshToken.CreateOne(
+		db.RefreshToken.ID.Set(token.ID),
+		db.RefreshToken.FamilyID.Set(token.FamilyID),
+		db.RefreshToken.UserID.Set(token.UserID),
+		db.RefreshToken.ExpiresAt.Set(token.ExpiresAt),
+	).Exec(r.ctx)
+
+	return err
+}
+
+func (r *PrismaRefreshTokenRepository) GetByID(id string) (*entity.RefreshToken, error) {
+	token, err := r.client.RefreshToken.FindUnique(
+		db.RefreshToken.ID.Equals(id),
+	).Exec(r.ctx)
+
+	if errors.Is(err, db.ErrNotFound) {
+		return nil, constants.ErrRefreshTokenNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return toRefreshTokenEntity(token), nil
+}
+
+func (r *PrismaRefreshTokenRepository) MarkUsed(id string, usedAt time.Time) error {
+	// Filtering on used_at makes the update a compare-and-set, so two
+	// concurrent refreshes of the same token cannot both succeed.
+	result, err := r.client.RefreshToken.FindMany(
+		db.RefreshToken.ID.Equals(id),
+		db.RefreshToken.UsedAt.IsNull(),
+	).Update(
+		db.RefreshToken.UsedAt.Set(usedAt),
+	).Exec(r.ctx)
+	if err != nil {
+		return err
+	}
+
+	if result.Count == 0 {
+		if _, err := r.GetByID(id); err != nil {
+			return err
+		}
+		return constants.ErrRefreshTokenReused
+	}
+
+	return nil
+}
+
+func (r *PrismaRefreshTokenRepository) RevokeFamily(familyID string, revokedAt time.Time) error {
+	_, err := r.client.RefreshToken.FindMany(
+		db.RefreshToken.FamilyID.Equals(familyID),
+		db.RefreshToken.RevokedAt.IsNull(),
+	).Update(
+		db.RefreshToken.RevokedAt.Set(revokedAt),
+	).Exec(r.ctx)
+
+	return err
+}
+
+func (r *PrismaRefreshTokenRepository) RevokeByUser(userID string, revokedAt time.Time) error {
+	_, err := r.client.RefreshToken.FindMany(
+		db.RefreshToken.UserID.Equals(userID),
+		db.RefreshToken.RevokedAt.IsNull(),
+	).Update(
+		db.RefreshToken.RevokedAt.Set(revokedAt),
+	).Exec(r.ctx)
+
+	return err
+}
+
+func toRefreshTokenEntity(token *db.RefreshTokenModel) *entity.RefreshToken {
+	result := &entity.RefreshToken{
+		ID:        token.ID,
+		FamilyID:  token.FamilyID,
+		UserID:    token.UserID,
+		ExpiresAt: token.ExpiresAt,
+		CreatedAt: token.CreatedAt,
+	}
+
+	if usedAt, ok := token.UsedAt(); ok {
+		result.UsedAt = &usedAt
+	}
+	if revokedAt, ok := token.RevokedAt(); ok {
+		result.RevokedAt = &revokedAt
+	}
+
+	return result
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+
+	"web-server/prisma/db"
+)
+
+type PrismaRolePermissionRepository struct {
+	client *db.PrismaClient
+	ctx    context.Context
+}
+
+func NewPrismaRolePermissionRepository(client *db.PrismaClient) *PrismaRolePermissionRepository {
+	return &PrismaRolePermissionRepository{
+		client: client,
+		ctx:    context.Background(),
+	}
+}
+
+func (r *PrismaRolePermissionRepository) ListRoles() (map[string][]string, error) {
+	stored, err := r.client.RolePermission.FindMany().Exec(r.ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	roles := make(map[string][]string, len(stored))
+	for _, role := range stored {
+		roles[role.Role] = role.Permissions
+	}
+
+	return roles, nil
+}
+
+func (r *PrismaRolePermissionRepository) SetPermissions(role string, permissions []string) error {
+	_, err := r.client.RolePermission.UpsertOne(
+		db.RolePermission.Role.Equals(role),
+	).Create(
+		db.RolePermission.Role.Set(role),
+		db.RolePermission.Permissions.Set(permissions),
+	).Update(
+		db.RolePermission.Permissions.Set(permissions),
+	).Exec(r.ctx)
+
+	return err
+}
+
+func (r *PrismaRolePermissionRepository) DeleteRole(role string) error {
+	_, err := r.client.RolePermission.FindMany(
+		db.RolePermission.Role.Equals(role),
+	).Delete().Exec(r.ctx)
+
+	return err
+}
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"web-server/internal/domain/constants"
+	"web-server/internal/domain/entity"
+	"web-server/prisma/db"
+)
+
+type PrismaSessionRepository struct {
+	client *db.PrismaClient
+	ctx    context.Context
+}
+
+func NewPrismaSessionRepository(client *db.PrismaClient) *PrismaSessionRepository {
+	return &PrismaSessionRepository{
+		client: client,
+		ctx:    context.Background(),
+	}
+}
+
+func (r *PrismaSessionRepository) Create(session *entity.Session) error {
+	_, err := r.client.Session.CreateOne(
+		db.Session.ID.Set(session.ID),
+		db.Session.UserID.Set(session.UserID),
+		db.Session.UserAgent.Set(session.UserAgent),
+		db.Session.IP.Set(session.IP),
+		db.Session.LastRefreshedAt.Set(session.LastRefreshedAt),
+		db.Session.ExpiresAt.Set(session.ExpiresAt),
+		db.Session.CreatedAt.Set(session.CreatedAt),
+	).Exec(r.ctx)
+
+	return err
+}
+
+func (r *PrismaSessionRepository) GetByID(id string) (*entity.Session, error) {
+	session, err := r.client.Session.FindUnique(
+		db.Session.ID.Equals(id),
+	).Exec(r.ctx)
+
+	if errors.Is(err, db.ErrNotFound) {
+		return nil, constants.ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return toSessionEntity(session), nil
+}
+
+func (r *PrismaSessionRepository) ListByUser(userID string) ([]*entity.Session, error) {
+	sessions, err := r.client.Session.FindMany(
+		db.Session.UserID.Equals(userID),
+	).OrderBy(
+		db.Session.CreatedAt.Order(db.SortOrderDesc),
+	).Exec(r.ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*entity.Session, len(sessions))
+	for i := range sessions {
+		result[i] = toSessionEntity(&sessions[i])
+	}
+
+	return result, nil
+}
+
+func (r *PrismaSessionRepository) Touch(id string, refreshedAt, expiresAt time.Time) error {
+	_, err := r.client.Session.FindMany(
+		db.Session.ID.Equals(id),
+	).Update(
+		db.Session.LastRefreshedAt.Set(refreshedAt),
+		db.Session.ExpiresAt.Set(expiresAt),
+	).Exec(r.ctx)
+
+	return err
+}
+
+func (r *PrismaSessionRepository) Revoke(id string, revokedAt time.Time) error {
+	_, err := r.client.Session.FindMany(
+		db.Session.ID.Equals(id),
+		db.Session.RevokedAt.IsNull(),
+	).Update(
+		db.Session.RevokedAt.Set(revokedAt),
+	).Exec(r.ctx)
+
+	return err
+}
+
+func (r *PrismaSessionRepository) RevokeByUser(userID string, revokedAt time.Time) error {
+	_, err := r.client.Session.FindMany(
+		db.Session.UserID.Equals(userID),
+		db.Session.RevokedAt.IsNull(),
+	).Update(
+		db.Session.RevokedAt.Set(revokedAt),
+	).Exec(r.ctx)
+
+	return err
+}
+
+func toSessionEntity(session *db.SessionModel) *entity.Session {
+	result := &entity.Session{
+		ID:              session.ID,
+		UserID:          session.UserID,
+		UserAgent:       session.UserAgent,
+		IP:              session.IP,
+		CreatedAt:       session.CreatedAt,
+		LastRefreshedAt: session.LastRefreshedAt,
+		ExpiresAt:       session.ExpiresAt,
+	}
+
+	if revokedAt, ok := session.RevokedAt(); ok {
+		result.RevokedAt = &revokedAt
+	}
+
+	return result
+}
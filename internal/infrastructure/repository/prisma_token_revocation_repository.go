@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"web-server/prisma/db"
+)
+
+type PrismaTokenRevocationRepository struct {
+	client *db.PrismaClient
+	ctx    context.Context
+}
+
+func NewPrismaTokenRevocationRepository(client *db.PrismaClient) *PrismaTokenRevocationRepository {
+	return &PrismaTokenRevocationRepository{
+		client: client,
+		ctx:    context.Background(),
+	}
+}
+
+func (r *PrismaTokenRevocationRepository) RevokeToken(jti string, expiresAt time.Time) error {
+	// Expired tokens are rejected anyway, so there is no need to remember them
+	if _, err := r.client.RevokedToken.FindMany(
+		db.RevokedToken.ExpiresAt.Lt(time.Now()),
+	).Delete().Exec(r.ctx); err != nil {
+		return err
+	}
+
+	_, err := r.client.RevokedToken.UpsertOne(
+		db.RevokedToken.ID.Equals(jti),
+	).Create(
+		db.RevokedToken.ID.Set(jti),
+		db.RevokedToken.ExpiresAt.Set(expiresAt),
+	).Update(
+		db.RevokedToken.ExpiresAt.Set(expiresAt),
+	).Exec(r.ctx)
+
+	return err
+}
+
+func (r *PrismaTokenRevocationRepository) RevokeTokenOnce(jti string, expiresAt time.Time) (bool, error) {
+	// Of concurrent requests creating the row, the primary key lets only one
+	// succeed
+	_, err := r.client.RevokedToken.CreateOne(
+		db.RevokedToken.ID.Set(jti),
+		db.RevokedToken.ExpiresAt.Set(expiresAt),
+	).Exec(r.ctx)
+	if _, ok := db.IsErrUniqueConstraint(err); ok {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (r *PrismaTokenRevocationRepository) IsTokenRevoked(jti string) (bool, error) {
+	_, err := r.client.RevokedToken.FindUnique(
+		db.RevokedToken.ID.Equals(jti),
+	).Exec(r.ctx)
+
+	if errors.Is(err, db.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (r *PrismaTokenRevocationRepository) RevokeAllForUser(userID string, validAfter time.Time) error {
+	_, err := r.client.UserTokenCutoff.UpsertOne(
+		db.UserTokenCutoff.UserID.Equals(userID),
+	).Create(
+		db.UserTokenCutoff.UserID.Set(userID),
+		db.UserTokenCutoff.ValidAfter.Set(validAfter),
+	).Update(
+		db.UserTokenCutoff.ValidAfter.Set(validAfter),
+	).Exec(r.ctx)
+
+	return err
+}
+
+func (r *PrismaTokenRevocationRepository) GetTokensValidAfter(userID string) (time.Time, error) {
+	cutoff, err := r.client.UserTokenCutoff.FindUnique(
+		db.UserTokenCutoff.UserID.Equals(userID),
+	).Exec(r.ctx)
+
+	if errors.Is(err, db.ErrNotFound) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return cutoff.ValidAfter, nil
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"web-server/internal/domain/constants"
+	"web-server/internal/domain/entity"
+	"web-server/prisma/db"
+)
+
+type PrismaUserIdentityRepository struct {
+	client *db.PrismaClient
+	ctx    context.Context
+}
+
+func NewPrismaUserIdentityRepository(client *db.PrismaClient) *PrismaUserIdentityRepository {
+	return &PrismaUserIdentityRepository{
+		client: client,
+		ctx:    context.Background(),
+	}
+}
+
+func (r *PrismaUserIdentityRepository) Create(identity *entity.UserIdentity) error {
+	_, err := r.client.UserIdentity.CreateOne(
+		db.UserIdentity.ID.Set(identity.ID),
+		db.UserIdentity.UserID.Set(identity.UserID),
+		db.UserIdentity.Issuer.Set(identity.Issuer),
+		db.UserIdentity.Subject.Set(identity.Subject),
+		db.UserIdentity.Email.Set(identity.Email),
+	).Exec(r.ctx)
+
+	return err
+}
+
+func (r *PrismaUserIdentityRepository) GetBySubject(issuer, subject string) (*entity.UserIdentity, error) {
+	identity, err := r.client.UserIdentity.FindFirst(
+		db.UserIdentity.Issuer.Equals(issuer),
+		db.UserIdentity.Subject.Equals(subject),
+	).Exec(r.ctx)
+
+	if errors.Is(err, db.ErrNotFound) {
+		return nil, constants.ErrUserIdentityNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.UserIdentity{
+		ID:        identity.ID,
+		UserID:    identity.UserID,
+		Issuer:    identity.Issuer,
+		Subject:   identity.Subject,
+		Email:     identity.Email,
+		CreatedAt: identity.CreatedAt,
+	}, nil
+}
+
+func (r *PrismaUserIdentityRepository) Delete(id string) error {
+	_, err := r.client.UserIdentity.FindUnique(
+		db.UserIdentity.ID.Equals(id),
+	).Delete().Exec(r.ctx)
+
+	if errors.Is(err, db.ErrNotFound) {
+		return constants.ErrUserIdentityNotFound
+	}
+	return err
+}
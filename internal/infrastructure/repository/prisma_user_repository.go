@@ -2,7 +2,9 @@ package repository
 
 import (
 	"context"
+	"errors"
 
+	"web-server/internal/domain/constants"
 	"web-server/internal/domain/entity"
 	"web-server/prisma/db"
 )
@@ -26,6 +28,13 @@ func (r *PrismaUserRepository) Create(user *entity.User) error {
 		db.User.Password.Set(user.Password),
 		db.User.Role.Set(user.Role),
 		db.User.ID.Set(user.ID),
+		db.User.EmailVerified.Set(user.EmailVerified),
+		db.User.Disabled.Set(user.Disabled),
+		db.User.PasswordResetRequired.Set(user.PasswordResetRequired),
+		db.User.MfaEnabled.Set(user.MFA.Enabled),
+		db.User.TotpSecret.Set(user.MFA.Secret),
+		db.User.TotpLastUsedStep.Set(int(user.MFA.LastUsedStep)),
+		db.User.RecoveryCodes.Set(user.MFA.RecoveryCodes),
 	).Exec(r.ctx)
 
 	return err
@@ -36,17 +45,14 @@ func (r *PrismaUserRepository) GetByID(id string) (*entity.User, error) {
 		db.User.ID.Equals(id),
 	).Exec(r.ctx)
 
+	if errors.Is(err, db.ErrNotFound) {
+		return nil, constants.ErrUserNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	return &entity.User{
-		ID:       user.ID,
-		Username: user.Username,
-		Email:    user.Email,
-		Password: user.Password,
-		Role:     user.Role,
-	}, nil
+	return toUserEntity(user), nil
 }
 
 func (r *PrismaUserRepository) GetByEmail(email string) (*entity.User, error) {
@@ -54,17 +60,14 @@ func (r *PrismaUserRepository) GetByEmail(email string) (*entity.User, error) {
 		db.User.Email.Equals(email),
 	).Exec(r.ctx)
 
+	if errors.Is(err, db.ErrNotFound) {
+		return nil, constants.ErrUserNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	return &entity.User{
-		ID:       user.ID,
-		Username: user.Username,
-		Email:    user.Email,
-		Password: user.Password,
-		Role:     user.Role,
-	}, nil
+	return toUserEntity(user), nil
 }
 
 func (r *PrismaUserRepository) Update(user *entity.User) error {
@@ -75,11 +78,39 @@ func (r *PrismaUserRepository) Update(user *entity.User) error {
 		db.User.Email.Set(user.Email),
 		db.User.Password.Set(user.Password),
 		db.User.Role.Set(user.Role),
+		db.User.EmailVerified.Set(user.EmailVerified),
+		db.User.Disabled.Set(user.Disabled),
+		db.User.PasswordResetRequired.Set(user.PasswordResetRequired),
 	).Exec(r.ctx)
 
 	return err
 }
 
+func (r *PrismaUserRepository) SwapMFA(userID string, old, mfa entity.MFASettings) error {
+	// Filtering on the old settings makes the update a compare-and-set
+	result, err := r.client.User.FindMany(
+		db.User.ID.Equals(userID),
+		db.User.MfaEnabled.Equals(old.Enabled),
+		db.User.TotpSecret.Equals(old.Secret),
+		db.User.TotpLastUsedStep.Equals(int(old.LastUsedStep)),
+		db.User.RecoveryCodes.Equals(old.RecoveryCodes),
+	).Update(
+		db.User.MfaEnabled.Set(mfa.Enabled),
+		db.User.TotpSecret.Set(mfa.Secret),
+		db.User.TotpLastUsedStep.Set(int(mfa.LastUsedStep)),
+		db.User.RecoveryCodes.Set(mfa.RecoveryCodes),
+	).Exec(r.ctx)
+	if err != nil {
+		return err
+	}
+
+	if result.Count == 0 {
+		return constants.ErrMFAChanged
+	}
+
+	return nil
+}
+
 func (r *PrismaUserRepository) Delete(id string) error {
 	_, err := r.client.User.FindUnique(
 		db.User.ID.Equals(id),
@@ -96,14 +127,28 @@ func (r *PrismaUserRepository) List() ([]*entity.User, error) {
 
 	users := make([]*entity.User, len(prismaUsers))
 	for i := range prismaUsers {
-		users[i] = &entity.User{
-			ID:       prismaUsers[i].ID,
-			Username: prismaUsers[i].Username,
-			Email:    prismaUsers[i].Email,
-			Password: prismaUsers[i].Password,
-			Role:     prismaUsers[i].Role,
-		}
+		users[i] = toUserEntity(&prismaUsers[i])
 	}
 
 	return users, nil
 }
+
+func toUserEntity(user *db.UserModel) *entity.User {
+	return &entity.User{
+		ID:       user.ID,
+		Username: user.Username,
+		Email:    user.Email,
+		Password: user.Password,
+		Role:     user.Role,
+
+		EmailVerified:         user.EmailVerified,
+		Disabled:              user.Disabled,
+		PasswordResetRequired: user.PasswordResetRequired,
+		MFA: entity.MFASettings{
+			Enabled:       user.MfaEnabled,
+			Secret:        user.TotpSecret,
+			LastUsedStep:  int64(user.TotpLastUsedStep),
+			RecoveryCodes: user.RecoveryCodes,
+		},
+	}
+}
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -10,8 +11,13 @@ import (
 	"syscall"
 	"time"
 	"web-server/internal/application/usecase"
+	"web-server/internal/domain/entity"
+	"web-server/internal/infrastructure/breach"
 	"web-server/internal/infrastructure/config"
+	"web-server/internal/infrastructure/hasher"
+	"web-server/internal/infrastructure/mailer"
 	"web-server/internal/infrastructure/middleware"
+	"web-server/internal/infrastructure/oidc"
 	"web-server/internal/infrastructure/repository"
 	"web-server/internal/interface/handler"
 
@@ -33,6 +39,12 @@ import (
 // @in header
 // @name Authorization
 // @description Enter the token with the `Bearer ` prefix, e.g. "Bearer abcde12345"
+// @securityDefinitions.apikey APIKeyAuth
+// @in header
+// @name X-API-Key
+// @description API key created at /private/users/api-keys
+// @securityDefinitions.basic BasicAuth
+// @description OAuth client ID and secret, see /private/users/admin/oauth-clients
 
 type Server struct {
 	router *gin.Engine
@@ -63,46 +75,308 @@ func NewServer() *Server {
 	// Initialize database and repositories
 	prismaClient := config.GetPrismaClient()
 	userRepo := repository.NewPrismaUserRepository(prismaClient)
+	refreshTokenRepo := repository.NewPrismaRefreshTokenRepository(prismaClient)
+	tokenRevocationRepo := repository.NewPrismaTokenRevocationRepository(prismaClient)
+	mfaPolicyRepo := repository.NewPrismaMFAPolicyRepository(prismaClient)
+	passwordResetTokenRepo := repository.NewPrismaPasswordResetTokenRepository(prismaClient)
+	loginAttemptRepo := repository.NewPrismaLoginAttemptRepository(prismaClient)
+	loginLinkRepo := repository.NewPrismaLoginLinkRepository(prismaClient)
+	apiKeyRepo := repository.NewPrismaAPIKeyRepository(prismaClient)
+	sessionRepo := repository.NewPrismaSessionRepository(prismaClient)
+	rolePermissionRepo := repository.NewPrismaRolePermissionRepository(prismaClient)
+	auditRepo := repository.NewPrismaAuditRepository(prismaClient)
+	oauthClientRepo := repository.NewPrismaOAuthClientRepository(prismaClient)
+	userIdentityRepo := repository.NewPrismaUserIdentityRepository(prismaClient)
+
+	// Initialize the mailer
+	cfg := config.GetConfig()
+
+	// Only take the client IP from X-Forwarded-For when the request comes
+	// through a configured proxy, otherwise clients could pick their own IP
+	// and dodge the per-IP login throttle
+	if err := server.router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		logger.WithError(err).Fatal("Invalid trusted proxies")
+	}
+
+	mail, err := mailer.New(cfg.Mail, logger)
+	if err != nil {
+		logger.WithError(err).Fatal("Could not configure the mailer")
+	}
+
+	// Initialize the password hasher
+	passwordHasher, err := hasher.New(cfg.PasswordHash)
+	if err != nil {
+		logger.WithError(err).Fatal("Could not configure password hashing")
+	}
+
+	// Initialize the password policy and breached password list
+	passwordPolicy := entity.PasswordPolicy{
+		MinLength:       cfg.PasswordPolicy.MinLength,
+		MaxLength:       cfg.PasswordPolicy.MaxLength,
+		RequiredClasses: cfg.PasswordPolicy.RequiredClasses,
+	}
+	if err := passwordPolicy.Validate(); err != nil {
+		logger.WithError(err).Fatal("Invalid password policy")
+	}
+	breachedPasswords, err := breach.New(cfg.PasswordPolicy.BreachedPasswordsPath)
+	if err != nil {
+		logger.WithError(err).Fatal("Could not load the breached password list")
+	}
+	passwordValidator := usecase.NewPasswordValidator(passwordPolicy, breachedPasswords)
+
+	// TOTP secrets get a key of their own, so that neither the payload
+	// encryption nor the MFA secrets weaken the other
+	if len(cfg.MFASecretKey) != 32 || bytes.Equal(cfg.MFASecretKey, cfg.EncryptionKey) {
+		logger.Fatal("MFA_SECRET_KEY must be a 32 byte key other than ENCRYPTION_KEY")
+	}
 
 	// Initialize use cases
-	userUseCase := usecase.NewUserUseCase(userRepo)
+	userUseCase := usecase.NewUserUseCase(userRepo, passwordHasher, passwordValidator)
+	mfaUseCase := usecase.NewMFAUseCase(userRepo, mfaPolicyRepo, cfg.MFASecretKey, cfg.MFAIssuer, cfg.MFARequiredRoles)
+	throttle := cfg.LoginThrottle
+	loginThrottleUseCase := usecase.NewLoginThrottleUseCase(
+		loginAttemptRepo,
+		entity.LoginThrottlePolicy{
+			FreeAttempts:    throttle.FreeAttempts,
+			MaxFailures:     throttle.MaxFailures,
+			BaseDelay:       throttle.BaseDelay,
+			LockoutDuration: throttle.LockoutDuration,
+		},
+		entity.LoginThrottlePolicy{
+			FreeAttempts:    throttle.FreeAttempts,
+			MaxFailures:     throttle.MaxFailuresPerIP,
+			BaseDelay:       throttle.BaseDelay,
+			LockoutDuration: throttle.LockoutDuration,
+		},
+	)
+	apiKeyUseCase := usecase.NewAPIKeyUseCase(apiKeyRepo, userRepo)
+	permissionUseCase := usecase.NewPermissionUseCase(rolePermissionRepo, cfg.RolePermissions)
+	userPolicy := usecase.NewUserPolicy(permissionUseCase, userRepo)
+	passwordResetUseCase := usecase.NewPasswordResetUseCase(
+		userRepo,
+		passwordResetTokenRepo,
+		mail,
+		passwordHasher,
+		passwordValidator,
+		cfg.AppURL+"/reset-password",
+		cfg.PasswordResetExpiration,
+	)
+	oauthClientUseCase := usecase.NewOAuthClientUseCase(oauthClientRepo)
+
+	// Initialize token signing keys and token manager
+	keyRing, err := middleware.LoadKeyRing(cfg)
+	if err != nil {
+		logger.WithError(err).Fatal("Could not load JWT signing keys")
+	}
+	tokenManager := middleware.NewTokenManager(
+		keyRing,
+		refreshTokenRepo,
+		tokenRevocationRepo,
+		sessionRepo,
+		oauthClientRepo,
+		userRepo,
+	)
+	userAdminUseCase := usecase.NewUserAdminUseCase(
+		userRepo,
+		auditRepo,
+		permissionUseCase,
+		passwordResetUseCase,
+		tokenManager,
+	)
+
+	// Initialize payload encryption keys
+	encryptionKeys, err := middleware.LoadEncryptionKeyRing(cfg)
+	if err != nil {
+		logger.WithError(err).Fatal("Could not load encryption keys")
+	}
+	keyExchange, err := middleware.LoadKeyExchange(cfg)
+	if err != nil {
+		logger.WithError(err).Fatal("Could not load key exchange key")
+	}
+	emailVerificationUseCase := usecase.NewEmailVerificationUseCase(
+		userRepo,
+		tokenManager,
+		mail,
+		cfg.AppURL+"/verify-email",
+		cfg.EmailVerification,
+	)
+	magicLinkUseCase := usecase.NewMagicLinkUseCase(
+		userRepo,
+		loginLinkRepo,
+		tokenManager,
+		mail,
+		cfg.AppURL+"/magic-login",
+		cfg.MagicLinkExpiration,
+	)
+
+	// Login through an external OpenID Connect provider, if one is configured
+	var oidcHandler *handler.OIDCHandler
+	if cfg.OIDC.Issuer != "" {
+		provider, err := oidc.New(cfg.OIDC)
+		if err != nil {
+			logger.WithError(err).Fatal("Invalid OIDC configuration")
+		}
+		oidcUseCase := usecase.NewOIDCUseCase(userRepo, userIdentityRepo, provider, tokenManager)
+		oidcHandler = handler.NewOIDCHandler(oidcUseCase, mfaUseCase, emailVerificationUseCase, tokenManager)
+	}
 
 	// Initialize handlers
-	userHandler := handler.NewUserHandler(userUseCase)
+	userHandler := handler.NewUserHandler(
+		userUseCase,
+		mfaUseCase,
+		emailVerificationUseCase,
+		loginThrottleUseCase,
+		apiKeyUseCase,
+		userPolicy,
+		tokenManager,
+	)
+	mfaHandler := handler.NewMFAHandler(
+		mfaUseCase,
+		emailVerificationUseCase,
+		loginThrottleUseCase.WithScope("mfa"), // Keyed by user ID instead of email
+		tokenManager,
+	)
+	emailVerificationHandler := handler.NewEmailVerificationHandler(emailVerificationUseCase)
+	magicLinkHandler := handler.NewMagicLinkHandler(
+		magicLinkUseCase,
+		mfaUseCase,
+		emailVerificationUseCase,
+		loginThrottleUseCase.WithScope("magic"),
+		tokenManager,
+	)
+	passwordHandler := handler.NewPasswordHandler(passwordResetUseCase, userUseCase, tokenManager)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyUseCase, userPolicy)
+	sessionHandler := handler.NewSessionHandler(userPolicy, tokenManager)
+	roleHandler := handler.NewRoleHandler(permissionUseCase)
+	userAdminHandler := handler.NewUserAdminHandler(userAdminUseCase, tokenManager)
+	jwksHandler := handler.NewJWKSHandler(keyRing)
+	oauthClientHandler := handler.NewOAuthClientHandler(oauthClientUseCase)
+	oauthHandler := handler.NewOAuthHandler(oauthClientUseCase, tokenManager)
+	encryptionKeyHandler := handler.NewEncryptionKeyHandler(encryptionKeys)
+	keyExchangeHandler := handler.NewKeyExchangeHandler(keyExchange)
+
+	// Apply rate limiter middleware - 100 requests per minute. It comes first
+	// so that it also covers the OAuth endpoints, where client secrets could
+	// otherwise be guessed without limit.
+	server.router.Use(middleware.RateLimiterMiddleware(100, 1.67))
 
-	// Apply global middleware
-	server.router.Use(middleware.EncryptionMiddleware())
+	// Public keys and the OAuth endpoints are registered before the
+	// encryption middleware so that other services can use them without the
+	// shared encryption key
+	server.router.GET("/.well-known/jwks.json", jwksHandler.GetJWKS)
+	oauth := server.router.Group("/oauth")
+	{
+		oauth.POST("/token", oauthHandler.Token)
+		oauth.POST("/introspect", oauthHandler.Introspect)
+		oauth.POST("/revoke", oauthHandler.Revoke)
+	}
 
-	// Apply rate limiter middleware - 100 requests per minute
-	server.router.Use(middleware.RateLimiterMiddleware(100, 1.67))
+	// Apply global middleware
+	server.router.Use(middleware.EncryptionMiddleware(encryptionKeys, keyExchange))
 
 	// Setup routes
 	api := server.router.Group("/api")
+	api.Use(middleware.CSRFMiddleware(tokenManager))
 	{
 		// Public routes
 		public := api.Group("/public")
 		{
 			public.POST("/users/register", userHandler.CreateUser)
 			public.POST("/users/login", userHandler.LoginUser)
+			public.POST("/users/login/mfa", mfaHandler.VerifyLogin)
+			public.POST("/users/login/mfa/enroll", mfaHandler.LoginEnroll)
+			public.POST("/users/login/magic", magicLinkHandler.RequestLink)
+			public.POST("/users/login/magic/verify", magicLinkHandler.RedeemLink)
 			public.POST("/users/refresh", userHandler.RefreshToken) // Add refresh token endpoint
+			public.POST("/users/password/forgot", passwordHandler.ForgotPassword)
+			public.POST("/users/password/reset", passwordHandler.ResetPassword)
+			public.POST("/users/email/verify", emailVerificationHandler.VerifyEmail)
+			public.POST("/users/email/resend", emailVerificationHandler.ResendVerification)
+			public.GET("/encryption/handshake", keyExchangeHandler.GetSuite)
+			public.POST("/encryption/handshake", keyExchangeHandler.Handshake)
+			if oidcHandler != nil {
+				public.GET("/users/login/oidc", oidcHandler.Login)
+				public.GET("/users/login/oidc/callback", oidcHandler.Callback)
+			}
 		}
 
 		// Private routes (require authentication)
 		private := api.Group("/private")
-		private.Use(middleware.AuthMiddleware())
+		private.Use(middleware.AuthMiddleware(tokenManager, apiKeyUseCase))
 		{
 			// User routes (require authentication)
 			users := private.Group("/users")
 			{
-				users.GET("/:id", userHandler.GetUser)
-				users.PUT("/:id", userHandler.UpdateUser)    // TODO: Implement update handler
-				users.DELETE("/:id", userHandler.DeleteUser) // TODO: Implement delete handler
+				// Available before the email address is verified
+				users.POST("/logout", userHandler.Logout)
+				// Actions an admin acting as the user must not take on their behalf
+				noImpersonation := middleware.DenyImpersonation()
+				noClientTokens := middleware.DenyClientTokens()
+
+				users.POST("/logout/all", noImpersonation, noClientTokens, userHandler.LogoutAll)
+
+				verified := users.Group("")
+				verified.Use(middleware.VerifiedEmailMiddleware())
+
+				// Routes acting on the account of the authenticated user, which
+				// OAuth clients do not have
+				account := verified.Group("")
+				account.Use(noClientTokens)
+				{
+					account.POST("/password/change", noImpersonation, passwordHandler.ChangePassword)
+
+					account.POST("/mfa/enroll", noImpersonation, mfaHandler.Enroll)
+					account.POST("/mfa/confirm", noImpersonation, mfaHandler.Confirm)
+					account.POST("/mfa/disable", noImpersonation, mfaHandler.Disable)
+
+					account.POST("/api-keys", noImpersonation, apiKeyHandler.CreateAPIKey)
+					account.GET("/api-keys", apiKeyHandler.ListAPIKeys)
+					account.DELETE("/api-keys/:keyId", noImpersonation, apiKeyHandler.RevokeAPIKey)
+
+					account.GET("/:id", userHandler.GetUser)
+					account.PUT("/:id", noImpersonation, userHandler.UpdateUser)    // TODO: Implement update handler
+					account.DELETE("/:id", noImpersonation, userHandler.DeleteUser) // TODO: Implement delete handler
+
+					account.GET("/:id/sessions", sessionHandler.ListSessions)
+					account.DELETE("/:id/sessions/:sessionId", noImpersonation, sessionHandler.RevokeSession)
+				}
 
-				// Admin only routes
-				admin := users.Group("/admin")
-				admin.Use(middleware.RoleMiddleware("admin"))
+				// Admin routes, each requiring a permission of the user's role or,
+				// for OAuth client tokens, a scope
+				usersRead := middleware.RequirePermission(permissionUseCase, entity.PermissionUsersRead)
+				usersWrite := middleware.RequirePermission(permissionUseCase, entity.PermissionUsersWrite)
+				rolesRead := middleware.RequirePermission(permissionUseCase, entity.PermissionRolesRead)
+				rolesWrite := middleware.RequirePermission(permissionUseCase, entity.PermissionRolesWrite)
+				usersImpersonate := middleware.RequirePermission(permissionUseCase, entity.PermissionUsersImpersonate)
+				clientsRead := middleware.RequirePermission(permissionUseCase, entity.PermissionClientsRead)
+				clientsWrite := middleware.RequirePermission(permissionUseCase, entity.PermissionClientsWrite)
+				encryptionRead := middleware.RequirePermission(permissionUseCase, entity.PermissionEncryptionRead)
+
+				// Admin routes are never available to impersonation tokens,
+				// whatever the role of the impersonated user
+				admin := verified.Group("/admin")
+				admin.Use(noImpersonation)
 				{
-					admin.GET("/", userHandler.ListUsers) // TODO: Implement list users handler
+					admin.GET("/", usersRead, userHandler.ListUsers) // TODO: Implement list users handler
+					admin.POST("/:id/logout", usersWrite, userHandler.LogoutUserEverywhere)
+					admin.POST("/:id/unlock", usersWrite, userHandler.UnlockUser)
+					admin.PUT("/:id/role", rolesWrite, userAdminHandler.ChangeRole)
+					admin.POST("/:id/disable", usersWrite, userAdminHandler.DisableUser)
+					admin.POST("/:id/enable", usersWrite, userAdminHandler.EnableUser)
+					admin.POST("/:id/password-reset", usersWrite, userAdminHandler.ForcePasswordReset)
+					admin.GET("/:id/audit", usersRead, userAdminHandler.ListAuditLog)
+					admin.POST("/:id/impersonate", usersImpersonate, noClientTokens, userAdminHandler.Impersonate)
+					admin.GET("/:id/api-keys", usersRead, apiKeyHandler.ListUserAPIKeys)
+					admin.DELETE("/:id/api-keys/:keyId", usersWrite, apiKeyHandler.RevokeUserAPIKey)
+					admin.GET("/mfa/roles", rolesRead, mfaHandler.ListRequiredRoles)
+					admin.PUT("/mfa/roles/:role", rolesWrite, mfaHandler.SetRoleRequired)
+					admin.GET("/roles", rolesRead, roleHandler.ListRoles)
+					admin.PUT("/roles/:role", rolesWrite, roleHandler.SetRolePermissions)
+					admin.DELETE("/roles/:role", rolesWrite, roleHandler.ResetRolePermissions)
+					admin.GET("/oauth-clients", clientsRead, oauthClientHandler.ListClients)
+					admin.POST("/oauth-clients", clientsWrite, oauthClientHandler.CreateClient)
+					admin.DELETE("/oauth-clients/:clientId", clientsWrite, oauthClientHandler.RevokeClient)
+					admin.GET("/encryption-keys", encryptionRead, encryptionKeyHandler.ListKeys)
 				}
 			}
 		}
@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"web-server/internal/application/usecase"
+	"web-server/internal/domain/constants"
+	"web-server/internal/domain/entity"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateAPIKeyRequest represents a request for a new API key
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" binding:"required,max=100" example:"CI pipeline"`
+	Scopes    []string   `json:"scopes" example:"read"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty" example:"2030-01-01T00:00:00Z"`
+}
+
+// CreateAPIKeyResponse represents a newly created API key. The key is only
+// returned once.
+type CreateAPIKeyResponse struct {
+	Key    string         `json:"key" example:"wsk_3q2-7wEAAAB..."`
+	APIKey *entity.APIKey `json:"api_key"`
+}
+
+// APIKeyHandler handles HTTP requests related to API keys
+type APIKeyHandler struct {
+	apiKeyUseCase *usecase.APIKeyUseCase
+	policy        *usecase.UserPolicy
+}
+
+func NewAPIKeyHandler(uc *usecase.APIKeyUseCase, policy *usecase.UserPolicy) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyUseCase: uc,
+		policy:        policy,
+	}
+}
+
+// @Summary Create API key
+// @Description Create an API key for the current user. The key is shown only in this response.
+// @Description Keys with the read scope only allow GET requests; admin rights require the admin scope,
+// @Description together with read or write.
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body CreateAPIKeyRequest true "API key details"
+// @Success 201 {object} CreateAPIKeyResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /private/users/api-keys [post]
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	key, apiKey, err := h.apiKeyUseCase.Create(c.GetString("userID"), req.Name, req.Scopes, req.ExpiresAt)
+	switch {
+	case errors.Is(err, constants.ErrInvalidAPIKeyScope):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	case errors.Is(err, constants.ErrInvalidAPIKey):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Expiry must be in the future"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to create API key"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, CreateAPIKeyResponse{Key: key, APIKey: apiKey})
+}
+
+// @Summary List API keys
+// @Description List the API keys of the current user, including revoked and expired ones
+// @Tags api-keys
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} entity.APIKey
+// @Failure 500 {object} ErrorResponse
+// @Router /private/users/api-keys [get]
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
+	h.listAPIKeys(c, c.GetString("userID"))
+}
+
+// @Summary List a user's API keys
+// @Description List the API keys of the given user (admin only). Only allowed for users whose role has no
+// @Description permissions beyond the caller's.
+// @Tags api-keys
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Success 200 {array} entity.APIKey
+// @Failure 403 {object} constants.ErrorResponse "User has more permissions than the caller"
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /private/users/admin/{id}/api-keys [get]
+func (h *APIKeyHandler) ListUserAPIKeys(c *gin.Context) {
+	id := c.Param("id")
+	if !authorizeUser(c, h.policy, id, entity.PermissionUsersRead) {
+		return
+	}
+	h.listAPIKeys(c, id)
+}
+
+// @Summary Revoke API key
+// @Description Revoke an API key of the current user
+// @Tags api-keys
+// @Produce json
+// @Security BearerAuth
+// @Param keyId path string true "API key ID"
+// @Success 200 {object} MessageResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /private/users/api-keys/{keyId} [delete]
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	h.revokeAPIKey(c, c.GetString("userID"))
+}
+
+// @Summary Revoke a user's API key
+// @Description Revoke an API key of the given user (admin only). Only allowed for users whose role has no
+// @Description permissions beyond the caller's.
+// @Tags api-keys
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Param keyId path string true "API key ID"
+// @Success 200 {object} MessageResponse
+// @Failure 403 {object} constants.ErrorResponse "User has more permissions than the caller"
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /private/users/admin/{id}/api-keys/{keyId} [delete]
+func (h *APIKeyHandler) RevokeUserAPIKey(c *gin.Context) {
+	id := c.Param("id")
+	if !authorizeUser(c, h.policy, id, entity.PermissionUsersWrite) {
+		return
+	}
+	h.revokeAPIKey(c, id)
+}
+
+func (h *APIKeyHandler) listAPIKeys(c *gin.Context, userID string) {
+	keys, err := h.apiKeyUseCase.List(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to list API keys"})
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+func (h *APIKeyHandler) revokeAPIKey(c *gin.Context, userID string) {
+	err := h.apiKeyUseCase.Revoke(userID, c.Param("keyId"))
+	if errors.Is(err, constants.ErrAPIKeyNotFound) {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "API key not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to revoke API key"})
+		return
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{Message: "API key revoked"})
+}
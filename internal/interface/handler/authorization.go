@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"web-server/internal/application/usecase"
+	"web-server/internal/domain/constants"
+	"web-server/internal/domain/entity"
+	"web-server/internal/infrastructure/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// actorFrom returns the authenticated user of the request.
+func actorFrom(c *gin.Context) entity.Actor {
+	return entity.Actor{
+		UserID:         c.GetString("userID"),
+		Role:           c.GetString("role"),
+		ImpersonatorID: c.GetString(middleware.ImpersonatorKey),
+	}
+}
+
+// authorizeUser checks with the policy that the authenticated user may perform
+// an action requiring the permission on the user's account. Otherwise it
+// responds with an error and returns false.
+func authorizeUser(c *gin.Context, policy *usecase.UserPolicy, userID, permission string) bool {
+	err := policy.Authorize(actorFrom(c), userID, permission)
+	if errors.Is(err, constants.ErrAccessDenied) {
+		c.JSON(http.StatusForbidden, constants.ErrInsufficientPermissions())
+		return false
+	}
+	if errors.Is(err, constants.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "User not found"})
+		return false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, constants.ErrInternalServer())
+		return false
+	}
+
+	return true
+}
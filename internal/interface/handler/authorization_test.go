@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"web-server/internal/application/usecase"
+	"web-server/internal/domain/entity"
+	"web-server/internal/infrastructure/config"
+	"web-server/internal/infrastructure/middleware"
+	"web-server/internal/infrastructure/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// adminRoutesFixture serves the admin routes that act on a single user, for
+// a "support" role with users:read and users:write.
+type adminRoutesFixture struct {
+	router  *gin.Engine
+	tokens  *middleware.TokenManager
+	apiKeys *usecase.APIKeyUseCase
+}
+
+func newAdminRoutesFixture(t *testing.T) *adminRoutesFixture {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	users := repository.NewInMemoryUserRepository()
+	for _, user := range []*entity.User{
+		{ID: "admin", Email: "admin@example.com", Role: entity.RoleAdmin},
+		{ID: "support", Email: "support@example.com", Role: "support"},
+		{ID: "user", Email: "user@example.com", Role: entity.RoleUser},
+	} {
+		require.NoError(t, users.Create(user))
+	}
+
+	permissions := usecase.NewPermissionUseCase(
+		repository.NewInMemoryRolePermissionRepository(),
+		map[string][]string{"support": {entity.PermissionUsersRead, entity.PermissionUsersWrite}},
+	)
+	policy := usecase.NewUserPolicy(permissions, users)
+	tokens := middleware.NewTokenManager(
+		middleware.NewHMACKeyRing(config.GetConfig().JWTSecret),
+		repository.NewInMemoryRefreshTokenRepository(),
+		repository.NewInMemoryTokenRevocationRepository(),
+		repository.NewInMemorySessionRepository(),
+		repository.NewInMemoryOAuthClientRepository(),
+		users,
+	)
+	apiKeys := usecase.NewAPIKeyUseCase(repository.NewInMemoryAPIKeyRepository(), users)
+	throttle := usecase.NewLoginThrottleUseCase(
+		repository.NewInMemoryLoginAttemptRepository(),
+		entity.LoginThrottlePolicy{},
+		entity.LoginThrottlePolicy{},
+	)
+
+	userHandler := NewUserHandler(
+		usecase.NewUserUseCase(users, nil, nil),
+		nil,
+		nil,
+		throttle,
+		apiKeys,
+		policy,
+		tokens,
+	)
+	apiKeyHandler := NewAPIKeyHandler(apiKeys, policy)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", "support")
+		c.Set("role", "support")
+		c.Next()
+	})
+	router.POST("/admin/:id/logout", userHandler.LogoutUserEverywhere)
+	router.POST("/admin/:id/unlock", userHandler.UnlockUser)
+	router.GET("/admin/:id/api-keys", apiKeyHandler.ListUserAPIKeys)
+	router.DELETE("/admin/:id/api-keys/:keyId", apiKeyHandler.RevokeUserAPIKey)
+
+	return &adminRoutesFixture{router: router, tokens: tokens, apiKeys: apiKeys}
+}
+
+func (f *adminRoutesFixture) serve(method, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	f.router.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+	return w
+}
+
+func TestAdminRoutes_RequireTargetPermissions(t *testing.T) {
+	tests := []struct {
+		target string
+		want   int
+	}{
+		{target: "admin", want: http.StatusForbidden},
+		{target: "user", want: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run("Support acting on "+tt.target, func(t *testing.T) {
+			f := newAdminRoutesFixture(t)
+			pair, err := f.tokens.GenerateTokenPair(tt.target, "")
+			require.NoError(t, err)
+			key, created, err := f.apiKeys.Create(tt.target, "CI", nil, nil)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.want, f.serve(http.MethodPost, "/admin/"+tt.target+"/logout").Code)
+			assert.Equal(t, tt.want, f.serve(http.MethodPost, "/admin/"+tt.target+"/unlock").Code)
+			assert.Equal(t, tt.want, f.serve(http.MethodGet, "/admin/"+tt.target+"/api-keys").Code)
+			assert.Equal(t, tt.want, f.serve(http.MethodDelete, "/admin/"+tt.target+"/api-keys/"+created.ID).Code)
+
+			// Denied requests leave the sessions and keys of the user alone
+			_, tokenErr := f.tokens.ValidateAccessToken(pair.AccessToken)
+			_, _, keyErr := f.apiKeys.AuthenticateAPIKey(key)
+			if tt.want == http.StatusForbidden {
+				assert.NoError(t, tokenErr)
+				assert.NoError(t, keyErr)
+			} else {
+				assert.Error(t, tokenErr)
+				assert.Error(t, keyErr)
+			}
+		})
+	}
+
+	t.Run("Unknown users are not found", func(t *testing.T) {
+		f := newAdminRoutesFixture(t)
+		assert.Equal(t, http.StatusNotFound, f.serve(http.MethodPost, "/admin/missing/logout").Code)
+	})
+}
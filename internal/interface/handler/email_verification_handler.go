@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"web-server/internal/application/usecase"
+	"web-server/internal/domain/constants"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VerifyEmailRequest represents a request with the token from a verification email
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// ResendVerificationRequest represents a request for a new verification email
+type ResendVerificationRequest struct {
+	Email string `json:"email" binding:"required,email" example:"user@example.com"`
+}
+
+// EmailVerificationHandler handles HTTP requests related to email verification
+type EmailVerificationHandler struct {
+	verificationUseCase *usecase.EmailVerificationUseCase
+}
+
+func NewEmailVerificationHandler(uc *usecase.EmailVerificationUseCase) *EmailVerificationHandler {
+	return &EmailVerificationHandler{
+		verificationUseCase: uc,
+	}
+}
+
+// @Summary Verify email address
+// @Description Mark the email address as verified with the token from the verification email.
+// @Description Restricted tokens issued before the verification stay restricted; log in again for full access.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body VerifyEmailRequest true "Verification token"
+// @Success 200 {object} MessageResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /public/users/email/verify [post]
+func (h *EmailVerificationHandler) VerifyEmail(c *gin.Context) {
+	var req VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	_, err := h.verificationUseCase.Verify(req.Token)
+	if errors.Is(err, constants.ErrInvalidVerificationToken) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to verify email"})
+		return
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{Message: "Email address verified"})
+}
+
+// @Summary Resend verification email
+// @Description Email a new verification link. The response is the same whether or not the email belongs to an unverified account.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body ResendVerificationRequest true "Account email"
+// @Success 202 {object} MessageResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /public/users/email/resend [post]
+func (h *EmailVerificationHandler) ResendVerification(c *gin.Context) {
+	var req ResendVerificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if err := h.verificationUseCase.Resend(req.Email); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to send verification email"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, MessageResponse{Message: "If the email belongs to an unverified account, a verification link has been sent"})
+}
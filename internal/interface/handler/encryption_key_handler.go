@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"net/http"
+
+	"web-server/internal/infrastructure/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EncryptionKeyHandler handles HTTP requests to inspect the payload
+// encryption keys. Keys are rotated through the configuration, see
+// middleware.LoadEncryptionKeyRing.
+type EncryptionKeyHandler struct {
+	keys *middleware.EncryptionKeyRing
+}
+
+func NewEncryptionKeyHandler(keys *middleware.EncryptionKeyRing) *EncryptionKeyHandler {
+	return &EncryptionKeyHandler{
+		keys: keys,
+	}
+}
+
+// @Summary List encryption keys
+// @Description List the IDs of the payload encryption keys and which one is primary (admin only)
+// @Tags encryption
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} middleware.EncryptionKeyInfo
+// @Router /private/users/admin/encryption-keys [get]
+func (h *EncryptionKeyHandler) ListKeys(c *gin.Context) {
+	c.JSON(http.StatusOK, h.keys.List())
+}
@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"net/http"
+
+	"web-server/internal/infrastructure/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JWKSHandler publishes the public keys access tokens can be verified with
+type JWKSHandler struct {
+	keys *middleware.KeyRing
+}
+
+func NewJWKSHandler(keys *middleware.KeyRing) *JWKSHandler {
+	return &JWKSHandler{
+		keys: keys,
+	}
+}
+
+// @Summary JSON Web Key Set
+// @Description Public keys for verifying access tokens, selected by the kid header
+// @Tags auth
+// @Produce json
+// @Success 200 {object} middleware.JSONWebKeySet
+// @Router /.well-known/jwks.json [get]
+func (h *JWKSHandler) GetJWKS(c *gin.Context) {
+	// Let verifiers cache the set, but pick up rotated keys reasonably soon
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.keys.JWKS())
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"time"
+
+	"web-server/internal/domain/constants"
+	"web-server/internal/infrastructure/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HandshakeRequest represents the encapsulated key of a client's HPKE sender
+// context
+type HandshakeRequest struct {
+	Enc string `json:"enc" binding:"required" example:"bD+7mGnfNAL..."` // Base64 encoded
+}
+
+// HandshakeResponse represents an encryption session. Requests name it in the
+// X-Encryption-Session header.
+type HandshakeResponse struct {
+	SessionID string    `json:"session_id" example:"3q2-7wEAAAB..."`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// KeyExchangeHandler handles the handshake clients agree on their payload
+// encryption key with
+type KeyExchangeHandler struct {
+	exchange *middleware.KeyExchange
+}
+
+func NewKeyExchangeHandler(exchange *middleware.KeyExchange) *KeyExchangeHandler {
+	return &KeyExchangeHandler{
+		exchange: exchange,
+	}
+}
+
+// @Summary Key exchange parameters
+// @Description The server's X25519 public key and the HPKE (RFC 9180) parameters clients derive their session key with
+// @Tags encryption
+// @Produce json
+// @Success 200 {object} middleware.KeyExchangeSuite
+// @Router /public/encryption/handshake [get]
+func (h *KeyExchangeHandler) GetSuite(c *gin.Context) {
+	c.JSON(http.StatusOK, h.exchange.Suite())
+}
+
+// @Summary Key exchange handshake
+// @Description Start an encryption session with the session key exported from an HPKE sender context for the server's public key
+// @Tags encryption
+// @Accept json
+// @Produce json
+// @Param request body HandshakeRequest true "Encapsulated key"
+// @Success 201 {object} HandshakeResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /public/encryption/handshake [post]
+func (h *KeyExchangeHandler) Handshake(c *gin.Context) {
+	var req HandshakeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	enc, err := base64.StdEncoding.DecodeString(req.Enc)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: constants.ErrInvalidEncapsulatedKey.Error()})
+		return
+	}
+
+	session, err := h.exchange.Handshake(enc)
+	if errors.Is(err, constants.ErrInvalidEncapsulatedKey) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to start encryption session"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, HandshakeResponse{SessionID: session.ID, ExpiresAt: session.ExpiresAt})
+}
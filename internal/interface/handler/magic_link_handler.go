@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"web-server/internal/application/usecase"
+	"web-server/internal/domain/constants"
+	"web-server/internal/infrastructure/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MagicLinkRequest represents a request for a passwordless login link
+type MagicLinkRequest struct {
+	Email string `json:"email" binding:"required,email" example:"user@example.com"`
+}
+
+// RedeemMagicLinkRequest represents a request with the token from a login link email
+type RedeemMagicLinkRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// MagicLinkHandler handles HTTP requests related to passwordless login links
+type MagicLinkHandler struct {
+	magicLinkUseCase    *usecase.MagicLinkUseCase
+	mfaUseCase          *usecase.MFAUseCase
+	verificationUseCase *usecase.EmailVerificationUseCase
+	throttleUseCase     *usecase.LoginThrottleUseCase // Counts link requests, separately from password logins
+	tokens              *middleware.TokenManager
+}
+
+func NewMagicLinkHandler(
+	magicLink *usecase.MagicLinkUseCase,
+	mfa *usecase.MFAUseCase,
+	verification *usecase.EmailVerificationUseCase,
+	throttle *usecase.LoginThrottleUseCase,
+	tokens *middleware.TokenManager,
+) *MagicLinkHandler {
+	return &MagicLinkHandler{
+		magicLinkUseCase:    magicLink,
+		mfaUseCase:          mfa,
+		verificationUseCase: verification,
+		throttleUseCase:     throttle,
+		tokens:              tokens,
+	}
+}
+
+// @Summary Request login link
+// @Description Email a single-use, short-lived login link. The response is the same whether or not the email belongs to an account.
+// @Description Every request counts against the email and the client IP, which are locked out after too many requests.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body MagicLinkRequest true "Account email"
+// @Success 202 {object} MessageResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 429 {object} constants.ErrorResponse "Too many login link requests"
+// @Failure 500 {object} ErrorResponse
+// @Router /public/users/login/magic [post]
+func (h *MagicLinkHandler) RequestLink(c *gin.Context) {
+	var req MagicLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	// Every request is counted, whether or not an account exists, so the
+	// lockout does not reveal which accounts exist
+	retryAfter, err := h.throttleUseCase.Attempt(req.Email, c.ClientIP())
+	if errors.Is(err, constants.ErrTooManyLoginAttempts) {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, constants.ErrLoginLockedOut())
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to check login attempts"})
+		return
+	}
+
+	if err := h.magicLinkUseCase.RequestLink(req.Email); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to send login link"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, MessageResponse{Message: "If the email belongs to an account, a login link has been sent"})
+}
+
+// @Summary Log in with login link
+// @Description Exchange the token from a login link email for JWT tokens. Each link works once.
+// @Description Redeeming a link verifies the email address. If the user needs a second factor,
+// @Description an MFA challenge token is returned instead, see /public/users/login/mfa.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body RedeemMagicLinkRequest true "Login link token"
+// @Success 200 {object} LoginResponse
+// @Success 202 {object} MFAChallengeResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse "Invalid, expired or already used login link"
+// @Failure 403 {object} ErrorResponse "Account disabled or password reset required"
+// @Failure 500 {object} ErrorResponse
+// @Router /public/users/login/magic/verify [post]
+func (h *MagicLinkHandler) RedeemLink(c *gin.Context) {
+	var req RedeemMagicLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	user, err := h.magicLinkUseCase.Redeem(req.Token)
+	if errors.Is(err, constants.ErrInvalidLoginLink) {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to check login link"})
+		return
+	}
+
+	// The link reached the owner of the account, so their requests for it no
+	// longer count
+	if err := h.throttleUseCase.Unlock(user.Email); err != nil {
+		_ = c.Error(err)
+	}
+
+	completeLogin(c, h.tokens, h.mfaUseCase, h.verificationUseCase, user)
+}
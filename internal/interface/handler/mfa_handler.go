@@ -0,0 +1,319 @@
+package handler
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"web-server/internal/application/usecase"
+	"web-server/internal/domain/constants"
+	"web-server/internal/infrastructure/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MFACodeRequest represents a request carrying a TOTP or recovery code
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required" example:"123456"`
+}
+
+// MFALoginRequest represents the second step of a login with MFA
+type MFALoginRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required" example:"123456"` // TOTP code or recovery code
+}
+
+// MFATokenRequest represents a request authenticated by an MFA challenge token
+type MFATokenRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+}
+
+// MFALoginResponse represents a completed login with MFA
+type MFALoginResponse struct {
+	LoginResponse
+	RecoveryCodes []string `json:"recovery_codes,omitempty"` // Only set when the login completed an enrollment
+}
+
+// MFAEnrollmentResponse represents a started TOTP enrollment
+type MFAEnrollmentResponse struct {
+	Secret     string `json:"secret" example:"JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"`
+	OTPAuthURI string `json:"otpauth_uri" example:"otpauth://totp/Web%20Server:user@example.com?secret=..."`
+}
+
+// RecoveryCodesResponse represents newly generated recovery codes
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// MFARolesResponse represents the roles that require MFA
+type MFARolesResponse struct {
+	Roles []string `json:"roles" example:"admin"`
+}
+
+// MFARoleRequest represents a change of the MFA requirement of a role
+type MFARoleRequest struct {
+	Required bool `json:"required" example:"true"`
+}
+
+// MFAHandler handles HTTP requests related to TOTP second factors
+type MFAHandler struct {
+	mfaUseCase          *usecase.MFAUseCase
+	verificationUseCase *usecase.EmailVerificationUseCase
+	throttleUseCase     *usecase.LoginThrottleUseCase // Counts wrong codes per user and IP, separately from password logins
+	tokens              *middleware.TokenManager
+}
+
+func NewMFAHandler(
+	uc *usecase.MFAUseCase,
+	verification *usecase.EmailVerificationUseCase,
+	throttle *usecase.LoginThrottleUseCase,
+	tokens *middleware.TokenManager,
+) *MFAHandler {
+	return &MFAHandler{
+		mfaUseCase:          uc,
+		verificationUseCase: verification,
+		throttleUseCase:     throttle,
+		tokens:              tokens,
+	}
+}
+
+// @Summary Start MFA enrollment
+// @Description Generate a TOTP secret for the current user. MFA is enabled once confirmed with a code.
+// @Tags mfa
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} MFAEnrollmentResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /private/users/mfa/enroll [post]
+func (h *MFAHandler) Enroll(c *gin.Context) {
+	h.enroll(c, c.GetString("userID"))
+}
+
+// @Summary Confirm MFA enrollment
+// @Description Enable MFA with a code from the authenticator. The recovery codes are only returned once.
+// @Tags mfa
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param code body MFACodeRequest true "TOTP code"
+// @Success 200 {object} RecoveryCodesResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /private/users/mfa/confirm [post]
+func (h *MFAHandler) Confirm(c *gin.Context) {
+	var req MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	codes, err := h.mfaUseCase.ConfirmEnrollment(c.GetString("userID"), req.Code)
+	if err != nil {
+		respondMFAError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// @Summary Disable MFA
+// @Description Disable MFA for the current user with a TOTP or recovery code
+// @Tags mfa
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param code body MFACodeRequest true "TOTP or recovery code"
+// @Success 200 {object} MessageResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /private/users/mfa/disable [post]
+func (h *MFAHandler) Disable(c *gin.Context) {
+	var req MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if err := h.mfaUseCase.Disable(c.GetString("userID"), req.Code); err != nil {
+		respondMFAError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{Message: "MFA disabled"})
+}
+
+// @Summary Start MFA enrollment during login
+// @Description Generate a TOTP secret for a user whose role requires MFA but who has not enrolled yet
+// @Tags mfa
+// @Accept json
+// @Produce json
+// @Param mfa_token body MFATokenRequest true "MFA challenge token from login"
+// @Success 200 {object} MFAEnrollmentResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /public/users/login/mfa/enroll [post]
+func (h *MFAHandler) LoginEnroll(c *gin.Context) {
+	var req MFATokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	userID, err := h.tokens.ValidateMFAToken(req.MFAToken)
+	if err != nil {
+		respondMFAError(c, err)
+		return
+	}
+
+	h.enroll(c, userID)
+}
+
+// @Summary Complete login with MFA
+// @Description Exchange the MFA challenge token and a TOTP or recovery code for JWT tokens.
+// @Description The challenge token works for a single login. Wrong codes count against the user and the
+// @Description client IP, which are locked out after too many, and a lockout also uses up the challenge token.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param credentials body MFALoginRequest true "MFA challenge token and code"
+// @Success 200 {object} MFALoginResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 429 {object} constants.ErrorResponse "Too many wrong codes"
+// @Failure 500 {object} ErrorResponse
+// @Router /public/users/login/mfa [post]
+func (h *MFAHandler) VerifyLogin(c *gin.Context) {
+	var req MFALoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	userID, err := h.tokens.ValidateMFAToken(req.MFAToken)
+	if err != nil {
+		respondMFAError(c, err)
+		return
+	}
+
+	// The attempt counts as a failure until the code turns out to be valid
+	retryAfter, err := h.throttleUseCase.Attempt(userID, c.ClientIP())
+	if errors.Is(err, constants.ErrTooManyLoginAttempts) {
+		// Further guesses need a new challenge, and with it the password
+		if err := h.tokens.RevokeMFAToken(req.MFAToken); err != nil {
+			_ = c.Error(err)
+		}
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, constants.ErrLoginLockedOut())
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to check login attempts"})
+		return
+	}
+
+	user, recoveryCodes, err := h.mfaUseCase.Verify(userID, req.Code)
+	if err != nil {
+		respondMFAError(c, err)
+		return
+	}
+
+	if err := h.throttleUseCase.RecordSuccess(userID, c.ClientIP()); err != nil {
+		_ = c.Error(err)
+	}
+
+	// The challenge token completes a single login. Of concurrent requests
+	// with the same token, only the one that revokes it gets tokens.
+	if err := h.tokens.RevokeMFAToken(req.MFAToken); err != nil {
+		respondMFAError(c, err)
+		return
+	}
+
+	tokens, err := issueLoginTokens(c, h.tokens, h.verificationUseCase, user)
+	if err != nil {
+		respondLoginError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, MFALoginResponse{
+		LoginResponse: newLoginResponse(user, tokens),
+		RecoveryCodes: recoveryCodes,
+	})
+}
+
+// @Summary List roles requiring MFA
+// @Description List the roles whose users must log in with a second factor (admin only)
+// @Tags mfa
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} MFARolesResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /private/users/admin/mfa/roles [get]
+func (h *MFAHandler) ListRequiredRoles(c *gin.Context) {
+	roles, err := h.mfaUseCase.RequiredRoles()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, MFARolesResponse{Roles: roles})
+}
+
+// @Summary Require MFA for a role
+// @Description Require or stop requiring MFA for a role (admin only). Roles required by configuration stay required.
+// @Tags mfa
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param role path string true "Role"
+// @Param policy body MFARoleRequest true "MFA requirement"
+// @Success 200 {object} MFARolesResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /private/users/admin/mfa/roles/{role} [put]
+func (h *MFAHandler) SetRoleRequired(c *gin.Context) {
+	var req MFARoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if err := h.mfaUseCase.SetRoleRequired(c.Param("role"), req.Required); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	h.ListRequiredRoles(c)
+}
+
+func (h *MFAHandler) enroll(c *gin.Context, userID string) {
+	secret, uri, err := h.mfaUseCase.BeginEnrollment(userID)
+	if err != nil {
+		respondMFAError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, MFAEnrollmentResponse{
+		Secret:     secret,
+		OTPAuthURI: uri,
+	})
+}
+
+// respondMFAError maps MFA errors to HTTP responses.
+func respondMFAError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, constants.ErrInvalidMFACode), errors.Is(err, constants.ErrInvalidMFAToken):
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: err.Error()})
+	case errors.Is(err, constants.ErrMFANotEnrolled):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	case errors.Is(err, constants.ErrMFAAlreadyEnabled), errors.Is(err, constants.ErrMFAChanged):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: err.Error()})
+	case errors.Is(err, constants.ErrMFARequired):
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to process MFA request"})
+	}
+}
@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"web-server/internal/application/usecase"
+	"web-server/internal/domain/constants"
+	"web-server/internal/domain/entity"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateOAuthClientRequest represents a request to register an OAuth client
+type CreateOAuthClientRequest struct {
+	Name   string   `json:"name" binding:"required,max=100" example:"billing-service"`
+	Scopes []string `json:"scopes" example:"users:read"` // Permissions the client may request for its tokens, or introspect
+}
+
+// CreateOAuthClientResponse represents a newly registered OAuth client. The
+// secret is only returned once.
+type CreateOAuthClientResponse struct {
+	ClientSecret string              `json:"client_secret" example:"wcs_3q2-7wEAAAB..."`
+	Client       *entity.OAuthClient `json:"client"`
+}
+
+// OAuthClientHandler handles HTTP requests to manage OAuth clients
+type OAuthClientHandler struct {
+	clientUseCase *usecase.OAuthClientUseCase
+}
+
+func NewOAuthClientHandler(uc *usecase.OAuthClientUseCase) *OAuthClientHandler {
+	return &OAuthClientHandler{
+		clientUseCase: uc,
+	}
+}
+
+// @Summary Register OAuth client
+// @Description Register a service that authenticates at the OAuth endpoints (admin only). The secret is shown only in this response.
+// @Tags oauth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body CreateOAuthClientRequest true "Client details"
+// @Success 201 {object} CreateOAuthClientResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /private/users/admin/oauth-clients [post]
+func (h *OAuthClientHandler) CreateClient(c *gin.Context) {
+	var req CreateOAuthClientRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	secret, client, err := h.clientUseCase.Create(req.Name, req.Scopes)
+	if errors.Is(err, constants.ErrInvalidScope) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to register OAuth client"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, CreateOAuthClientResponse{ClientSecret: secret, Client: client})
+}
+
+// @Summary List OAuth clients
+// @Description List the registered OAuth clients, including revoked ones (admin only)
+// @Tags oauth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} entity.OAuthClient
+// @Failure 500 {object} ErrorResponse
+// @Router /private/users/admin/oauth-clients [get]
+func (h *OAuthClientHandler) ListClients(c *gin.Context) {
+	clients, err := h.clientUseCase.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to list OAuth clients"})
+		return
+	}
+
+	c.JSON(http.StatusOK, clients)
+}
+
+// @Summary Revoke OAuth client
+// @Description Revoke an OAuth client so that its credentials and the access tokens issued to it stop working (admin only)
+// @Tags oauth
+// @Produce json
+// @Security BearerAuth
+// @Param clientId path string true "Client ID"
+// @Success 200 {object} MessageResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /private/users/admin/oauth-clients/{clientId} [delete]
+func (h *OAuthClientHandler) RevokeClient(c *gin.Context) {
+	err := h.clientUseCase.Revoke(c.Param("clientId"))
+	if errors.Is(err, constants.ErrOAuthClientNotFound) {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to revoke OAuth client"})
+		return
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{Message: "OAuth client revoked"})
+}
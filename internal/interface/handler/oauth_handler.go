@@ -0,0 +1,207 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"web-server/internal/application/usecase"
+	"web-server/internal/domain/constants"
+	"web-server/internal/domain/entity"
+	"web-server/internal/infrastructure/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OAuthErrorResponse is the error response of the OAuth endpoints, see
+// RFC 6749 section 5.2
+type OAuthErrorResponse struct {
+	Error            string `json:"error" example:"invalid_client"`
+	ErrorDescription string `json:"error_description,omitempty" example:"invalid client credentials"`
+}
+
+// ClientTokenResponse is the access token response of the client credentials
+// grant, see RFC 6749 section 4.4.3
+type ClientTokenResponse struct {
+	AccessToken string `json:"access_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	TokenType   string `json:"token_type" example:"Bearer"`
+	ExpiresIn   int64  `json:"expires_in" example:"900"`
+	Scope       string `json:"scope" example:"users:read"`
+}
+
+// OAuthHandler handles the OAuth endpoints other services call with client
+// credentials
+type OAuthHandler struct {
+	clientUseCase *usecase.OAuthClientUseCase
+	tokens        *middleware.TokenManager
+}
+
+func NewOAuthHandler(clients *usecase.OAuthClientUseCase, tokens *middleware.TokenManager) *OAuthHandler {
+	return &OAuthHandler{
+		clientUseCase: clients,
+		tokens:        tokens,
+	}
+}
+
+// @Summary Request client token
+// @Description Exchange client credentials for an access token (RFC 6749 client credentials grant).
+// @Description The token has the subject client:<client_id> and the requested scopes, all scopes
+// @Description of the client by default. It cannot be refreshed. Authenticate like at /oauth/introspect.
+// @Tags oauth
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Security BasicAuth
+// @Param grant_type formData string true "client_credentials"
+// @Param scope formData string false "Space separated scopes"
+// @Success 200 {object} ClientTokenResponse
+// @Failure 400 {object} OAuthErrorResponse
+// @Failure 401 {object} OAuthErrorResponse
+// @Failure 500 {object} OAuthErrorResponse
+// @Router /oauth/token [post]
+func (h *OAuthHandler) Token(c *gin.Context) {
+	client, ok := h.authenticateClient(c)
+	if !ok {
+		return
+	}
+
+	switch c.PostForm("grant_type") {
+	case "client_credentials":
+	case "":
+		c.JSON(http.StatusBadRequest, OAuthErrorResponse{Error: "invalid_request", ErrorDescription: "grant_type is required"})
+		return
+	default:
+		c.JSON(http.StatusBadRequest, OAuthErrorResponse{Error: "unsupported_grant_type"})
+		return
+	}
+
+	scopes, err := h.clientUseCase.GrantScopes(client, c.PostForm("scope"))
+	if errors.Is(err, constants.ErrInvalidScope) {
+		c.JSON(http.StatusBadRequest, OAuthErrorResponse{Error: "invalid_scope", ErrorDescription: err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, OAuthErrorResponse{Error: "server_error"})
+		return
+	}
+
+	token, expiresIn, err := h.tokens.GenerateClientToken(client.Subject(), client.ID, scopes)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, OAuthErrorResponse{Error: "server_error"})
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+	c.JSON(http.StatusOK, ClientTokenResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   expiresIn,
+		Scope:       strings.Join(scopes, " "),
+	})
+}
+
+// @Summary Introspect token
+// @Description Report whether an access or refresh token is active and describe it (RFC 7662).
+// @Description Client tokens are only reported as active to the client they were issued to. User tokens
+// @Description are reported to clients with the introspect scope, or named in the token's audience.
+// @Description Authenticate with the client ID and secret as HTTP Basic credentials or as
+// @Description client_id and client_secret form fields.
+// @Tags oauth
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Security BasicAuth
+// @Param token formData string true "Token to introspect"
+// @Param token_type_hint formData string false "access_token or refresh_token"
+// @Success 200 {object} middleware.TokenIntrospection
+// @Failure 400 {object} OAuthErrorResponse
+// @Failure 401 {object} OAuthErrorResponse
+// @Failure 500 {object} OAuthErrorResponse
+// @Router /oauth/introspect [post]
+func (h *OAuthHandler) Introspect(c *gin.Context) {
+	client, ok := h.authenticateClient(c)
+	if !ok {
+		return
+	}
+
+	token := c.PostForm("token")
+	if token == "" {
+		c.JSON(http.StatusBadRequest, OAuthErrorResponse{Error: "invalid_request", ErrorDescription: "token is required"})
+		return
+	}
+
+	introspection, err := h.tokens.Introspect(token, c.PostForm("token_type_hint"), client)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, OAuthErrorResponse{Error: "server_error"})
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, introspection)
+}
+
+// @Summary Revoke token
+// @Description Revoke an access or refresh token (RFC 7009). Revoking a refresh token ends its session.
+// @Description Only tokens the calling client may introspect are revoked. Other, unknown and invalid
+// @Description tokens are accepted as well. Authenticate like at /oauth/introspect.
+// @Tags oauth
+// @Accept x-www-form-urlencoded
+// @Security BasicAuth
+// @Param token formData string true "Token to revoke"
+// @Param token_type_hint formData string false "access_token or refresh_token"
+// @Success 200
+// @Failure 400 {object} OAuthErrorResponse
+// @Failure 401 {object} OAuthErrorResponse
+// @Failure 500 {object} OAuthErrorResponse
+// @Router /oauth/revoke [post]
+func (h *OAuthHandler) Revoke(c *gin.Context) {
+	client, ok := h.authenticateClient(c)
+	if !ok {
+		return
+	}
+
+	token := c.PostForm("token")
+	if token == "" {
+		c.JSON(http.StatusBadRequest, OAuthErrorResponse{Error: "invalid_request", ErrorDescription: "token is required"})
+		return
+	}
+
+	if err := h.tokens.RevokeToken(token, c.PostForm("token_type_hint"), client); err != nil {
+		c.JSON(http.StatusInternalServerError, OAuthErrorResponse{Error: "server_error"})
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// authenticateClient checks the client credentials of the request and
+// responds with invalid_client if they are missing or wrong. HTTP Basic
+// credentials are form-urlencoded as required by RFC 6749 section 2.3.1.
+func (h *OAuthHandler) authenticateClient(c *gin.Context) (*entity.OAuthClient, bool) {
+	clientID, secret, basic := c.Request.BasicAuth()
+	if basic {
+		var idErr, secretErr error
+		clientID, idErr = url.QueryUnescape(clientID)
+		secret, secretErr = url.QueryUnescape(secret)
+		if idErr != nil || secretErr != nil {
+			clientID, secret = "", ""
+		}
+	} else {
+		clientID, secret = c.PostForm("client_id"), c.PostForm("client_secret")
+	}
+
+	client, err := h.clientUseCase.Authenticate(clientID, secret)
+	if errors.Is(err, constants.ErrInvalidClient) {
+		if basic {
+			c.Header("WWW-Authenticate", `Basic realm="oauth"`)
+		}
+		c.JSON(http.StatusUnauthorized, OAuthErrorResponse{Error: "invalid_client", ErrorDescription: err.Error()})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, OAuthErrorResponse{Error: "server_error"})
+		return nil, false
+	}
+
+	return client, true
+}
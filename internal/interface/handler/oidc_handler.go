@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"web-server/internal/application/usecase"
+	"web-server/internal/domain/constants"
+	"web-server/internal/infrastructure/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OIDCHandler handles HTTP requests of the login through an external OpenID
+// Connect provider
+type OIDCHandler struct {
+	oidcUseCase         *usecase.OIDCUseCase
+	mfaUseCase          *usecase.MFAUseCase
+	verificationUseCase *usecase.EmailVerificationUseCase
+	tokens              *middleware.TokenManager
+}
+
+func NewOIDCHandler(
+	oidc *usecase.OIDCUseCase,
+	mfa *usecase.MFAUseCase,
+	verification *usecase.EmailVerificationUseCase,
+	tokens *middleware.TokenManager,
+) *OIDCHandler {
+	return &OIDCHandler{
+		oidcUseCase:         oidc,
+		mfaUseCase:          mfa,
+		verificationUseCase: verification,
+		tokens:              tokens,
+	}
+}
+
+// @Summary Log in with the identity provider
+// @Description Redirect the browser to the login page of the configured OpenID Connect provider.
+// @Description The state of the login is kept in an HttpOnly cookie until the callback.
+// @Tags auth
+// @Success 302 "Redirect to the identity provider"
+// @Failure 502 {object} ErrorResponse "Identity provider unreachable"
+// @Router /public/users/login/oidc [get]
+func (h *OIDCHandler) Login(c *gin.Context) {
+	authURL, stateToken, err := h.oidcUseCase.Begin()
+	if err != nil {
+		_ = c.Error(err)
+		c.JSON(http.StatusBadGateway, ErrorResponse{Error: "Failed to reach the identity provider"})
+		return
+	}
+
+	middleware.SetOIDCStateCookie(c, stateToken)
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// @Summary Identity provider callback
+// @Description Complete a login at the identity provider with the code and state it redirected back with.
+// @Description The ID token is checked against the provider's keys. The external account is linked to the
+// @Description user with the same email, or a user is created, if the provider has verified the email.
+// @Description Responds like the password login, including MFA challenges and the cookie session mode.
+// @Tags auth
+// @Produce json
+// @Param code query string true "Authorization code"
+// @Param state query string true "State sent to the provider"
+// @Success 200 {object} LoginResponse
+// @Success 202 {object} MFAChallengeResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse "Login failed or was not started by this browser"
+// @Failure 403 {object} ErrorResponse "Email not verified by the provider, account disabled or password reset required"
+// @Failure 500 {object} ErrorResponse
+// @Router /public/users/login/oidc/callback [get]
+func (h *OIDCHandler) Callback(c *gin.Context) {
+	// The state can only be used once, whatever the outcome
+	stateToken, _ := c.Cookie(middleware.OIDCStateCookie)
+	middleware.ClearOIDCStateCookie(c)
+
+	if reason := c.Query("error"); reason != "" {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Login at the identity provider failed: " + reason})
+		return
+	}
+
+	code, state := c.Query("code"), c.Query("state")
+	if code == "" || state == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "code and state are required"})
+		return
+	}
+
+	user, err := h.oidcUseCase.Complete(stateToken, state, code)
+	switch {
+	case errors.Is(err, constants.ErrInvalidOIDCState),
+		errors.Is(err, constants.ErrInvalidIDToken),
+		errors.Is(err, constants.ErrOIDCCodeExchange):
+		_ = c.Error(err)
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Login at the identity provider failed"})
+		return
+	case errors.Is(err, constants.ErrExternalEmailNotVerified):
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: err.Error()})
+		return
+	case err != nil:
+		_ = c.Error(err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to complete the login"})
+		return
+	}
+
+	completeLogin(c, h.tokens, h.mfaUseCase, h.verificationUseCase, user)
+}
@@ -0,0 +1,174 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"web-server/internal/application/usecase"
+	"web-server/internal/domain/constants"
+	"web-server/internal/infrastructure/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ForgotPasswordRequest represents a request for a password reset email
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email" example:"user@example.com"`
+}
+
+// ResetPasswordRequest represents a password reset with the token from the email
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required" example:"correct-horse-battery"` // Checked against the password policy
+}
+
+// ChangePasswordRequest represents a password change by a logged in user
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required" example:"password123"`
+	NewPassword     string `json:"new_password" binding:"required" example:"correct-horse-battery"` // Checked against the password policy
+}
+
+// PasswordHandler handles HTTP requests related to changing and forgotten passwords
+type PasswordHandler struct {
+	resetUseCase *usecase.PasswordResetUseCase
+	userUseCase  *usecase.UserUseCase
+	tokens       *middleware.TokenManager
+}
+
+func NewPasswordHandler(
+	uc *usecase.PasswordResetUseCase,
+	users *usecase.UserUseCase,
+	tokens *middleware.TokenManager,
+) *PasswordHandler {
+	return &PasswordHandler{
+		resetUseCase: uc,
+		userUseCase:  users,
+		tokens:       tokens,
+	}
+}
+
+// @Summary Request a password reset
+// @Description Email a single-use password reset link. The response is the same whether or not the email belongs to an account.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body ForgotPasswordRequest true "Account email"
+// @Success 202 {object} MessageResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /public/users/password/forgot [post]
+func (h *PasswordHandler) ForgotPassword(c *gin.Context) {
+	var req ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if err := h.resetUseCase.RequestReset(req.Email); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to send password reset email"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, MessageResponse{Message: "If the email belongs to an account, a password reset link has been sent"})
+}
+
+// @Summary Reset password
+// @Description Set a new password with the token from the reset email. All sessions of the user are logged out.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body ResetPasswordRequest true "Reset token and new password"
+// @Success 200 {object} MessageResponse
+// @Failure 400 {object} ValidationErrorResponse "Invalid token or password rejected by the password policy"
+// @Failure 500 {object} ErrorResponse
+// @Router /public/users/password/reset [post]
+func (h *PasswordHandler) ResetPassword(c *gin.Context) {
+	var req ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	userID, err := h.resetUseCase.ResetPassword(req.Token, req.Password)
+	if errors.Is(err, constants.ErrInvalidResetToken) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if respondValidationError(c, err) {
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to reset password"})
+		return
+	}
+
+	// Whoever knew the old password must not stay logged in
+	if err := h.tokens.LogoutAll(userID); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to log out existing sessions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{Message: "Password has been reset"})
+}
+
+// @Summary Change password
+// @Description Replace the current user's password. Every other session of the user is logged out.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body ChangePasswordRequest true "Current and new password"
+// @Success 200 {object} MessageResponse
+// @Failure 400 {object} ValidationErrorResponse "Wrong current password or new password rejected by the password policy"
+// @Failure 500 {object} ErrorResponse
+// @Router /private/users/password/change [post]
+func (h *PasswordHandler) ChangePassword(c *gin.Context) {
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	userID := c.GetString("userID")
+	if err := h.userUseCase.ChangePassword(userID, req.CurrentPassword, req.NewPassword); err != nil {
+		if !respondValidationError(c, err) {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to change password"})
+		}
+		return
+	}
+
+	if err := h.logoutOtherSessions(c, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to log out other sessions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{Message: "Password has been changed"})
+}
+
+// logoutOtherSessions revokes every session of the user except the one the
+// request was made with. Requests authenticated with an API key have no
+// session, so all sessions are revoked.
+func (h *PasswordHandler) logoutOtherSessions(c *gin.Context, userID string) error {
+	var current string
+	if value, ok := c.Get("claims"); ok {
+		if claims, ok := value.(*middleware.JWTClaims); ok {
+			current = claims.SessionID
+		}
+	}
+
+	sessions, err := h.tokens.ListSessions(userID)
+	if err != nil {
+		return err
+	}
+
+	for _, session := range sessions {
+		if session.ID == current {
+			continue
+		}
+		if err := h.tokens.RevokeSession(userID, session.ID); err != nil && !errors.Is(err, constants.ErrSessionNotFound) {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -1,5 +1,14 @@
 package handler
 
+import (
+	"errors"
+	"net/http"
+
+	"web-server/internal/domain/entity"
+
+	"github.com/gin-gonic/gin"
+)
+
 // ErrorResponse represents an error response
 type ErrorResponse struct {
 	Error string `json:"error" example:"Error message here"`
@@ -9,3 +18,22 @@ type ErrorResponse struct {
 type MessageResponse struct {
 	Message string `json:"message" example:"Operation completed successfully"`
 }
+
+// ValidationErrorResponse represents a request rejected because of problems
+// with some of its fields
+type ValidationErrorResponse struct {
+	Error  string              `json:"error" example:"validation failed"`
+	Fields map[string][]string `json:"fields"` // Problems by field name
+}
+
+// respondValidationError responds with the field errors if err is an
+// *entity.ValidationError, and reports whether it did.
+func respondValidationError(c *gin.Context, err error) bool {
+	var validationErr *entity.ValidationError
+	if !errors.As(err, &validationErr) {
+		return false
+	}
+
+	c.JSON(http.StatusBadRequest, ValidationErrorResponse{Error: "validation failed", Fields: validationErr.Fields})
+	return true
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"web-server/internal/application/usecase"
+	"web-server/internal/domain/constants"
+	"web-server/internal/domain/entity"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RolesResponse represents the roles and the permissions they are granted
+type RolesResponse struct {
+	Roles       map[string][]string `json:"roles"`
+	Permissions []string            `json:"permissions" example:"users:read"` // Every permission that can be granted
+}
+
+// RolePermissionsRequest represents the permissions to grant a role
+type RolePermissionsRequest struct {
+	Permissions []string `json:"permissions" example:"users:read"`
+}
+
+// RoleHandler handles HTTP requests related to roles and permissions
+type RoleHandler struct {
+	permissionUseCase *usecase.PermissionUseCase
+}
+
+func NewRoleHandler(uc *usecase.PermissionUseCase) *RoleHandler {
+	return &RoleHandler{
+		permissionUseCase: uc,
+	}
+}
+
+// @Summary List roles
+// @Description List every role with the permissions it is granted (requires roles:read)
+// @Tags roles
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} RolesResponse
+// @Failure 403 {object} constants.ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /private/users/admin/roles [get]
+func (h *RoleHandler) ListRoles(c *gin.Context) {
+	roles, err := h.permissionUseCase.Roles()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to list roles"})
+		return
+	}
+
+	c.JSON(http.StatusOK, RolesResponse{
+		Roles:       roles,
+		Permissions: entity.Permissions(),
+	})
+}
+
+// @Summary Set role permissions
+// @Description Grant a role exactly the given permissions, creating the role if needed (requires roles:write).
+// @Description The permissions replace those configured for the role. The admin role cannot be changed.
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param role path string true "Role"
+// @Param permissions body RolePermissionsRequest true "Permissions"
+// @Success 200 {object} RolesResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} constants.ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /private/users/admin/roles/{role} [put]
+func (h *RoleHandler) SetRolePermissions(c *gin.Context) {
+	var req RolePermissionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if err := h.permissionUseCase.SetRolePermissions(c.Param("role"), req.Permissions); err != nil {
+		respondRoleError(c, err)
+		return
+	}
+
+	h.ListRoles(c)
+}
+
+// @Summary Reset role permissions
+// @Description Remove the stored permissions of a role so that the configured ones apply again (requires roles:write)
+// @Tags roles
+// @Produce json
+// @Security BearerAuth
+// @Param role path string true "Role"
+// @Success 200 {object} RolesResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} constants.ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /private/users/admin/roles/{role} [delete]
+func (h *RoleHandler) ResetRolePermissions(c *gin.Context) {
+	if err := h.permissionUseCase.ResetRolePermissions(c.Param("role")); err != nil {
+		respondRoleError(c, err)
+		return
+	}
+
+	h.ListRoles(c)
+}
+
+// respondRoleError maps role errors to HTTP responses.
+func respondRoleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, constants.ErrInvalidPermission),
+		errors.Is(err, constants.ErrUnknownRole),
+		errors.Is(err, constants.ErrBuiltInRole):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to update role"})
+	}
+}
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"web-server/internal/application/usecase"
+	"web-server/internal/domain/constants"
+	"web-server/internal/domain/entity"
+	"web-server/internal/infrastructure/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SessionHandler handles HTTP requests related to login sessions
+type SessionHandler struct {
+	policy *usecase.UserPolicy
+	tokens *middleware.TokenManager
+}
+
+func NewSessionHandler(policy *usecase.UserPolicy, tokens *middleware.TokenManager) *SessionHandler {
+	return &SessionHandler{
+		policy: policy,
+		tokens: tokens,
+	}
+}
+
+// @Summary List sessions
+// @Description List the devices a user is logged in on. Users can list their own sessions, users:read those of any user.
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Success 200 {array} entity.Session
+// @Failure 403 {object} constants.ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /private/users/{id}/sessions [get]
+func (h *SessionHandler) ListSessions(c *gin.Context) {
+	userID := c.Param("id")
+	if !authorizeUser(c, h.policy, userID, entity.PermissionUsersRead) {
+		return
+	}
+
+	sessions, err := h.tokens.ListSessions(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to list sessions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+// @Summary Revoke session
+// @Description Log a user out on one device. Users can revoke their own sessions, users:write those of any user.
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Param sessionId path string true "Session ID"
+// @Success 200 {object} MessageResponse
+// @Failure 403 {object} constants.ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /private/users/{id}/sessions/{sessionId} [delete]
+func (h *SessionHandler) RevokeSession(c *gin.Context) {
+	userID := c.Param("id")
+	if !authorizeUser(c, h.policy, userID, entity.PermissionUsersWrite) {
+		return
+	}
+
+	err := h.tokens.RevokeSession(userID, c.Param("sessionId"))
+	if errors.Is(err, constants.ErrSessionNotFound) {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Session not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to revoke session"})
+		return
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{Message: "Session revoked"})
+}
@@ -0,0 +1,227 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"web-server/internal/application/usecase"
+	"web-server/internal/domain/constants"
+	"web-server/internal/domain/entity"
+	"web-server/internal/infrastructure/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ChangeRoleRequest represents the new role of a user
+type ChangeRoleRequest struct {
+	Role string `json:"role" binding:"required" example:"support"`
+}
+
+// ImpersonateRequest represents the reason an admin gives for acting as a user
+type ImpersonateRequest struct {
+	Reason string `json:"reason" binding:"max=500" example:"Reproducing support ticket #4521"` // Optional, recorded in the audit log
+}
+
+// ImpersonationResponse represents an access token to act as a user with
+type ImpersonationResponse struct {
+	AccessToken    string `json:"access_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	ExpiresIn      int64  `json:"expires_in" example:"900"` // 15 minutes in seconds, cannot be refreshed
+	ImpersonatorID string `json:"impersonator_id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	User           struct {
+		ID       string `json:"id" example:"123e4567-e89b-12d3-a456-426614174001"`
+		Username string `json:"username" example:"johndoe"`
+		Email    string `json:"email" example:"user@example.com"`
+		Role     string `json:"role" example:"user"`
+	} `json:"user"`
+}
+
+// UserAdminHandler handles the HTTP requests admins manage user accounts with
+type UserAdminHandler struct {
+	adminUseCase *usecase.UserAdminUseCase
+	tokens       *middleware.TokenManager
+}
+
+func NewUserAdminHandler(uc *usecase.UserAdminUseCase, tokens *middleware.TokenManager) *UserAdminHandler {
+	return &UserAdminHandler{
+		adminUseCase: uc,
+		tokens:       tokens,
+	}
+}
+
+// @Summary Change a user's role
+// @Description Promote or demote a user (requires roles:write). The user is logged out everywhere,
+// @Description since existing tokens carry the old role. Admins cannot change their own role, nor the role
+// @Description of users with permissions they lack, nor hand out such roles.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Param role body ChangeRoleRequest true "New role"
+// @Success 200 {object} entity.User
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} constants.ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /private/users/admin/{id}/role [put]
+func (h *UserAdminHandler) ChangeRole(c *gin.Context) {
+	var req ChangeRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	user, err := h.adminUseCase.ChangeRole(actorFrom(c), c.Param("id"), req.Role)
+	h.respond(c, user, err)
+}
+
+// @Summary Disable a user
+// @Description Block logins and API keys of a user and log them out everywhere (requires users:write)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Success 200 {object} entity.User
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} constants.ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /private/users/admin/{id}/disable [post]
+func (h *UserAdminHandler) DisableUser(c *gin.Context) {
+	user, err := h.adminUseCase.SetDisabled(actorFrom(c), c.Param("id"), true)
+	h.respond(c, user, err)
+}
+
+// @Summary Enable a user
+// @Description Allow a disabled user to log in again (requires users:write)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Success 200 {object} entity.User
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} constants.ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /private/users/admin/{id}/enable [post]
+func (h *UserAdminHandler) EnableUser(c *gin.Context) {
+	user, err := h.adminUseCase.SetDisabled(actorFrom(c), c.Param("id"), false)
+	h.respond(c, user, err)
+}
+
+// @Summary Force a password reset
+// @Description Email a user a reset link and block their logins until they choose a new password.
+// @Description The user is logged out everywhere (requires users:write).
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Success 200 {object} entity.User
+// @Failure 403 {object} constants.ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /private/users/admin/{id}/password-reset [post]
+func (h *UserAdminHandler) ForcePasswordReset(c *gin.Context) {
+	user, err := h.adminUseCase.ForcePasswordReset(actorFrom(c), c.Param("id"))
+	h.respond(c, user, err)
+}
+
+// @Summary List a user's audit log
+// @Description List the role changes and other administrative actions on a user, with the acting admin (requires users:read)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Success 200 {array} entity.AuditEntry
+// @Failure 403 {object} constants.ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /private/users/admin/{id}/audit [get]
+func (h *UserAdminHandler) ListAuditLog(c *gin.Context) {
+	entries, err := h.adminUseCase.AuditLog(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to list audit log"})
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
+// @Summary Impersonate a user
+// @Description Issue a short-lived access token to act as a user, e.g. to reproduce what they see
+// @Description (requires users:impersonate). The token names the admin in its act claim, is recorded
+// @Description in the user's audit log and cannot change the password, MFA, API keys or sessions of
+// @Description the user or reach admin routes. Admins and disabled users cannot be impersonated.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Param request body ImpersonateRequest false "Reason for the audit log"
+// @Success 200 {object} ImpersonationResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse "User cannot be impersonated"
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /private/users/admin/{id}/impersonate [post]
+func (h *UserAdminHandler) Impersonate(c *gin.Context) {
+	var req ImpersonateRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	actor := actorFrom(c)
+	user, err := h.adminUseCase.Impersonate(actor, c.Param("id"), req.Reason)
+	switch {
+	case errors.Is(err, constants.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "User not found"})
+		return
+	case errors.Is(err, constants.ErrNotImpersonatable):
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to impersonate user"})
+		return
+	}
+
+	token, expiresIn, err := h.tokens.GenerateImpersonationToken(user.ID, user.Role, actor.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to generate token"})
+		return
+	}
+
+	response := ImpersonationResponse{
+		AccessToken:    token,
+		ExpiresIn:      expiresIn,
+		ImpersonatorID: actor.UserID,
+	}
+	response.User.ID = user.ID
+	response.User.Username = user.Username
+	response.User.Email = user.Email
+	response.User.Role = user.Role
+
+	c.JSON(http.StatusOK, response)
+}
+
+// respond responds with the changed user. The use case has already ended the
+// sessions of the user, or undone the change if it could not.
+func (h *UserAdminHandler) respond(c *gin.Context, user *entity.User, err error) {
+	switch {
+	case errors.Is(err, constants.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "User not found"})
+		return
+	case errors.Is(err, constants.ErrUnknownRole), errors.Is(err, constants.ErrSelfModification):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	case errors.Is(err, constants.ErrAccessDenied):
+		c.JSON(http.StatusForbidden, constants.ErrInsufficientPermissions())
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to update user"})
+		return
+	}
+
+	user.Password = ""
+	c.JSON(http.StatusOK, user)
+}
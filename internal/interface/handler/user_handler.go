@@ -1,9 +1,14 @@
 package handler
 
 import (
+	"errors"
+	"io"
+	"math"
 	"net/http"
+	"strconv"
 
 	"web-server/internal/application/usecase"
+	"web-server/internal/domain/constants"
 	"web-server/internal/domain/entity"
 	"web-server/internal/infrastructure/middleware"
 
@@ -11,30 +16,62 @@ import (
 	"github.com/google/uuid"
 )
 
+// RegisterRequest represents the registration request payload. New users
+// always get the user role.
+type RegisterRequest struct {
+	Username string `json:"username" binding:"required,min=3,max=50" example:"johndoe"`
+	Email    string `json:"email" binding:"required,email" example:"user@example.com"`
+	Password string `json:"password" binding:"required" example:"correct-horse-battery"` // Checked against the password policy
+}
+
 // LoginRequest represents the login request payload
 type LoginRequest struct {
 	Email    string `json:"email" binding:"required,email" example:"user@example.com"`
 	Password string `json:"password" binding:"required" example:"password123"`
 }
 
+// UpdateUserRequest represents a change of a user's details. Only the user can
+// change their own password, and only with the current one.
+type UpdateUserRequest struct {
+	Username        string `json:"username" binding:"required,min=3,max=50" example:"johndoe"`
+	Email           string `json:"email" binding:"required,email" example:"user@example.com"`
+	Password        string `json:"password,omitempty" example:"correct-horse-battery"` // Optional, checked against the password policy
+	CurrentPassword string `json:"current_password,omitempty"`                         // Required with password
+}
+
 // RefreshRequest represents the refresh token request payload
 type RefreshRequest struct {
-	RefreshToken string `json:"refresh_token" binding:"required"`
+	RefreshToken string `json:"refresh_token"` // Required unless the refresh token cookie is set
+}
+
+// LogoutRequest represents the logout request payload
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"` // Optional, also revokes the refresh token family
 }
 
 // LoginResponse represents the login response
 type LoginResponse struct {
-	AccessToken  string `json:"access_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
-	RefreshToken string `json:"refresh_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
-	ExpiresIn    int64  `json:"expires_in" example:"900"` // 15 minutes in seconds
+	AccessToken  string `json:"access_token,omitempty" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`  // Left out in the cookie session mode
+	RefreshToken string `json:"refresh_token,omitempty" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."` // Left out in the cookie session mode
+	ExpiresIn    int64  `json:"expires_in" example:"900"`                                                  // 15 minutes in seconds
+	CSRFToken    string `json:"csrf_token,omitempty" example:"q3JxZ2V0Y3NyZnRva2VuZXhhbXBsZQ"`             // Only set in the cookie session mode
 	User         struct {
-		ID       string `json:"id" example:"123e4567-e89b-12d3-a456-426614174000"`
-		Username string `json:"username" example:"johndoe"`
-		Email    string `json:"email" example:"user@example.com"`
-		Role     string `json:"role" example:"user"`
+		ID            string `json:"id" example:"123e4567-e89b-12d3-a456-426614174000"`
+		Username      string `json:"username" example:"johndoe"`
+		Email         string `json:"email" example:"user@example.com"`
+		Role          string `json:"role" example:"user"`
+		EmailVerified bool   `json:"email_verified" example:"true"`
 	} `json:"user"`
 }
 
+// MFAChallengeResponse is returned by login instead of tokens when a second
+// factor is required
+type MFAChallengeResponse struct {
+	MFARequired        bool   `json:"mfa_required" example:"true"`
+	MFAToken           string `json:"mfa_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	EnrollmentRequired bool   `json:"enrollment_required" example:"false"` // The user's role requires MFA but it is not set up yet
+}
+
 // UserResponse represents the user response for swagger documentation
 // @Description User response model
 type UserResponse struct {
@@ -45,53 +82,87 @@ type UserResponse struct {
 
 // UserHandler handles HTTP requests related to users
 type UserHandler struct {
-	userUseCase *usecase.UserUseCase
+	userUseCase         *usecase.UserUseCase
+	mfaUseCase          *usecase.MFAUseCase
+	verificationUseCase *usecase.EmailVerificationUseCase
+	throttleUseCase     *usecase.LoginThrottleUseCase
+	apiKeyUseCase       *usecase.APIKeyUseCase
+	policy              *usecase.UserPolicy
+	tokens              *middleware.TokenManager
 }
 
-func NewUserHandler(uc *usecase.UserUseCase) *UserHandler {
+func NewUserHandler(
+	uc *usecase.UserUseCase,
+	mfa *usecase.MFAUseCase,
+	verification *usecase.EmailVerificationUseCase,
+	throttle *usecase.LoginThrottleUseCase,
+	apiKeys *usecase.APIKeyUseCase,
+	policy *usecase.UserPolicy,
+	tokens *middleware.TokenManager,
+) *UserHandler {
 	return &UserHandler{
-		userUseCase: uc,
+		userUseCase:         uc,
+		mfaUseCase:          mfa,
+		verificationUseCase: verification,
+		throttleUseCase:     throttle,
+		apiKeyUseCase:       apiKeys,
+		policy:              policy,
+		tokens:              tokens,
 	}
 }
 
 // @Summary Create a new user
-// @Description Create a new user with the provided details
+// @Description Create a new user with the provided details and email them a verification link
 // @Tags users
 // @Accept json
 // @Produce json
-// @Param user body entity.User true "User details"
+// @Param user body RegisterRequest true "User details"
 // @Success 201 {object} UserResponse
-// @Failure 400 {object} ErrorResponse
+// @Failure 400 {object} ValidationErrorResponse "Invalid request or password rejected by the password policy"
 // @Failure 500 {object} ErrorResponse
 // @Router /public/users/register [post]
 func (h *UserHandler) CreateUser(c *gin.Context) {
-	var user entity.User
-	if err := c.ShouldBindJSON(&user); err != nil {
+	var req RegisterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 		return
 	}
 
-	// Generate UUID
-	user.ID = uuid.New().String()
+	// Roles are only given by admins, see UserAdminHandler
+	user := *entity.NewUser(req.Username, req.Email, req.Password)
 
-	// Set default role if not provided
-	if user.Role == "" {
-		user.Role = "user"
+	if err := h.userUseCase.ValidatePassword(user.Password, &user); err != nil {
+		if !respondValidationError(c, err) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check password"})
+		}
+		return
 	}
 
+	// Generate UUID
+	user.ID = uuid.New().String()
+
 	// Hash password
-	hashedPassword, err := entity.HashPassword(user.Password)
+	hashedPassword, err := h.userUseCase.HashPassword(user.Password)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process password"})
 		return
 	}
 	user.Password = hashedPassword
 
+	// Only a verification link can mark the email as verified
+	user.EmailVerified = false
+
 	if err := h.userUseCase.CreateUser(&user); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	// The account exists at this point, so a failed email is only logged and
+	// the user can ask for another link
+	if err := h.verificationUseCase.SendVerification(&user); err != nil {
+		_ = c.Error(err)
+	}
+
 	// Don't return the password in the response
 	user.Password = ""
 	c.JSON(http.StatusCreated, user)
@@ -103,10 +174,15 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 // @Produce json
 // @Param id path string true "User ID"
 // @Success 200 {object} UserResponse "User details"
+// @Failure 403 {object} constants.ErrorResponse "Not the user's own account"
 // @Failure 404 {object} gin.H "User not found"
 // @Router /users/{id} [get]
 func (h *UserHandler) GetUser(c *gin.Context) {
 	id := c.Param("id")
+	if !authorizeUser(c, h.policy, id, entity.PermissionUsersRead) {
+		return
+	}
+
 	user, err := h.userUseCase.GetUser(id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
@@ -117,14 +193,21 @@ func (h *UserHandler) GetUser(c *gin.Context) {
 }
 
 // @Summary Login user
-// @Description Login with email and password to get JWT tokens
+// @Description Login with email and password to get JWT tokens. If the user needs a second
+// @Description factor, an MFA challenge token is returned instead, see /public/users/login/mfa.
+// @Description With the X-Session-Mode: cookie header the tokens are set as HttpOnly cookies
+// @Description and the response carries the CSRF token instead.
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param credentials body LoginRequest true "Login credentials"
+// @Param X-Session-Mode header string false "cookie to receive the tokens as cookies"
 // @Success 200 {object} LoginResponse
+// @Success 202 {object} MFAChallengeResponse
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse "Email address is not verified, account disabled or password reset required"
+// @Failure 429 {object} constants.ErrorResponse "Too many failed login attempts"
 // @Router /public/users/login [post]
 func (h *UserHandler) LoginUser(c *gin.Context) {
 	var loginRequest LoginRequest
@@ -134,102 +217,477 @@ func (h *UserHandler) LoginUser(c *gin.Context) {
 		return
 	}
 
-	user, err := h.userUseCase.GetUserByEmail(loginRequest.Email)
+	// Throttled before the user is looked up, so that the response is the
+	// same whether or not the account exists. The attempt counts as a failure
+	// until the credentials turn out to be valid.
+	retryAfter, err := h.throttleUseCase.Attempt(loginRequest.Email, c.ClientIP())
+	if errors.Is(err, constants.ErrTooManyLoginAttempts) {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, constants.ErrLoginLockedOut())
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check login attempts"})
 		return
 	}
 
-	// Verify password
-	if !entity.CheckPassword(loginRequest.Password, user.Password) {
+	user, err := h.userUseCase.Authenticate(loginRequest.Email, loginRequest.Password)
+	if errors.Is(err, constants.ErrInvalidCredentials) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
 		return
 	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check credentials"})
+		return
+	}
+
+	if err := h.throttleUseCase.RecordSuccess(loginRequest.Email, c.ClientIP()); err != nil {
+		_ = c.Error(err)
+	}
+
+	// The plaintext password is only available here, so hashes made with an
+	// outdated algorithm or parameters are upgraded on login. A failed upgrade
+	// is retried on the next login.
+	if err := h.userUseCase.UpgradePasswordHash(user, loginRequest.Password); err != nil {
+		_ = c.Error(err)
+	}
+
+	completeLogin(c, h.tokens, h.mfaUseCase, h.verificationUseCase, user)
+}
+
+// completeLogin finishes a login once the user has proven who they are. It
+// responds with the token pair, or with an MFA challenge if the user needs a
+// second factor.
+func completeLogin(
+	c *gin.Context,
+	tokens *middleware.TokenManager,
+	mfa *usecase.MFAUseCase,
+	verification *usecase.EmailVerificationUseCase,
+	user *entity.User,
+) {
+	if err := checkAccountStatus(user); err != nil {
+		respondLoginError(c, err)
+		return
+	}
+
+	if _, err := verification.CheckLogin(user); err != nil {
+		respondLoginError(c, err)
+		return
+	}
+
+	// Ask for the second factor before issuing any tokens
+	mfaRequired, err := mfa.IsRequired(user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check MFA"})
+		return
+	}
+
+	if mfaRequired {
+		mfaToken, err := tokens.GenerateMFAToken(user.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate tokens"})
+			return
+		}
+
+		c.JSON(http.StatusAccepted, MFAChallengeResponse{
+			MFARequired:        true,
+			MFAToken:           mfaToken,
+			EnrollmentRequired: !user.MFA.Enabled,
+		})
+		return
+	}
 
 	// Generate JWT tokens
-	tokens, err := middleware.GenerateTokenPair(user.ID, user.Role)
+	pair, err := issueLoginTokens(c, tokens, verification, user)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate tokens"})
+		respondLoginError(c, err)
 		return
 	}
 
+	c.JSON(http.StatusOK, newLoginResponse(user, pair))
+}
+
+// issueLoginTokens issues the token pair and session of a completed login. Users
+// who have not verified their email get restricted tokens if the configuration
+// asks for it. Clients that asked for the cookie session mode get the tokens
+// as cookies.
+func issueLoginTokens(
+	c *gin.Context,
+	tokens *middleware.TokenManager,
+	verification *usecase.EmailVerificationUseCase,
+	user *entity.User,
+) (*middleware.TokenPair, error) {
+	if err := checkAccountStatus(user); err != nil {
+		return nil, err
+	}
+
+	restricted, err := verification.CheckLogin(user)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []middleware.TokenOption{
+		middleware.WithClient(c.Request.UserAgent(), c.ClientIP()),
+	}
+	if restricted {
+		opts = append(opts, middleware.WithEmailUnverified())
+	}
+
+	pair, err := tokens.GenerateTokenPair(user.ID, user.Role, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return deliverTokens(c, tokens, pair, middleware.WantsSessionCookies(c)), nil
+}
+
+// deliverTokens returns the token pair for the response body. In the cookie
+// session mode the tokens are set as cookies instead and only the expiry and
+// the CSRF token of the session remain in the body.
+func deliverTokens(c *gin.Context, tokens *middleware.TokenManager, pair *middleware.TokenPair, cookies bool) *middleware.TokenPair {
+	if !cookies {
+		return pair
+	}
+
+	return &middleware.TokenPair{
+		ExpiresIn: pair.ExpiresIn,
+		CSRFToken: tokens.SetSessionCookies(c, pair),
+		SessionID: pair.SessionID,
+	}
+}
+
+// checkAccountStatus returns an error if admins have blocked logins to the account.
+func checkAccountStatus(user *entity.User) error {
+	if user.Disabled {
+		return constants.ErrAccountDisabled
+	}
+	if user.PasswordResetRequired {
+		return constants.ErrPasswordResetRequired
+	}
+	return nil
+}
+
+// respondLoginError maps errors of the last login steps to HTTP responses.
+func respondLoginError(c *gin.Context, err error) {
+	if errors.Is(err, constants.ErrEmailNotVerified) ||
+		errors.Is(err, constants.ErrAccountDisabled) ||
+		errors.Is(err, constants.ErrPasswordResetRequired) {
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to generate tokens"})
+}
+
+// newLoginResponse builds the response for a completed login.
+func newLoginResponse(user *entity.User, tokens *middleware.TokenPair) LoginResponse {
 	response := LoginResponse{
 		AccessToken:  tokens.AccessToken,
 		RefreshToken: tokens.RefreshToken,
 		ExpiresIn:    tokens.ExpiresIn,
+		CSRFToken:    tokens.CSRFToken,
 	}
 	response.User.ID = user.ID
 	response.User.Username = user.Username
 	response.User.Email = user.Email
 	response.User.Role = user.Role
+	response.User.EmailVerified = user.EmailVerified
 
-	c.JSON(http.StatusOK, response)
+	return response
 }
 
 // @Summary Refresh access token
-// @Description Get new access token using refresh token
+// @Description Get new access token using refresh token. In the cookie session mode the
+// @Description refresh token is read from its cookie and the new tokens are set as cookies.
 // @Tags auth
 // @Accept json
 // @Produce json
-// @Param refresh_token body RefreshRequest true "Refresh token"
+// @Param refresh_token body RefreshRequest false "Refresh token"
 // @Success 200 {object} LoginResponse
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
 // @Router /public/users/refresh [post]
 func (h *UserHandler) RefreshToken(c *gin.Context) {
 	var req RefreshRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	tokens, err := middleware.RefreshToken(req.RefreshToken)
+	// Browsers in the cookie session mode send the refresh token as a cookie
+	// and get the new pair as cookies too
+	refreshToken, fromCookie := req.RefreshToken, false
+	if refreshToken == "" {
+		refreshToken, _ = c.Cookie(middleware.RefreshTokenCookie)
+		fromCookie = true
+	}
+	if refreshToken == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "refresh_token is required"})
+		return
+	}
+
+	tokens, err := h.tokens.RefreshToken(refreshToken)
+	if err != nil {
+		if isRefreshTokenError(err) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh tokens"})
+		return
+	}
+
+	c.JSON(http.StatusOK, deliverTokens(c, h.tokens, tokens, fromCookie || middleware.WantsSessionCookies(c)))
+}
+
+// @Summary Logout
+// @Description Revoke the current access token and, if given, the refresh token
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param refresh_token body LogoutRequest false "Refresh token to revoke"
+// @Success 200 {object} MessageResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /private/users/logout [post]
+func (h *UserHandler) Logout(c *gin.Context) {
+	var req LogoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	// Requests authenticated with an API key have no token to revoke
+	value, _ := c.Get("claims")
+	claims, ok := value.(*middleware.JWTClaims)
+	if !ok {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Logout requires a bearer token"})
+		return
+	}
+
+	if err := h.tokens.Logout(claims, req.RefreshToken); err != nil {
+		if errors.Is(err, constants.ErrInvalidRefreshToken) {
+			c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Invalid refresh token"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to logout"})
+		return
+	}
+
+	if middleware.HasSessionCookies(c) {
+		middleware.ClearSessionCookies(c)
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{Message: "Logged out successfully"})
+}
+
+// @Summary Logout everywhere
+// @Description Revoke every access and refresh token of the current user
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} MessageResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /private/users/logout/all [post]
+func (h *UserHandler) LogoutAll(c *gin.Context) {
+	if middleware.HasSessionCookies(c) {
+		middleware.ClearSessionCookies(c)
+	}
+	h.logoutAll(c, c.GetString("userID"))
+}
+
+// @Summary Logout a user everywhere
+// @Description Revoke every access and refresh token of the given user (admin only). Only allowed for users
+// @Description whose role has no permissions beyond the caller's.
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Success 200 {object} MessageResponse
+// @Failure 403 {object} constants.ErrorResponse "User has more permissions than the caller"
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /private/users/admin/{id}/logout [post]
+func (h *UserHandler) LogoutUserEverywhere(c *gin.Context) {
+	id := c.Param("id")
+	if !authorizeUser(c, h.policy, id, entity.PermissionUsersWrite) {
+		return
+	}
+	h.logoutAll(c, id)
+}
+
+func (h *UserHandler) logoutAll(c *gin.Context, userID string) {
+	if err := h.tokens.LogoutAll(userID); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to logout"})
+		return
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{Message: "Logged out of all sessions"})
+}
+
+// @Summary Unlock a user's login
+// @Description Clear the failed login attempts and lockout of a user's account (admin only). Only allowed for
+// @Description users whose role has no permissions beyond the caller's.
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Success 200 {object} MessageResponse
+// @Failure 403 {object} constants.ErrorResponse "User has more permissions than the caller"
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /private/users/admin/{id}/unlock [post]
+func (h *UserHandler) UnlockUser(c *gin.Context) {
+	id := c.Param("id")
+	if !authorizeUser(c, h.policy, id, entity.PermissionUsersWrite) {
+		return
+	}
+
+	user, err := h.userUseCase.GetUser(id)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "User not found"})
 		return
 	}
 
-	c.JSON(http.StatusOK, tokens)
+	if err := h.throttleUseCase.Unlock(user.Email); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to unlock user"})
+		return
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{Message: "User unlocked"})
+}
+
+// isRefreshTokenError reports whether err means the refresh token itself was rejected.
+func isRefreshTokenError(err error) bool {
+	return errors.Is(err, constants.ErrInvalidRefreshToken) ||
+		errors.Is(err, constants.ErrInvalidTokenType) ||
+		errors.Is(err, constants.ErrRefreshTokenReused) ||
+		errors.Is(err, constants.ErrRefreshTokenRevoked)
 }
 
 // @Summary Update a user
-// @Description Update user details by their ID
+// @Description Update the username and email of a user by their ID. Users with `users:write` can only
+// @Description update accounts whose role has no permissions beyond their own, and cannot change passwords.
+// @Description Changing the password needs the current one. Changing the password or the email logs the user
+// @Description out everywhere.
 // @Tags users
 // @Accept json
 // @Produce json
 // @Param id path string true "User ID"
-// @Param user body entity.User true "User details"
+// @Param user body UpdateUserRequest true "User details"
 // @Success 200 {object} UserResponse "User updated successfully"
-// @Failure 400 {object} gin.H "Bad request"
+// @Failure 400 {object} ValidationErrorResponse "Bad request, wrong current password or password rejected by the password policy"
+// @Failure 403 {object} constants.ErrorResponse "Not allowed to update the account or its password"
+// @Failure 404 {object} ErrorResponse "User not found"
 // @Failure 500 {object} gin.H "Internal server error"
 // @Router /users/{id} [put]
 func (h *UserHandler) UpdateUser(c *gin.Context) {
 	id := c.Param("id")
-	var user entity.User
-	if err := c.ShouldBindJSON(&user); err != nil {
+	if !authorizeUser(c, h.policy, id, entity.PermissionUsersWrite) {
+		return
+	}
+
+	var req UpdateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	user.ID = id // Ensure the ID matches the URL parameter
+	existing, err := h.userUseCase.GetUser(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Role, status and MFA settings are only changed through their own endpoints
+	user := *existing
+	user.Username = req.Username
+	user.Email = req.Email
+
+	passwordChanged := req.Password != ""
+	if passwordChanged {
+		// Whoever else may edit the account must not be able to take it over
+		if actorFrom(c).UserID != id {
+			c.JSON(http.StatusForbidden, constants.ErrInsufficientPermissions())
+			return
+		}
+
+		if !h.userUseCase.CheckPassword(existing, req.CurrentPassword) {
+			respondValidationError(c, entity.NewValidationError("current_password", "is incorrect"))
+			return
+		}
+
+		if err := h.userUseCase.ValidatePassword(req.Password, &user); err != nil {
+			if !respondValidationError(c, err) {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check password"})
+			}
+			return
+		}
+
+		hashedPassword, err := h.userUseCase.HashPassword(req.Password)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process password"})
+			return
+		}
+		user.Password = hashedPassword
+	}
+
+	// A new address has to be verified again
+	emailChanged := user.Email != existing.Email
+	user.EmailVerified = existing.EmailVerified && !emailChanged
+
 	if err := h.userUseCase.UpdateUser(&user); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	// Tokens obtained with the old password or through the old address must
+	// not outlive the change
+	if passwordChanged || emailChanged {
+		if err := h.tokens.LogoutAll(id); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "User updated, but failed to log out the user's sessions"})
+			return
+		}
+	}
+
+	if emailChanged {
+		if err := h.verificationUseCase.SendVerification(&user); err != nil {
+			_ = c.Error(err)
+		}
+	}
+
+	// Don't return the password hash in the response
+	user.Password = ""
 	c.JSON(http.StatusOK, user)
 }
 
 // @Summary Delete a user
-// @Description Delete a user by their ID
+// @Description Delete a user by their ID. The user is logged out everywhere and their API keys are revoked
+// @Description first.
 // @Tags users
 // @Produce json
 // @Param id path string true "User ID"
 // @Success 200 {object} gin.H "User deleted successfully"
+// @Failure 403 {object} constants.ErrorResponse "Not the user's own account"
 // @Failure 500 {object} gin.H "Internal server error"
 // @Router /users/{id} [delete]
 func (h *UserHandler) DeleteUser(c *gin.Context) {
 	id := c.Param("id")
+	if !authorizeUser(c, h.policy, id, entity.PermissionUsersDelete) {
+		return
+	}
+
+	// Refresh tokens and API keys are not tied to the user record, so they
+	// are revoked before it goes
+	if err := h.tokens.LogoutAll(id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out the user's sessions"})
+		return
+	}
+	if err := h.apiKeyUseCase.RevokeAll(id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke the user's API keys"})
+		return
+	}
+
 	if err := h.userUseCase.DeleteUser(id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return